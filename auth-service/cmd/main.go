@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
 	_ "github.com/tranthanhsang2k3/healthmate-backend/auth-service/docs"
 	"github.com/swaggo/gin-swagger"
 	"github.com/swaggo/files"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/handlers"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/metrics"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/migrations"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/router"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
 )
 
 // @title           Swagger Auth Service API
@@ -27,21 +41,291 @@ import (
 // @host      127.0.0.1:9000
 // @BasePath  /api/v1
 // @schemes http https
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
+// @securityDefinitions.basic BasicAuth
 func main() {
-	conf := config.LoadConfig()
+	configFile := flag.String("config", "", "YAML config file, defaults to $CONFIG_FILE")
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+	flag.Parse()
+
+	conf := loadConfig(*configFile, *printConfig)
 	log :=  config.InitLogger(conf.AppConfig)
+	config.InitTracing(conf, log)
 	config.ConnectDatabase(conf, log)
+	checkMigrations(conf, log)
 	config.InitRedisServer(conf)
+	config.InitMailer(conf, log)
+	utils.InitTokenTTLs(conf.AccessTokenTTL, conf.RefreshTokenTTL)
+	initJWTKeys(conf, log)
+	initPasswordHasher(conf)
+	initPoolMetrics(log)
 
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(config.ServiceName, otelgin.WithFilter(isTraced)))
+	r.Use(middleware.Metrics())
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	createUserHandler(r, log)
-	r.Run(conf.GinHost+":"+conf.GinPort)
+	router.MetricsRouter(r)
+	healthHandler := handlers.NewHealthHandler(conf.HealthCheckTimeout, log,
+		handlers.HealthCheck{Name: "postgres", Check: config.PingDatabase},
+		handlers.HealthCheck{Name: "redis", Check: config.PingRedis},
+	)
+	router.HealthRouter(r, healthHandler)
+	r.Use(middleware.RequestMeta(), middleware.RequestLogger(log), middleware.ErrorHandler(log))
+	createUserHandler(r, conf, log)
+	createOAuthHandler(r, conf, log)
+	router.WellKnownRouter(r, handlers.NewJWKSHandler())
+	createTokenHandler(r, conf, log)
+	serve(r, conf, healthHandler, log)
+}
+
+// isTraced leaves probes and metric scrapes out of traces.
+func isTraced(req *http.Request) bool {
+	switch req.URL.Path {
+	case "/healthz", "/readyz", "/metrics":
+		return false
+	}
+	return true
+}
+
+func initPoolMetrics(log *logrus.Logger) {
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		log.WithError(err).Fatal("Không thể lấy kết nối database")
+	}
+	metrics.RegisterDBPool(sqlDB)
+	metrics.RegisterRedisPool(config.RedisPoolStats)
+}
+
+// serve runs the HTTP server until SIGINT or SIGTERM. It then fails readiness,
+// stops accepting connections, waits up to SHUTDOWN_TIMEOUT for in-flight
+// requests and closes the database and Redis pools.
+func serve(r *gin.Engine, conf *config.Config, healthHandler *handlers.HealthHandler, log *logrus.Logger) {
+	srv := &http.Server{
+		Addr:    conf.GinHost + ":" + conf.GinPort,
+		Handler: r,
+	}
+
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.WithField("addr", srv.Addr).Info("HTTP server đang chạy")
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		log.WithError(err).Fatal("HTTP server dừng bất thường")
+	case <-stop.Done():
+	}
+
+	log.Info("Đang tắt server, chờ các request đang xử lý")
+	healthHandler.ShutDown()
+	ctx, cancelShutdown := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.WithError(err).Error("Hết thời gian chờ, vẫn còn request chưa xử lý xong")
+	}
+
+	if err := config.CloseDatabase(); err != nil {
+		log.WithError(err).Error("Không thể đóng kết nối database")
+	}
+	if err := config.CloseRedis(); err != nil {
+		log.WithError(err).Error("Không thể đóng kết nối Redis")
+	}
+	if err := config.ShutdownTracing(ctx); err != nil {
+		log.WithError(err).Error("Không thể xuất các span còn lại")
+	}
+	log.Info("Server đã tắt")
 }
 
-func createUserHandler(r *gin.Engine, log *logrus.Logger) {
+func createUserHandler(r *gin.Engine, conf *config.Config, log *logrus.Logger) {
 	userRepository := repositories.NewUserRepository(config.DB)
-	userService := services.NewUserService(userRepository, log)
+	sessionRepository := repositories.NewSessionRepository(config.DB)
+	roleRepository := repositories.NewRoleRepository(config.DB)
+	auditRepository := repositories.NewAuditRepository(config.DB)
+	userService := services.NewUserService(userRepository, sessionRepository, roleRepository, auditRepository, conf.LoginLockout, log)
 	userHandler := handlers.NewUserHandler(userService)
-	router.LoginRouter(r, userHandler)
-}
\ No newline at end of file
+	limits := middleware.NewRateLimits(middleware.NewRateLimiter(conf.RateLimit.Backend), conf.RateLimit.Rules)
+	router.LoginRouter(r, userHandler, limits)
+	adminUserService := services.NewAdminUserService(userRepository, sessionRepository, roleRepository, auditRepository, log)
+	adminUserHandler := handlers.NewAdminUserHandler(adminUserService)
+	roleService := services.NewRoleService(roleRepository, auditRepository, log)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	auditService := services.NewAuditService(auditRepository, log)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	router.AdminRouter(r, userHandler, adminUserHandler, roleHandler, auditHandler)
+	createSessionHandler(r, log)
+	createTwoFactorHandler(r, conf, limits, log)
+	createPasskeyHandler(r, conf, limits, log)
+}
+
+func createSessionHandler(r *gin.Engine, log *logrus.Logger) {
+	sessionRepository := repositories.NewSessionRepository(config.DB)
+	auditRepository := repositories.NewAuditRepository(config.DB)
+	sessionService := services.NewSessionService(sessionRepository, auditRepository, log)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	router.SessionRouter(r, sessionHandler)
+}
+
+func createTwoFactorHandler(r *gin.Engine, conf *config.Config, limits *middleware.RateLimits, log *logrus.Logger) {
+	var encryptionKey []byte
+	if conf.TOTPEncryptionKey == "" {
+		log.Warn("Chưa cấu hình TOTP_ENCRYPTION_KEY, không thể bật xác thực hai lớp")
+	} else {
+		key, err := utils.ParseEncryptionKey(conf.TOTPEncryptionKey)
+		if err != nil {
+			log.WithError(err).Fatal("TOTP_ENCRYPTION_KEY không hợp lệ")
+		}
+		encryptionKey = key
+	}
+
+	userRepository := repositories.NewUserRepository(config.DB)
+	twoFactorRepository := repositories.NewTwoFactorRepository(config.DB)
+	sessionRepository := repositories.NewSessionRepository(config.DB)
+	roleRepository := repositories.NewRoleRepository(config.DB)
+	auditRepository := repositories.NewAuditRepository(config.DB)
+	twoFactorService := services.NewTwoFactorService(userRepository, sessionRepository, roleRepository, auditRepository, twoFactorRepository, encryptionKey, conf.TOTPIssuer, log)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+	router.TwoFactorRouter(r, twoFactorHandler, limits)
+}
+
+func createPasskeyHandler(r *gin.Engine, conf *config.Config, limits *middleware.RateLimits, log *logrus.Logger) {
+	if conf.WebAuthn.RPID == "" {
+		log.Warn("Chưa cấu hình WEBAUTHN_RP_ID, không thể dùng passkey")
+	}
+
+	userRepository := repositories.NewUserRepository(config.DB)
+	sessionRepository := repositories.NewSessionRepository(config.DB)
+	roleRepository := repositories.NewRoleRepository(config.DB)
+	auditRepository := repositories.NewAuditRepository(config.DB)
+	passkeyRepository := repositories.NewPasskeyRepository(config.DB)
+	passkeyService, err := services.NewPasskeyService(conf.WebAuthn, userRepository, sessionRepository, roleRepository, auditRepository, passkeyRepository, log)
+	if err != nil {
+		log.WithError(err).Fatal("Cấu hình WebAuthn không hợp lệ")
+	}
+	passkeyHandler := handlers.NewPasskeyHandler(passkeyService)
+	router.PasskeyRouter(r, passkeyHandler, limits)
+}
+
+func createOAuthHandler(r *gin.Engine, conf *config.Config, log *logrus.Logger) {
+	userRepository := repositories.NewUserRepository(config.DB)
+	sessionRepository := repositories.NewSessionRepository(config.DB)
+	userProviderRepository := repositories.NewUserProviderRepository(config.DB)
+	roleRepository := repositories.NewRoleRepository(config.DB)
+	auditRepository := repositories.NewAuditRepository(config.DB)
+	oauthService := services.NewOAuthService(conf.OAuthProviders, userRepository, sessionRepository, roleRepository, auditRepository, userProviderRepository, log)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+	router.OAuthRouter(r, oauthHandler)
+}
+
+func createTokenHandler(r *gin.Engine, conf *config.Config, log *logrus.Logger) {
+	userRepository := repositories.NewUserRepository(config.DB)
+	sessionRepository := repositories.NewSessionRepository(config.DB)
+	tokenService := services.NewTokenService(userRepository, sessionRepository, log)
+	tokenHandler := handlers.NewTokenHandler(tokenService, conf.IntrospectionCacheTTL)
+	router.TokenRouter(r, tokenHandler, conf.IntrospectionClients)
+}
+
+// loadConfig exits listing every configuration problem, or after printing the
+// configuration when printConfig is set.
+func loadConfig(path string, printConfig bool) *config.Config {
+	conf, err := config.LoadConfig(path)
+	var invalid *config.ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if printConfig {
+		if err := conf.PrintSettings(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if invalid != nil {
+		fmt.Fprintln(os.Stderr, invalid)
+		os.Exit(1)
+	}
+	if printConfig {
+		os.Exit(0)
+	}
+	return conf
+}
+
+// checkMigrations reports migrations that have not been applied with
+// `migrate up`. With DB_REQUIRE_MIGRATIONS set the server refuses to start
+// until they are.
+func checkMigrations(conf *config.Config, log *logrus.Logger) {
+	all, err := migrations.Load(migrations.Files)
+	if err != nil {
+		log.WithError(err).Fatal("Không thể đọc migrations")
+	}
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		log.WithError(err).Fatal("Không thể lấy kết nối database")
+	}
+
+	pending, err := migrations.NewMigrator(sqlDB, all).Pending(context.Background())
+	if err != nil {
+		log.WithError(err).Fatal("Không thể kiểm tra migrations")
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(pending))
+	for _, m := range pending {
+		names = append(names, fmt.Sprintf("%06d_%s", m.Version, m.Name))
+	}
+	entry := log.WithField("pending", names)
+	if conf.RequireMigrations {
+		entry.Fatal("Còn migrations chưa chạy, hãy chạy `migrate up` trước khi khởi động")
+	}
+	entry.Warn("Còn migrations chưa chạy")
+}
+
+// initJWTKeys loads the signing keyset and reloads it on SIGHUP so keys can
+// be rotated without a restart.
+func initJWTKeys(conf *config.Config, log *logrus.Logger) {
+	if conf.JWTKeysDir == "" {
+		log.Warn("Chưa cấu hình JWT_KEYS_DIR, token sẽ được ký bằng HS256")
+		utils.InitJWTSecret(conf.JWTSecret, log)
+		return
+	}
+
+	if err := utils.LoadKeySet(conf.JWTKeysDir); err != nil {
+		log.WithError(err).Fatal("Không thể tải JWT keyset")
+	}
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := utils.ReloadKeySet(); err != nil {
+				log.WithError(err).Error("Không thể tải lại JWT keyset")
+				continue
+			}
+			log.Info("Đã tải lại JWT keyset")
+		}
+	}()
+}
+
+// initPasswordHasher chọn thuật toán băm mật khẩu mới theo cấu hình.
+func initPasswordHasher(conf *config.Config) {
+	hashing := conf.PasswordHashing
+	if hashing.Algorithm == config.PasswordHashBcrypt {
+		utils.InitPasswordHasher(utils.BcryptHasher{Cost: hashing.BcryptCost})
+		return
+	}
+	params := utils.DefaultArgon2idParams
+	params.Memory = uint32(hashing.Argon2Memory)
+	params.Iterations = uint32(hashing.Argon2Iterations)
+	params.Parallelism = uint8(hashing.Argon2Parallelism)
+	utils.InitPasswordHasher(utils.NewArgon2idHasher(params))
+}
@@ -0,0 +1,115 @@
+// Command migrate manages the database schema of the auth service.
+//
+//	migrate up            apply every pending migration
+//	migrate down [N]      revert the last N applied migrations (default 1)
+//	migrate status        list migrations and when they were applied
+//	migrate create NAME   add an empty migration pair to the migrations directory
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/migrations"
+)
+
+func main() {
+	dir := flag.String("dir", "migrations", "directory that create writes new migrations to")
+	configFile := flag.String("config", "", "YAML config file, defaults to $CONFIG_FILE")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate [-dir DIR] [-config FILE] up | down [N] | status | create NAME")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		up, down, err := migrations.Create(*dir, args[1])
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println("Created", up)
+		fmt.Println("Created", down)
+		return
+	}
+
+	conf, err := config.LoadDatabaseConfig(*configFile)
+	if err != nil {
+		fail(err)
+	}
+	log := config.InitLogger(conf.AppConfig)
+	config.ConnectDatabase(conf, log)
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		fail(err)
+	}
+	defer sqlDB.Close()
+
+	all, err := migrations.Load(migrations.Files)
+	if err != nil {
+		fail(err)
+	}
+	migrator := migrations.NewMigrator(sqlDB, all)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			fmt.Printf("Applied %06d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fail(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			n, err = strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fail(fmt.Errorf("invalid number of migrations: %s", args[1]))
+			}
+		}
+		done, err := migrator.Down(ctx, n)
+		for _, m := range done {
+			fmt.Printf("Reverted %06d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fail(err)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fail(err)
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%06d_%-40s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "migrate:", err)
+	os.Exit(1)
+}
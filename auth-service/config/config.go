@@ -1,11 +1,20 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 	"github.com/joho/godotenv"
 )
 
+// minJWTSecretLength là độ dài tối thiểu của khoá HS256 (256 bit).
+const minJWTSecretLength = 32
+
 type Config struct {
 	DBHost string
 	DBPort string
@@ -13,37 +22,288 @@ type Config struct {
 	DBPass string
 	DBName string
 	DBTimezone string
+	// DBSSLMode là sslmode của PostgreSQL: disable, allow, prefer, require,
+	// verify-ca hoặc verify-full.
+	DBSSLMode string
+	DBMaxOpenConns int
+	DBMaxIdleConns int
+	DBConnMaxLifetime time.Duration
+	// RequireMigrations makes the server refuse to start while migrations are pending.
+	RequireMigrations bool
 	AppConfig string
 	GinPort string
 	GinHost string
+	// ShutdownTimeout là thời gian chờ các request đang xử lý khi nhận SIGTERM.
+	ShutdownTimeout time.Duration
+	// HealthCheckTimeout giới hạn thời gian ping mỗi dependency trong /readyz.
+	HealthCheckTimeout time.Duration
 	RedisHost string
 	RedisPort string
+	RedisPassword string
+	RedisDB int
+	SMTPHost string
+	SMTPPort string
+	SMTPUser string
+	SMTPPass string
+	SMTPFrom string
+	OAuthProviders map[string]OAuthProvider
+	// JWTSecret ký token bằng HS256 khi không cấu hình JWTKeysDir.
+	JWTSecret string
+	JWTKeysDir string
+	AccessTokenTTL time.Duration
+	RefreshTokenTTL time.Duration
+	IntrospectionClients map[string]string
+	IntrospectionCacheTTL time.Duration
+	LoginLockout LoginLockout
+	RateLimit RateLimit
+	TOTPEncryptionKey string
+	TOTPIssuer string
+	WebAuthn WebAuthn
+	Tracing Tracing
+	PasswordHashing PasswordHashing
+
+	settings []setting
+}
+
+// LoginLockout cấu hình chống dò mật khẩu. MaxAttempts = 0 sẽ tắt cơ chế khoá.
+type LoginLockout struct {
+	MaxAttempts      int
+	MaxAttemptsPerIP int
+	DelayAfter       int
+	BaseDelay        time.Duration
+	LockoutDuration  time.Duration
+	Window           time.Duration
+}
+
+// ValidationError liệt kê mọi lỗi cấu hình tìm thấy, để sửa một lần thay vì
+// khởi động lại sau từng lỗi.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
 }
 
-func LoadConfig() *Config {
-	err := godotenv.Load()
+// LoadConfig đọc cấu hình theo thứ tự ưu tiên tăng dần: giá trị mặc định,
+// file YAML ở path (hoặc CONFIG_FILE nếu path rỗng), biến môi trường (kể cả
+// từ .env), và <KEY>_FILE cho các secret. Nếu cấu hình không hợp lệ, lỗi trả
+// về là *ValidationError liệt kê mọi vấn đề; Config vẫn được trả về để có thể
+// in ra khi cần.
+func LoadConfig(path string) (*Config, error) {
+	return loadConfig(path, (*Config).validate)
+}
+
+// LoadDatabaseConfig đọc cấu hình giống LoadConfig nhưng chỉ kiểm tra các
+// thiết lập PostgreSQL, để lệnh migrate chạy được từ CI hoặc init container
+// mà không cần Redis, JWT hay SMTP.
+func LoadDatabaseConfig(path string) (*Config, error) {
+	return loadConfig(path, (*Config).validateDatabase)
+}
+
+func loadConfig(path string, validate func(*Config) []string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	l, err := newLoader(path)
 	if err != nil {
-		fmt.Println("env don't loading")
+		return nil, err
+	}
+
+	conf := &Config{
+		DBHost: l.string("POSTGRES_HOST", ""),
+		DBPort: l.string("POSTGRES_PORT", "5432"),
+		DBUser: l.string("POSTGRES_USER", ""),
+		DBPass: l.secret("POSTGRES_PASS"),
+		DBName: l.string("POSTGRES_DB", ""),
+		DBTimezone: l.string("POSTGRES_TIMEZONE", "UTC"),
+		DBSSLMode: l.string("POSTGRES_SSLMODE", "disable"),
+		DBMaxOpenConns: l.int("POSTGRES_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns: l.int("POSTGRES_MAX_IDLE_CONNS", 5),
+		DBConnMaxLifetime: l.duration("POSTGRES_CONN_MAX_LIFETIME", 30*time.Minute),
+		RequireMigrations: l.bool("DB_REQUIRE_MIGRATIONS", false),
+		AppConfig: l.string("APP_ENV", "development"),
+		GinPort: l.string("GIN_PORT", "9000"),
+		GinHost: l.string("GIN_HOST", ""),
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", 15*time.Second),
+		HealthCheckTimeout: l.duration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		RedisHost: l.string("REDIS_HOST", ""),
+		RedisPort: l.string("REDIS_PORT", "6379"),
+		RedisPassword: l.secret("REDIS_PASSWORD"),
+		RedisDB: l.int("REDIS_DB", 0),
+		SMTPHost: l.string("SMTP_HOST", ""),
+		SMTPPort: l.string("SMTP_PORT", ""),
+		SMTPUser: l.string("SMTP_USER", ""),
+		SMTPPass: l.secret("SMTP_PASS"),
+		SMTPFrom: l.string("SMTP_FROM", ""),
+		OAuthProviders: loadOAuthProviders(l),
+		JWTSecret: l.secret("JWT_SECRET"),
+		JWTKeysDir: l.string("JWT_KEYS_DIR", ""),
+		AccessTokenTTL: l.duration("JWT_ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: l.duration("JWT_REFRESH_TOKEN_TTL", 24*time.Hour),
+		IntrospectionClients: parseClientCredentials(l.secret("INTROSPECTION_CLIENTS")),
+		IntrospectionCacheTTL: l.seconds("INTROSPECTION_CACHE_SECONDS", 30),
+		LoginLockout: LoginLockout{
+			MaxAttempts:      l.int("LOGIN_MAX_ATTEMPTS", 5),
+			MaxAttemptsPerIP: l.int("LOGIN_MAX_ATTEMPTS_PER_IP", 20),
+			DelayAfter:       l.int("LOGIN_DELAY_AFTER", 3),
+			BaseDelay:        l.seconds("LOGIN_BASE_DELAY_SECONDS", 1),
+			LockoutDuration:  l.seconds("LOGIN_LOCKOUT_SECONDS", 900),
+			Window:           l.seconds("LOGIN_ATTEMPT_WINDOW_SECONDS", 900),
+		},
+		RateLimit: loadRateLimit(l),
+		TOTPEncryptionKey: l.secret("TOTP_ENCRYPTION_KEY"),
+		TOTPIssuer: l.string("TOTP_ISSUER", "HealthMate"),
+		WebAuthn: loadWebAuthn(l),
+		Tracing: loadTracing(l),
+		PasswordHashing: loadPasswordHashing(l),
+	}
+	conf.settings = l.settings
+	l.checkUnknown()
+
+	problems := append(l.problems, validate(conf)...)
+	if len(problems) > 0 {
+		return conf, &ValidationError{Problems: problems}
 	}
-	return &Config{
-		DBHost: getEnv("POSTGRES_HOST"),
-		DBPort: getEnv("POSTGRES_PORT"),
-		DBUser: getEnv("POSTGRES_USER"),
-		DBPass: getEnv("POSTGRES_PASS"),
-		DBName: getEnv("POSTGRES_DB"),
-		DBTimezone: getEnv("POSTGRES_TIMEZONE"),
-		AppConfig: getEnv("APP_ENV"),
-		GinPort: getEnv("GIN_PORT"),
-		GinHost: getEnv("GIN_HOST"),
-		RedisHost: getEnv("REDIS_HOST"),
-		RedisPort: getEnv("REDIS_PORT"),
+	return conf, nil
+}
+
+// Validate kiểm tra các thiết lập bắt buộc và các ràng buộc giữa chúng.
+func (c *Config) Validate() error {
+	if problems := c.validate(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
 	}
+	return nil
 }
 
-func getEnv(key string) string {
-	value := os.Getenv(key)
+func (c *Config) validate() []string {
+	problems := problemList(c.validateDatabase())
+
+	problems.required("GIN_PORT", c.GinPort)
+	problems.port("GIN_PORT", c.GinPort)
+	if c.ShutdownTimeout <= 0 {
+		problems.addf("SHUTDOWN_TIMEOUT must be positive")
+	}
+	if c.HealthCheckTimeout <= 0 {
+		problems.addf("HEALTH_CHECK_TIMEOUT must be positive")
+	}
+	problems.required("REDIS_HOST", c.RedisHost)
+	problems.required("REDIS_PORT", c.RedisPort)
+	problems.port("REDIS_PORT", c.RedisPort)
+	if c.RedisDB < 0 {
+		problems.addf("REDIS_DB must not be negative")
+	}
+
+	if c.SMTPHost != "" {
+		problems.required("SMTP_PORT", c.SMTPPort)
+		problems.port("SMTP_PORT", c.SMTPPort)
+		problems.required("SMTP_FROM", c.SMTPFrom)
+	}
+
+	if c.JWTKeysDir == "" {
+		if c.JWTSecret == "" {
+			problems.addf("JWT_SECRET is required when JWT_KEYS_DIR is not set")
+		} else if len(c.JWTSecret) < minJWTSecretLength {
+			problems.addf("JWT_SECRET must be at least %d bytes", minJWTSecretLength)
+		}
+	}
+	if c.AccessTokenTTL <= 0 {
+		problems.addf("JWT_ACCESS_TOKEN_TTL must be positive")
+	}
+	if c.RefreshTokenTTL <= c.AccessTokenTTL {
+		problems.addf("JWT_REFRESH_TOKEN_TTL must be longer than JWT_ACCESS_TOKEN_TTL")
+	}
+	if c.IntrospectionCacheTTL < 0 {
+		problems.addf("INTROSPECTION_CACHE_SECONDS must not be negative")
+	}
+
+	names := make([]string, 0, len(c.OAuthProviders))
+	for name := range c.OAuthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		provider := c.OAuthProviders[name]
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		problems.required(prefix+"CLIENT_SECRET", provider.ClientSecret)
+		problems.required(prefix+"REDIRECT_URL", provider.RedirectURL)
+	}
+	if c.WebAuthn.RPID != "" && len(c.WebAuthn.RPOrigins) == 0 {
+		problems.addf("WEBAUTHN_RP_ORIGINS is required when WEBAUTHN_RP_ID is set")
+	}
+	problems = append(problems, c.Tracing.validate()...)
+	problems = append(problems, c.PasswordHashing.validate()...)
+
+	return problems
+}
+
+// validateDatabase kiểm tra các thiết lập PostgreSQL.
+func (c *Config) validateDatabase() []string {
+	var problems problemList
+
+	problems.required("POSTGRES_HOST", c.DBHost)
+	problems.required("POSTGRES_PORT", c.DBPort)
+	problems.port("POSTGRES_PORT", c.DBPort)
+	problems.required("POSTGRES_USER", c.DBUser)
+	problems.required("POSTGRES_PASS", c.DBPass)
+	problems.required("POSTGRES_DB", c.DBName)
+	if _, err := time.LoadLocation(c.DBTimezone); err != nil {
+		problems.addf("POSTGRES_TIMEZONE %q is not a known time zone", c.DBTimezone)
+	}
+	switch c.DBSSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		problems.addf("POSTGRES_SSLMODE must be one of disable, allow, prefer, require, verify-ca, verify-full, got %q", c.DBSSLMode)
+	}
+	if c.DBMaxOpenConns < 0 {
+		problems.addf("POSTGRES_MAX_OPEN_CONNS must not be negative")
+	}
+	if c.DBMaxIdleConns < 0 {
+		problems.addf("POSTGRES_MAX_IDLE_CONNS must not be negative")
+	}
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		problems.addf("POSTGRES_MAX_IDLE_CONNS (%d) must not exceed POSTGRES_MAX_OPEN_CONNS (%d)", c.DBMaxIdleConns, c.DBMaxOpenConns)
+	}
+	if c.DBConnMaxLifetime < 0 {
+		problems.addf("POSTGRES_CONN_MAX_LIFETIME must not be negative")
+	}
+
+	return problems
+}
+
+// problemList gom các lỗi tìm thấy trong một lần kiểm tra cấu hình.
+type problemList []string
+
+func (p *problemList) addf(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p *problemList) required(key string, value string) {
+	if value == "" {
+		p.addf("%s is required", key)
+	}
+}
+
+func (p *problemList) port(key string, value string) {
 	if value == "" {
-		fmt.Printf("Missing env variable: %s\n", key)
+		return
+	}
+	if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+		p.addf("%s must be a port number, got %q", key, value)
 	}
-	return value
-}
\ No newline at end of file
+}
+// parseClientCredentials đọc danh sách "client_id:secret" cách nhau bởi dấu phẩy.
+func parseClientCredentials(raw string) map[string]string {
+	clients := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || id == "" || secret == "" {
+			continue
+		}
+		clients[id] = secret
+	}
+	return clients
+}
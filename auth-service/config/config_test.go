@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func setValidEnv(t *testing.T) {
+	t.Setenv("POSTGRES_HOST", "localhost")
+	t.Setenv("POSTGRES_USER", "auth")
+	t.Setenv("POSTGRES_PASS", "secret")
+	t.Setenv("POSTGRES_DB", "auth")
+	t.Setenv("REDIS_HOST", "localhost")
+	t.Setenv("JWT_SECRET", "0123456789abcdef0123456789abcdef")
+}
+
+func TestLoadConfig_Layers(t *testing.T) {
+	setValidEnv(t)
+	path := writeFile(t, "config.yaml", `
+postgres:
+  host: db.internal
+  max_open_conns: 50
+jwt_access_token_ttl: 5m
+redis_db: 2
+`)
+	t.Setenv("POSTGRES_HOST", "")
+	t.Setenv("REDIS_DB", "3")
+	t.Setenv("POSTGRES_PASS", "")
+	t.Setenv("POSTGRES_PASS_FILE", writeFile(t, "pass", "from-file\n"))
+
+	conf, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "db.internal", conf.DBHost)
+	assert.Equal(t, 50, conf.DBMaxOpenConns)
+	assert.Equal(t, 5*time.Minute, conf.AccessTokenTTL)
+	assert.Equal(t, 3, conf.RedisDB)
+	assert.Equal(t, "from-file", conf.DBPass)
+	assert.Equal(t, "disable", conf.DBSSLMode)
+	assert.Equal(t, 24*time.Hour, conf.RefreshTokenTTL)
+}
+
+func TestLoadConfig_ListsAllProblems(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("POSTGRES_HOST", "")
+	t.Setenv("POSTGRES_SSLMODE", "sometimes")
+	t.Setenv("REDIS_DB", "two")
+	t.Setenv("JWT_SECRET", "short")
+	t.Setenv("JWT_REFRESH_TOKEN_TTL", "1m")
+
+	_, err := LoadConfig(writeFile(t, "config.yaml", "postgres_hots: db\n"))
+
+	var invalid *ValidationError
+	require.True(t, errors.As(err, &invalid))
+	assert.ElementsMatch(t, []string{
+		`REDIS_DB must be an integer, got "two"`,
+		`unknown setting "postgres_hots" in config file`,
+		"POSTGRES_HOST is required",
+		`POSTGRES_SSLMODE must be one of disable, allow, prefer, require, verify-ca, verify-full, got "sometimes"`,
+		"JWT_SECRET must be at least 32 bytes",
+		"JWT_REFRESH_TOKEN_TTL must be longer than JWT_ACCESS_TOKEN_TTL",
+	}, invalid.Problems)
+}
+
+func TestLoadDatabaseConfig_OnlyNeedsPostgres(t *testing.T) {
+	t.Setenv("POSTGRES_HOST", "localhost")
+	t.Setenv("POSTGRES_USER", "auth")
+	t.Setenv("POSTGRES_PASS", "secret")
+	t.Setenv("POSTGRES_DB", "auth")
+	t.Setenv("REDIS_HOST", "")
+	t.Setenv("JWT_SECRET", "")
+
+	conf, err := LoadDatabaseConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost", conf.DBHost)
+
+	_, err = LoadConfig("")
+	assert.Error(t, err)
+
+	t.Setenv("POSTGRES_HOST", "")
+	_, err = LoadDatabaseConfig("")
+	var invalid *ValidationError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, []string{"POSTGRES_HOST is required"}, invalid.Problems)
+}
+
+func TestLoadConfig_SecretAndSecretFileBothSet(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt", "0123456789abcdef0123456789abcdef"))
+
+	_, err := LoadConfig("")
+
+	var invalid *ValidationError
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, []string{"JWT_SECRET and JWT_SECRET_FILE are both set"}, invalid.Problems)
+}
+
+func TestPrintSettings_RedactsSecrets(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("REDIS_PASSWORD", "redis-secret")
+
+	conf, err := LoadConfig("")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, conf.PrintSettings(&out))
+
+	assert.Contains(t, out.String(), `postgres_host: "localhost" # env`)
+	assert.Contains(t, out.String(), `redis_password: "******" # env`)
+	assert.Contains(t, out.String(), `jwt_access_token_ttl: "15m0s" # default`)
+	assert.NotContains(t, out.String(), "redis-secret")
+	assert.NotContains(t, out.String(), "0123456789abcdef")
+}
+
+func TestLoadConfig_PasswordHashing(t *testing.T) {
+	setValidEnv(t)
+
+	conf, err := LoadConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, PasswordHashing{
+		Algorithm:         PasswordHashArgon2id,
+		Argon2Memory:      64 * 1024,
+		Argon2Iterations:  3,
+		Argon2Parallelism: 2,
+		BcryptCost:        10,
+	}, conf.PasswordHashing)
+
+	t.Setenv("PASSWORD_ARGON2_MEMORY_KIB", "4")
+	t.Setenv("PASSWORD_ARGON2_ITERATIONS", "0")
+	_, err = LoadConfig("")
+	var invalid *ValidationError
+	require.True(t, errors.As(err, &invalid))
+	assert.ElementsMatch(t, []string{
+		"PASSWORD_ARGON2_MEMORY_KIB must be at least 16",
+		"PASSWORD_ARGON2_ITERATIONS must be positive",
+	}, invalid.Problems)
+
+	// 2^32 + 1 would wrap around to 1 when converted to uint32.
+	t.Setenv("PASSWORD_ARGON2_MEMORY_KIB", "4294967297")
+	t.Setenv("PASSWORD_ARGON2_ITERATIONS", "1000")
+	_, err = LoadConfig("")
+	require.True(t, errors.As(err, &invalid))
+	assert.ElementsMatch(t, []string{
+		"PASSWORD_ARGON2_MEMORY_KIB must be at most 4194304",
+		"PASSWORD_ARGON2_ITERATIONS must be at most 64",
+	}, invalid.Problems)
+
+	t.Setenv("PASSWORD_HASH_ALGORITHM", "bcrypt")
+	t.Setenv("PASSWORD_BCRYPT_COST", "40")
+	_, err = LoadConfig("")
+	require.True(t, errors.As(err, &invalid))
+	assert.Equal(t, []string{"PASSWORD_BCRYPT_COST must be between 4 and 31"}, invalid.Problems)
+}
+
+func TestLoadConfig_OAuthDoesNotTrustUnverifiedEmail(t *testing.T) {
+	setValidEnv(t)
+	t.Setenv("OAUTH_FACEBOOK_CLIENT_ID", "facebook-client")
+	t.Setenv("OAUTH_FACEBOOK_CLIENT_SECRET", "facebook-secret")
+	t.Setenv("OAUTH_FACEBOOK_REDIRECT_URL", "http://localhost/callback")
+
+	conf, err := LoadConfig("")
+	require.NoError(t, err)
+	assert.False(t, conf.OAuthProviders["facebook"].TrustEmail)
+
+	t.Setenv("OAUTH_FACEBOOK_TRUST_EMAIL", "true")
+	conf, err = LoadConfig("")
+	require.NoError(t, err)
+	assert.True(t, conf.OAuthProviders["facebook"].TrustEmail)
+}
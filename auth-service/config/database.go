@@ -1,23 +1,26 @@
 package config
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/opentelemetry/tracing"
 )
 
 var DB *gorm.DB
 
 func ConnectDatabase(cf *Config, log *logrus.Logger) {
 	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=%s",
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
 		cf.DBHost,
 		cf.DBUser,
 		cf.DBPass,
 		cf.DBName,
 		cf.DBPort,
+		cf.DBSSLMode,
 		cf.DBTimezone,
 	)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -27,7 +30,34 @@ func ConnectDatabase(cf *Config, log *logrus.Logger) {
 
 	}
 
+	// Không ghi giá trị tham số vào span để email hay hash không lọt ra ngoài.
+	if err := db.Use(tracing.NewPlugin(tracing.WithoutMetrics(), tracing.WithoutQueryVariables())); err != nil {
+		log.WithError(err).Fatal("Không thể bật tracing cho database")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.WithError(err).Fatal("Không thể lấy kết nối database")
+	}
+	sqlDB.SetMaxOpenConns(cf.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cf.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cf.DBConnMaxLifetime)
+
 	DB = db
 	log.Info("Kết nối database thành công")
 }
 
+func PingDatabase(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func CloseDatabase() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
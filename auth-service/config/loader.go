@@ -0,0 +1,244 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Nguồn của một thiết lập, theo thứ tự ưu tiên tăng dần.
+const (
+	sourceDefault    = "default"
+	sourceFile       = "file"
+	sourceEnv        = "env"
+	sourceSecretFile = "secret file"
+)
+
+const redacted = "******"
+
+// setting ghi lại giá trị cuối cùng của một khoá và nơi nó được đọc, dùng cho
+// --print-config.
+type setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// loader đọc thiết lập theo từng lớp: giá trị mặc định, file YAML, biến môi
+// trường, và với secret là file được trỏ tới bởi <KEY>_FILE. Lỗi đọc được gom
+// lại trong problems thay vì dừng ở lỗi đầu tiên.
+type loader struct {
+	file     map[string]string
+	used     map[string]bool
+	settings []setting
+	problems []string
+}
+
+// newLoader đọc file YAML ở path (nếu có). Khoá trong file là tên biến môi
+// trường viết thường; map lồng nhau được nối bằng dấu gạch dưới, nên
+// "postgres: {host: db}" tương đương "postgres_host: db" và POSTGRES_HOST.
+func newLoader(path string) (*loader, error) {
+	l := &loader{file: make(map[string]string), used: make(map[string]bool)}
+	if path == "" {
+		return l, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	flattenYAML("", doc, l.file)
+	return l, nil
+}
+
+func flattenYAML(prefix string, node map[string]interface{}, out map[string]string) {
+	for key, value := range node {
+		key = strings.ToUpper(prefix + key)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenYAML(key+"_", v, out)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// lookup trả về giá trị của key từ lớp ưu tiên cao nhất có giá trị. Chỉ secret
+// mới được đọc từ <KEY>_FILE, và đặt cùng lúc cả <KEY> lẫn <KEY>_FILE là lỗi.
+func (l *loader) lookup(key string, secret bool) (string, string, bool) {
+	l.used[key] = true
+
+	if secret {
+		if path := os.Getenv(key + "_FILE"); path != "" {
+			if os.Getenv(key) != "" {
+				l.problemf("%s and %s_FILE are both set", key, key)
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				l.problemf("%s_FILE: %v", key, err)
+				return "", sourceSecretFile, false
+			}
+			return strings.TrimRight(string(raw), "\r\n"), sourceSecretFile, true
+		}
+	}
+	if value := os.Getenv(key); value != "" {
+		return value, sourceEnv, true
+	}
+	if value, ok := l.file[key]; ok && value != "" {
+		return value, sourceFile, true
+	}
+	return "", sourceDefault, false
+}
+
+func (l *loader) record(key string, value string, source string, secret bool) {
+	l.settings = append(l.settings, setting{Key: key, Value: value, Source: source, Secret: secret})
+}
+
+func (l *loader) problemf(format string, args ...interface{}) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+func (l *loader) string(key string, fallback string) string {
+	value, source, ok := l.lookup(key, false)
+	if !ok {
+		value = fallback
+	}
+	l.record(key, value, source, false)
+	return value
+}
+
+func (l *loader) secret(key string) string {
+	value, source, _ := l.lookup(key, true)
+	l.record(key, value, source, true)
+	return value
+}
+
+func (l *loader) int(key string, fallback int) int {
+	raw, source, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, strconv.Itoa(fallback), source, false)
+		return fallback
+	}
+	l.record(key, raw, source, false)
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		l.problemf("%s must be an integer, got %q", key, raw)
+		return fallback
+	}
+	return n
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	raw, source, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, strconv.FormatBool(fallback), source, false)
+		return fallback
+	}
+	l.record(key, raw, source, false)
+	b, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.problemf("%s must be true or false, got %q", key, raw)
+		return fallback
+	}
+	return b
+}
+
+func (l *loader) float(key string, fallback float64) float64 {
+	raw, source, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, strconv.FormatFloat(fallback, 'g', -1, 64), source, false)
+		return fallback
+	}
+	l.record(key, raw, source, false)
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		l.problemf("%s must be a number, got %q", key, raw)
+		return fallback
+	}
+	return f
+}
+
+// duration đọc giá trị dạng time.ParseDuration, ví dụ "15m" hoặc "24h".
+func (l *loader) duration(key string, fallback time.Duration) time.Duration {
+	raw, source, ok := l.lookup(key, false)
+	if !ok {
+		l.record(key, fallback.String(), source, false)
+		return fallback
+	}
+	l.record(key, raw, source, false)
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		l.problemf("%s must be a duration such as 30s or 15m, got %q", key, raw)
+		return fallback
+	}
+	return d
+}
+
+// seconds đọc các khoá *_SECONDS cũ, giá trị là số giây nguyên.
+func (l *loader) seconds(key string, fallback int) time.Duration {
+	return time.Duration(l.int(key, fallback)) * time.Second
+}
+
+func (l *loader) list(key string) []string {
+	return splitList(l.string(key, ""))
+}
+
+// skip đánh dấu mọi khoá trong file YAML bắt đầu bằng prefix là đã dùng, cho
+// các nhóm thiết lập bị tắt (ví dụ provider OAuth chưa có client id).
+func (l *loader) skip(prefix string) {
+	for key := range l.file {
+		if strings.HasPrefix(key, prefix) {
+			l.used[key] = true
+		}
+	}
+}
+
+// checkUnknown báo lỗi cho những khoá trong file YAML không ứng với thiết lập
+// nào, thường là do gõ sai tên.
+func (l *loader) checkUnknown() {
+	var unknown []string
+	for key := range l.file {
+		if !l.used[key] {
+			unknown = append(unknown, strings.ToLower(key))
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		l.problemf("unknown setting %q in config file", key)
+	}
+}
+
+// PrintSettings ghi mọi thiết lập đã đọc dưới dạng YAML, kèm nguồn của từng
+// giá trị. Secret được thay bằng dấu sao, nên có thể dùng kết quả làm file
+// cấu hình sau khi điền lại secret.
+func (c *Config) PrintSettings(w io.Writer) error {
+	for _, s := range c.settings {
+		value := s.Value
+		if s.Secret && value != "" {
+			value = redacted
+		}
+		if _, err := fmt.Fprintf(w, "%s: %q # %s\n", strings.ToLower(s.Key), value, s.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
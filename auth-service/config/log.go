@@ -28,8 +28,13 @@ func setupProdLogger() *logrus.Logger {
 }
 
 func InitLogger(env string) *logrus.Logger {
+    var logger *logrus.Logger
     if env == "production" {
-        return setupProdLogger()
+        logger = setupProdLogger()
+    } else {
+        logger = setupDevLogger()
     }
-    return setupDevLogger()
+    logger.AddHook(traceHook{})
+    logger.AddHook(redactHook{})
+    return logger
 }
\ No newline at end of file
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Mailer interface {
+	Send(to string, subject string, body string) error
+}
+
+var mailer Mailer
+
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func (m *smtpMailer) Send(to string, subject string, body string) error {
+	msg := strings.Join([]string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"UTF-8\"",
+		"",
+		body,
+	}, "\r\n")
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg))
+}
+
+// logMailer được dùng khi chưa cấu hình SMTP (môi trường dev/test).
+type logMailer struct {
+	log *logrus.Logger
+}
+
+func (m *logMailer) Send(to string, subject string, body string) error {
+	m.log.WithFields(logrus.Fields{
+		"to":      to,
+		"subject": subject,
+	}).Debug(body)
+	return nil
+}
+
+func InitMailer(conf *Config, log *logrus.Logger) {
+	if conf.SMTPHost == "" {
+		log.Warn("Chưa cấu hình SMTP, email sẽ chỉ được ghi ra log")
+		mailer = &logMailer{log: log}
+		return
+	}
+
+	var auth smtp.Auth
+	if conf.SMTPUser != "" {
+		auth = smtp.PlainAuth("", conf.SMTPUser, conf.SMTPPass, conf.SMTPHost)
+	}
+	mailer = &smtpMailer{
+		addr: conf.SMTPHost + ":" + conf.SMTPPort,
+		from: conf.SMTPFrom,
+		auth: auth,
+	}
+}
+
+func SendMail(to string, subject string, body string) error {
+	if mailer == nil {
+		return fmt.Errorf("mailer is not initialized")
+	}
+	return mailer.Send(to, subject, body)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// OAuthProvider mô tả một nhà cung cấp OAuth2/OIDC. Các endpoint có giá trị
+// mặc định cho google, apple, facebook và có thể ghi đè qua biến môi trường
+// OAUTH_<PROVIDER>_* (ví dụ để trỏ tới OIDC giả lập khi test).
+type OAuthProvider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Issuer       string
+	AuthURL      string
+	TokenURL     string
+	JWKSURL      string
+	UserInfoURL  string
+	Scopes       []string
+	ResponseMode string
+	// TrustEmail coi email trả về từ UserInfoURL là đã xác thực khi provider
+	// không gửi kèm email_verified. Mặc định tắt: email chưa xác thực có thể
+	// bị dùng để chiếm tài khoản local có cùng email.
+	TrustEmail bool
+}
+
+var defaultOAuthProviders = map[string]OAuthProvider{
+	"google": {
+		Issuer:   "https://accounts.google.com",
+		AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL: "https://oauth2.googleapis.com/token",
+		JWKSURL:  "https://www.googleapis.com/oauth2/v3/certs",
+		Scopes:   []string{"openid", "email", "profile"},
+	},
+	"apple": {
+		Issuer:       "https://appleid.apple.com",
+		AuthURL:      "https://appleid.apple.com/auth/authorize",
+		TokenURL:     "https://appleid.apple.com/auth/token",
+		JWKSURL:      "https://appleid.apple.com/auth/keys",
+		Scopes:       []string{"openid", "email", "name"},
+		ResponseMode: "form_post",
+	},
+	"facebook": {
+		Issuer:      "https://www.facebook.com",
+		AuthURL:     "https://www.facebook.com/v18.0/dialog/oauth",
+		TokenURL:    "https://graph.facebook.com/v18.0/oauth/access_token",
+		JWKSURL:     "https://www.facebook.com/.well-known/oauth/openid/jwks/",
+		UserInfoURL: "https://graph.facebook.com/me?fields=id,email",
+		Scopes:      []string{"email"},
+	},
+}
+
+// loadOAuthProviders chỉ bật những provider có OAUTH_<PROVIDER>_CLIENT_ID.
+func loadOAuthProviders(l *loader) map[string]OAuthProvider {
+	names := make([]string, 0, len(defaultOAuthProviders))
+	for name := range defaultOAuthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	providers := make(map[string]OAuthProvider)
+	for _, name := range names {
+		provider := defaultOAuthProviders[name]
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		provider.Name = name
+		provider.ClientID = l.string(prefix+"CLIENT_ID", "")
+		if provider.ClientID == "" {
+			l.skip(prefix)
+			continue
+		}
+		provider.ClientSecret = l.secret(prefix + "CLIENT_SECRET")
+		provider.RedirectURL = l.string(prefix+"REDIRECT_URL", "")
+		provider.Issuer = l.string(prefix+"ISSUER", provider.Issuer)
+		provider.AuthURL = l.string(prefix+"AUTH_URL", provider.AuthURL)
+		provider.TokenURL = l.string(prefix+"TOKEN_URL", provider.TokenURL)
+		provider.JWKSURL = l.string(prefix+"JWKS_URL", provider.JWKSURL)
+		provider.UserInfoURL = l.string(prefix+"USERINFO_URL", provider.UserInfoURL)
+		provider.TrustEmail = l.bool(prefix+"TRUST_EMAIL", provider.TrustEmail)
+		if scopes := l.list(prefix + "SCOPES"); len(scopes) > 0 {
+			provider.Scopes = scopes
+		}
+		providers[name] = provider
+	}
+	return providers
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+// Giới hạn trên của tham số Argon2id: đủ rộng cho mọi cấu hình hợp lý, và
+// nằm trong uint32 mà thư viện argon2 nhận.
+const (
+	maxArgon2Memory     = 4 * 1024 * 1024 // 4 GiB
+	maxArgon2Iterations = 64
+)
+
+// PasswordHashing chọn thuật toán băm mật khẩu mới. Hash cũ (bcrypt hoặc
+// argon2id với tham số khác) vẫn đăng nhập được và được băm lại khi đăng
+// nhập thành công. Argon2Memory tính bằng KiB.
+type PasswordHashing struct {
+	Algorithm         string
+	Argon2Memory      int
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+}
+
+func loadPasswordHashing(l *loader) PasswordHashing {
+	return PasswordHashing{
+		Algorithm:         l.string("PASSWORD_HASH_ALGORITHM", PasswordHashArgon2id),
+		Argon2Memory:      l.int("PASSWORD_ARGON2_MEMORY_KIB", 64*1024),
+		Argon2Iterations:  l.int("PASSWORD_ARGON2_ITERATIONS", 3),
+		Argon2Parallelism: l.int("PASSWORD_ARGON2_PARALLELISM", 2),
+		BcryptCost:        l.int("PASSWORD_BCRYPT_COST", bcrypt.DefaultCost),
+	}
+}
+
+func (p PasswordHashing) validate() []string {
+	var problems []string
+	switch p.Algorithm {
+	case PasswordHashArgon2id:
+		// RFC 9106 yêu cầu bộ nhớ tối thiểu 8 KiB cho mỗi luồng.
+		if p.Argon2Parallelism < 1 || p.Argon2Parallelism > 255 {
+			problems = append(problems, "PASSWORD_ARGON2_PARALLELISM must be between 1 and 255")
+		} else if p.Argon2Memory < 8*p.Argon2Parallelism {
+			problems = append(problems, fmt.Sprintf("PASSWORD_ARGON2_MEMORY_KIB must be at least %d", 8*p.Argon2Parallelism))
+		} else if p.Argon2Memory > maxArgon2Memory {
+			problems = append(problems, fmt.Sprintf("PASSWORD_ARGON2_MEMORY_KIB must be at most %d", maxArgon2Memory))
+		}
+		if p.Argon2Iterations < 1 {
+			problems = append(problems, "PASSWORD_ARGON2_ITERATIONS must be positive")
+		} else if p.Argon2Iterations > maxArgon2Iterations {
+			problems = append(problems, fmt.Sprintf("PASSWORD_ARGON2_ITERATIONS must be at most %d", maxArgon2Iterations))
+		}
+	case PasswordHashBcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			problems = append(problems, fmt.Sprintf("PASSWORD_BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("PASSWORD_HASH_ALGORITHM must be argon2id or bcrypt, got %q", p.Algorithm))
+	}
+	return problems
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	RateLimitKeyIP    = "ip"
+	RateLimitKeyEmail = "email"
+	RateLimitKeyUser  = "user"
+)
+
+const (
+	RateLimitBackendRedis  = "redis"
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendOff    = "off"
+)
+
+// RateLimit cấu hình giới hạn tần suất request. Rules được nhóm theo tên
+// route (login, register, ...), mỗi route có thể giới hạn theo nhiều khoá.
+type RateLimit struct {
+	Backend string
+	Rules   map[string][]RateLimitRule
+}
+
+// RateLimitRule cho phép tối đa Limit request trong Window (cửa sổ trượt)
+// cho mỗi giá trị của Key (ip, email trong body, user id trong JWT).
+type RateLimitRule struct {
+	Key    string
+	Limit  int
+	Window time.Duration
+}
+
+func defaultRateLimitRules() map[string][]RateLimitRule {
+	return map[string][]RateLimitRule{
+		"login": {
+			{Key: RateLimitKeyIP, Limit: 30, Window: time.Minute},
+			{Key: RateLimitKeyEmail, Limit: 10, Window: time.Minute},
+		},
+		// register và password_forgot gửi OTP qua email nên giới hạn chặt hơn.
+		"register": {
+			{Key: RateLimitKeyIP, Limit: 10, Window: time.Hour},
+			{Key: RateLimitKeyEmail, Limit: 3, Window: 15 * time.Minute},
+		},
+		"password_forgot": {
+			{Key: RateLimitKeyIP, Limit: 10, Window: time.Hour},
+			{Key: RateLimitKeyEmail, Limit: 3, Window: 15 * time.Minute},
+		},
+		"verify_email": {
+			{Key: RateLimitKeyIP, Limit: 30, Window: 15 * time.Minute},
+		},
+		"password_reset": {
+			{Key: RateLimitKeyIP, Limit: 30, Window: 15 * time.Minute},
+		},
+		"two_factor_verify": {
+			{Key: RateLimitKeyIP, Limit: 30, Window: 15 * time.Minute},
+		},
+		"passkey_login": {
+			{Key: RateLimitKeyIP, Limit: 30, Window: time.Minute},
+		},
+		"refresh": {
+			{Key: RateLimitKeyIP, Limit: 60, Window: time.Minute},
+		},
+		"logout": {
+			{Key: RateLimitKeyUser, Limit: 30, Window: time.Minute},
+		},
+	}
+}
+
+// loadRateLimit đọc RATE_LIMIT_BACKEND (redis, memory, off) và RATE_LIMIT_RULES
+// dạng "route:key=limit/window;..." ví dụ "login:email=5/1m;register:ip=0/1h".
+// Mỗi mục ghi đè rule mặc định cùng route và key, limit = 0 sẽ bỏ rule đó.
+func loadRateLimit(l *loader) RateLimit {
+	rules := defaultRateLimitRules()
+	if raw := l.string("RATE_LIMIT_RULES", ""); raw != "" {
+		overrides, err := parseRateLimitRules(raw)
+		if err != nil {
+			l.problemf("RATE_LIMIT_RULES: %v", err)
+		} else {
+			rules = mergeRateLimitRules(rules, overrides)
+		}
+	}
+
+	backend := l.string("RATE_LIMIT_BACKEND", RateLimitBackendRedis)
+	switch backend {
+	case RateLimitBackendRedis, RateLimitBackendMemory, RateLimitBackendOff:
+	default:
+		l.problemf("RATE_LIMIT_BACKEND must be redis, memory or off, got %q", backend)
+	}
+
+	return RateLimit{Backend: backend, Rules: rules}
+}
+
+func parseRateLimitRules(raw string) (map[string][]RateLimitRule, error) {
+	rules := make(map[string][]RateLimitRule)
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		target, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing '=' in %q", entry)
+		}
+		route, key, ok := strings.Cut(strings.TrimSpace(target), ":")
+		if !ok || route == "" {
+			return nil, fmt.Errorf("invalid target in %q", entry)
+		}
+		switch key {
+		case RateLimitKeyIP, RateLimitKeyEmail, RateLimitKeyUser:
+		default:
+			return nil, fmt.Errorf("unknown key %q in %q", key, entry)
+		}
+
+		limitStr, windowStr, ok := strings.Cut(strings.TrimSpace(value), "/")
+		if !ok {
+			return nil, fmt.Errorf("missing '/' in %q", entry)
+		}
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid limit in %q", entry)
+		}
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid window in %q", entry)
+		}
+
+		rules[route] = append(rules[route], RateLimitRule{Key: key, Limit: limit, Window: window})
+	}
+	return rules, nil
+}
+
+func mergeRateLimitRules(base map[string][]RateLimitRule, overrides map[string][]RateLimitRule) map[string][]RateLimitRule {
+	for route, routeOverrides := range overrides {
+		for _, override := range routeOverrides {
+			merged := base[route][:0:0]
+			for _, rule := range base[route] {
+				if rule.Key != override.Key {
+					merged = append(merged, rule)
+				}
+			}
+			if override.Limit > 0 {
+				merged = append(merged, override)
+			}
+			base[route] = merged
+		}
+	}
+	return base
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redactedValue = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*@([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	// secretPattern bắt các cặp khoá=giá trị như "password=abc" hay
+	// `"otp":"123456"` trong message, nhưng không bắt "Failed to save OTP: ..."
+	// để giữ nguyên nội dung lỗi.
+	secretPattern = regexp.MustCompile(`(?i)((?:password|passwd|otp|token|secret|code)[a-z_]*=|"(?:password|passwd|otp|token|secret|code)[a-z_]*"\s*:\s*"?)[^\s"',&]+`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+)
+
+// sensitiveKeys là các phần tên field mà giá trị luôn bị che hoàn toàn.
+var sensitiveKeys = []string{"password", "passwd", "otp", "token", "secret", "authorization", "recovery_code", "code"}
+
+// redactHook che email, token, mật khẩu và OTP trong message và field trước
+// khi formatter ghi log, áp dụng cho cả logger dev lẫn prod.
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = redactString(entry.Message)
+	for key, value := range entry.Data {
+		if isSensitiveKey(key) {
+			entry.Data[key] = redactedValue
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = redactString(v)
+		case error:
+			entry.Data[key] = redactString(v.Error())
+		case fmt.Stringer:
+			entry.Data[key] = redactString(v.String())
+		case []string:
+			redacted := make([]string, len(v))
+			for i, item := range v {
+				redacted[i] = redactString(item)
+			}
+			entry.Data[key] = redacted
+		}
+	}
+	return nil
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactString giữ lại ký tự đầu và tên miền của email (a***@example.com) để
+// vẫn lần được log, còn token và secret bị che hoàn toàn.
+func redactString(s string) string {
+	s = jwtPattern.ReplaceAllString(s, redactedValue)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+redactedValue)
+	s = secretPattern.ReplaceAllString(s, "${1}"+redactedValue)
+	return emailPattern.ReplaceAllString(s, "${1}***@${2}")
+}
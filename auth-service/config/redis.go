@@ -2,8 +2,12 @@ package config
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
+	"strconv"
 	"time"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -15,26 +19,337 @@ func InitRedisServer(conf *Config) {
 	addr := conf.RedisHost + ":" + conf.RedisPort
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
-		Password: "",
-		DB:       0,
+		Password: conf.RedisPassword,
+		DB:       conf.RedisDB,
 	})
 
+	// Không ghi câu lệnh vào span vì key và giá trị chứa email và OTP.
+	// InstrumentTracing chỉ lỗi với kiểu client không được hỗ trợ.
+	_ = redisotel.InstrumentTracing(rdb, redisotel.WithDBStatement(false))
 	client = rdb
 }
 
-func SaveOTP(otp string, email string) error {
-	err := client.Set(ctx, email, otp, 5*time.Minute).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+func PingRedis(ctx context.Context) error {
+	return client.Ping(ctx).Err()
+}
+
+func RedisPoolStats() *redis.PoolStats {
+	return client.PoolStats()
+}
+
+func CloseRedis() error {
+	return client.Close()
+}
+
+type OTPPurpose string
+
+const (
+	OTPVerifyEmail   OTPPurpose = "verify_email"
+	OTPResetPassword OTPPurpose = "reset_password"
+)
+
+const (
+	otpKeyPrefix   = "otp:"
+	otpTTL         = 5 * time.Minute
+	otpMaxAttempts = 5
+)
+
+func otpKey(purpose OTPPurpose, email string) string {
+	return otpKeyPrefix + string(purpose) + ":" + email
 }
 
-func GetOTP(email string) (string, error) {
-	val, err := client.Get(ctx, email).Result()
+func SaveOTP(ctx context.Context, purpose OTPPurpose, otp string, email string) error {
+	key := otpKey(purpose, email)
+	pipe := client.TxPipeline()
+	pipe.Set(ctx, key, otp, otpTTL)
+	pipe.Del(ctx, key+":attempts")
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func GetOTP(ctx context.Context, purpose OTPPurpose, email string) (string, error) {
+	val, err := client.Get(ctx, otpKey(purpose, email)).Result()
 	if err != nil {
 		return "", err
 	}
 	return val, nil
 }
 
+func DeleteOTP(ctx context.Context, purpose OTPPurpose, email string) error {
+	key := otpKey(purpose, email)
+	return client.Del(ctx, key, key+":attempts").Err()
+}
+
+// VerifyOTP kiểm tra và tiêu thụ mã OTP. Mã chỉ dùng được một lần và bị huỷ
+// sau otpMaxAttempts lần nhập sai.
+func VerifyOTP(ctx context.Context, purpose OTPPurpose, email string, otp string) (bool, error) {
+	key := otpKey(purpose, email)
+	attemptsKey := key + ":attempts"
+
+	stored, err := client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(otp)) != 1 {
+		attempts, err := client.Incr(ctx, attemptsKey).Result()
+		if err != nil {
+			return false, err
+		}
+		if attempts == 1 {
+			client.Expire(ctx, attemptsKey, otpTTL)
+		}
+		if attempts >= otpMaxAttempts {
+			return false, client.Del(ctx, key, attemptsKey).Err()
+		}
+		return false, nil
+	}
+
+	// Chỉ request xoá được key mới được coi là hợp lệ, tránh dùng lại mã khi
+	// có nhiều request đồng thời.
+	deleted, err := client.Del(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	client.Del(ctx, attemptsKey)
+
+	return deleted == 1, nil
+}
+
+const (
+	revokedTokenKeyPrefix   = "revoked:jti:"
+	revokedUserKeyPrefix    = "revoked:user:"
+	revokedSessionKeyPrefix = "revoked:sid:"
+)
+
+// RevokeToken đưa jti vào denylist cho đến khi token hết hạn.
+func RevokeToken(jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return client.Set(ctx, revokedTokenKeyPrefix+jti, 1, ttl).Err()
+}
+
+// RevokeUserTokens thu hồi mọi token của user được cấp trước thời điểm before.
+// ttl nên bằng thời gian sống dài nhất của token. Thời điểm được lưu theo mili
+// giây, cùng độ chính xác với claim iat, để lần đăng nhập ngay sau khi thu hồi
+// không bị từ chối mà token cấp trước đó trong cùng giây vẫn bị thu hồi. iat
+// đọc từ token có thể sớm hơn 1ms do làm tròn số thực, lệch về phía thu hồi.
+func RevokeUserTokens(userID int, before time.Time, ttl time.Duration) error {
+	key := revokedUserKeyPrefix + strconv.Itoa(userID)
+	return client.Set(ctx, key, before.UnixMilli(), ttl).Err()
+}
+
+// RevokeSession thu hồi mọi access token còn hạn của một phiên đăng nhập.
+// ttl nên bằng thời gian sống của access token.
+func RevokeSession(sessionID string, ttl time.Duration) error {
+	return client.Set(ctx, revokedSessionKeyPrefix+sessionID, 1, ttl).Err()
+}
+
+func IsTokenRevoked(jti string, sessionID string, userID int, issuedAt time.Time) (bool, error) {
+	keys := make([]string, 0, 2)
+	if jti != "" {
+		keys = append(keys, revokedTokenKeyPrefix+jti)
+	}
+	if sessionID != "" {
+		keys = append(keys, revokedSessionKeyPrefix+sessionID)
+	}
+	if len(keys) > 0 {
+		n, err := client.Exists(ctx, keys...).Result()
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+
+	before, err := client.Get(ctx, revokedUserKeyPrefix+strconv.Itoa(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return issuedAt.UnixMilli() < before, nil
+}
+
+const oauthStateKeyPrefix = "oauth:state:"
+
+func SaveOAuthState(state string, value string, ttl time.Duration) error {
+	return client.Set(ctx, oauthStateKeyPrefix+state, value, ttl).Err()
+}
+
+// ConsumeOAuthState lấy và xoá state để mỗi state chỉ dùng được một lần.
+func ConsumeOAuthState(state string) (string, error) {
+	return client.GetDel(ctx, oauthStateKeyPrefix+state).Result()
+}
+
+const loginKeyPrefix = "login:"
+
+func loginKey(kind string, scope string, id string) string {
+	return loginKeyPrefix + kind + ":" + scope + ":" + id
+}
+
+// IncrLoginFailures tăng bộ đếm đăng nhập sai, bộ đếm tự hết hạn sau window.
+func IncrLoginFailures(scope string, id string, window time.Duration) (int64, error) {
+	key := loginKey("fail", scope, id)
+	pipe := client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// BlockLogin chặn đăng nhập trong khoảng d. locked = true là khoá tài khoản,
+// ngược lại chỉ là thời gian chờ tăng dần giữa các lần thử.
+func BlockLogin(scope string, id string, locked bool, d time.Duration) error {
+	kind := "delay"
+	if locked {
+		kind = "lock"
+	}
+	return client.Set(ctx, loginKey(kind, scope, id), 1, d).Err()
+}
+
+// LoginBlockTTL trả về trạng thái khoá và thời gian chặn còn lại, 0 nếu không bị chặn.
+func LoginBlockTTL(scope string, id string) (bool, time.Duration, error) {
+	lockTTL, err := client.PTTL(ctx, loginKey("lock", scope, id)).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if lockTTL > 0 {
+		return true, lockTTL, nil
+	}
+
+	delayTTL, err := client.PTTL(ctx, loginKey("delay", scope, id)).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	if delayTTL > 0 {
+		return false, delayTTL, nil
+	}
+
+	return false, 0, nil
+}
+
+func ResetLoginFailures(scope string, id string) error {
+	return client.Del(ctx, loginKey("fail", scope, id), loginKey("delay", scope, id)).Err()
+}
+
+func UnlockLogin(scope string, id string) error {
+	return client.Del(ctx,
+		loginKey("fail", scope, id),
+		loginKey("delay", scope, id),
+		loginKey("lock", scope, id),
+	).Err()
+}
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// rateLimitScript giữ log các request trong sorted set (score = thời điểm ms)
+// để đếm theo cửa sổ trượt. Trả về {allowed, count, reset_ms} với reset_ms là
+// thời gian tới khi request cũ nhất rời khỏi cửa sổ.
+var rateLimitScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local reset = window
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// RateLimitHit ghi nhận một request cho key nếu còn trong giới hạn và trả về
+// số request trong cửa sổ cùng thời gian tới khi có thêm lượt.
+func RateLimitHit(key string, limit int, window time.Duration, now time.Time, member string) (bool, int, time.Duration, error) {
+	res, err := rateLimitScript.Run(ctx, client, []string{rateLimitKeyPrefix + key},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return false, 0, 0, err
+	}
+	return res[0] == 1, int(res[1]), time.Duration(res[2]) * time.Millisecond, nil
+}
+
+const (
+	mfaChallengeKeyPrefix   = "mfa:challenge:"
+	mfaUsedStepKeyPrefix    = "mfa:totp:used:"
+	maxMFAChallengeAttempts = 5
+)
+
+// SaveMFAChallenge lưu challenge của bước xác thực thứ hai theo hash của
+// challenge token.
+func SaveMFAChallenge(tokenHash string, userID int, ttl time.Duration) error {
+	return client.Set(ctx, mfaChallengeKeyPrefix+tokenHash, userID, ttl).Err()
+}
+
+// GetMFAChallenge trả về user id của challenge và tính một lần thử. Quá
+// maxMFAChallengeAttempts lần thì challenge bị huỷ, trả về redis.Nil.
+func GetMFAChallenge(tokenHash string) (int, error) {
+	key := mfaChallengeKeyPrefix + tokenHash
+	userID, err := client.Get(ctx, key).Int()
+	if err != nil {
+		return 0, err
+	}
+
+	attempts, err := client.Incr(ctx, key+":attempts").Result()
+	if err != nil {
+		return 0, err
+	}
+	if attempts == 1 {
+		client.Expire(ctx, key+":attempts", client.TTL(ctx, key).Val())
+	}
+	if attempts > maxMFAChallengeAttempts {
+		client.Del(ctx, key, key+":attempts")
+		return 0, redis.Nil
+	}
+	return userID, nil
+}
+
+// ConsumeMFAChallenge xoá challenge sau khi xác thực thành công. Trả về false
+// nếu một request khác đã dùng challenge này trước.
+func ConsumeMFAChallenge(tokenHash string) (bool, error) {
+	key := mfaChallengeKeyPrefix + tokenHash
+	deleted, err := client.Del(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+	client.Del(ctx, key+":attempts")
+	return deleted == 1, nil
+}
+
+// MarkTOTPStepUsed đánh dấu mã TOTP của một time step đã dùng để chống dùng
+// lại mã trong thời gian còn hiệu lực. Trả về false nếu đã được dùng.
+func MarkTOTPStepUsed(userID int, step int64, ttl time.Duration) (bool, error) {
+	key := mfaUsedStepKeyPrefix + strconv.Itoa(userID) + ":" + strconv.FormatInt(step, 10)
+	return client.SetNX(ctx, key, 1, ttl).Result()
+}
+
+const webauthnSessionKeyPrefix = "webauthn:session:"
+
+// SaveWebAuthnSession lưu dữ liệu của một lần đăng ký hoặc đăng nhập passkey.
+func SaveWebAuthnSession(sessionID string, value string, ttl time.Duration) error {
+	return client.Set(ctx, webauthnSessionKeyPrefix+sessionID, value, ttl).Err()
+}
+
+// ConsumeWebAuthnSession lấy và xoá session, mỗi challenge chỉ dùng được một lần.
+func ConsumeWebAuthnSession(sessionID string) (string, error) {
+	return client.GetDel(ctx, webauthnSessionKeyPrefix+sessionID).Result()
+}
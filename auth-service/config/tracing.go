@@ -0,0 +1,143 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const ServiceName = "auth-service"
+
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+)
+
+// Tracing cấu hình OpenTelemetry. Exporter "otlp" gửi span qua OTLP/HTTP tới
+// OTLPEndpoint (để trống sẽ dùng OTEL_EXPORTER_OTLP_ENDPOINT hoặc
+// localhost:4318), "stdout" và "file" ghi span dạng JSON để xem khi chạy local.
+type Tracing struct {
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	File         string
+	SampleRatio  float64
+}
+
+func loadTracing(l *loader) Tracing {
+	return Tracing{
+		Exporter:     l.string("TRACING_EXPORTER", TracingExporterNone),
+		OTLPEndpoint: l.string("TRACING_OTLP_ENDPOINT", ""),
+		OTLPInsecure: l.bool("TRACING_OTLP_INSECURE", false),
+		File:         l.string("TRACING_FILE", "traces.json"),
+		SampleRatio:  l.float("TRACING_SAMPLE_RATIO", 1),
+	}
+}
+
+func (t Tracing) validate() []string {
+	var problems []string
+	switch t.Exporter {
+	case TracingExporterNone, TracingExporterOTLP, TracingExporterStdout, TracingExporterFile:
+	default:
+		problems = append(problems, fmt.Sprintf("TRACING_EXPORTER must be none, otlp, stdout or file, got %q", t.Exporter))
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		problems = append(problems, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+	return problems
+}
+
+var tracerProvider *sdktrace.TracerProvider
+
+// InitTracing đăng ký TracerProvider toàn cục và propagator W3C trace-context.
+// Propagator luôn được bật để trace id từ request vẫn được chuyển tiếp kể cả
+// khi service không xuất span.
+func InitTracing(conf *Config, log *logrus.Logger) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if conf.Tracing.Exporter == TracingExporterNone {
+		return
+	}
+
+	exporter, err := newSpanExporter(conf.Tracing)
+	if err != nil {
+		log.WithError(err).Fatal("Không thể khởi tạo tracing exporter")
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.DeploymentEnvironment(conf.AppConfig),
+	))
+	if err != nil {
+		log.WithError(err).Fatal("Không thể khởi tạo tracing resource")
+	}
+
+	tracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.Tracing.SampleRatio))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+	log.WithField("exporter", conf.Tracing.Exporter).Info("Đã bật tracing")
+}
+
+func newSpanExporter(conf Tracing) (sdktrace.SpanExporter, error) {
+	switch conf.Exporter {
+	case TracingExporterOTLP:
+		var opts []otlptracehttp.Option
+		if conf.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(conf.OTLPEndpoint))
+		}
+		if conf.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	case TracingExporterFile:
+		file, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	}
+}
+
+// ShutdownTracing xuất nốt các span còn trong bộ đệm.
+func ShutdownTracing(ctx context.Context) error {
+	if tracerProvider == nil {
+		return nil
+	}
+	return tracerProvider.Shutdown(ctx)
+}
+
+// traceHook thêm trace_id và span_id vào log ghi kèm context có span, qua
+// log.WithContext(ctx).
+type traceHook struct{}
+
+func (traceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (traceHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	entry.Data["trace_id"] = spanContext.TraceID().String()
+	entry.Data["span_id"] = spanContext.SpanID().String()
+	return nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceHook_AddsTraceIDs(t *testing.T) {
+	var out bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&out)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	log.AddHook(traceHook{})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+
+	log.WithContext(ctx).Info("with span")
+	log.Info("without span")
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var withSpan, withoutSpan map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &withSpan))
+	require.NoError(t, json.Unmarshal(lines[1], &withoutSpan))
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", withSpan["trace_id"])
+	assert.Equal(t, "00f067aa0ba902b7", withSpan["span_id"])
+	assert.NotContains(t, withoutSpan, "trace_id")
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+)
+
+// WebAuthn cấu hình Relying Party cho passkey. Để trống WEBAUTHN_RP_ID sẽ tắt
+// passkey.
+type WebAuthn struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+	// Attestation là none, indirect, direct hoặc enterprise.
+	Attestation string
+	// AttestationFormats giới hạn các định dạng attestation chấp nhận (packed,
+	// tpm, android-key, apple, fido-u2f, none). Để trống sẽ chấp nhận tất cả.
+	AttestationFormats []string
+}
+
+func loadWebAuthn(l *loader) WebAuthn {
+	return WebAuthn{
+		RPID:               l.string("WEBAUTHN_RP_ID", ""),
+		RPDisplayName:      l.string("WEBAUTHN_RP_NAME", "HealthMate"),
+		RPOrigins:          l.list("WEBAUTHN_RP_ORIGINS"),
+		Attestation:        l.string("WEBAUTHN_ATTESTATION", "none"),
+		AttestationFormats: l.list("WEBAUTHN_ATTESTATION_FORMATS"),
+	}
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+var errInvalidUserID = utils.ErrBadRequest.WithMessage("Invalid user id")
+
+type AdminUserHandler struct {
+	adminUserService services.AdminUserService
+}
+
+func NewAdminUserHandler(adminUserService services.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{
+		adminUserService: adminUserService,
+	}
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List users with optional email, role and status filters. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page, starting at 1"
+// @Param page_size query int false "Page size, at most 100"
+// @Param email query string false "Email contains"
+// @Param role query string false "Has role"
+// @Param status query string false "active, disabled or unverified"
+// @Success 200 {object} utils.Response{data=user.UserListResponse} "Users"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users [get]
+func (h *AdminUserHandler) ListUsers() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.ListUsersRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request query", err))
+			return
+		}
+
+		resp, err := h.adminUserService.ListUsers(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "List users successfully")))
+	}
+}
+
+// CreateUser godoc
+// @Summary Create user
+// @Description Create an account with the given roles. Unless verified is set, a verification OTP is emailed. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user.CreateUserRequest true "New user"
+// @Success 201 {object} utils.Response{data=user.UserResponse} "User created"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or unknown role"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 409 {object} utils.ErrorResponse "Email already registered"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users [post]
+func (h *AdminUserHandler) CreateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.CreateUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.adminUserService.CreateUser(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, utils.ResponseFull(true, resp, middleware.Translate(c, "Create user successfully")))
+	}
+}
+
+// GetUser godoc
+// @Summary Get user
+// @Description Get one user. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User id"
+// @Success 200 {object} utils.Response{data=user.UserResponse} "User"
+// @Failure 400 {object} utils.ErrorResponse "Invalid user id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id} [get]
+func (h *AdminUserHandler) GetUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := userIDParam(c)
+		if !ok {
+			return
+		}
+
+		resp, err := h.adminUserService.GetUser(c.Request.Context(), userID)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Get user successfully")))
+	}
+}
+
+// UpdateUser godoc
+// @Summary Update user
+// @Description Change the email of a user. The account becomes unverified, is signed out everywhere and gets a verification OTP at the new address. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User id"
+// @Param request body user.UpdateUserRequest true "User fields"
+// @Success 200 {object} utils.Response{data=user.UserResponse} "User updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 409 {object} utils.ErrorResponse "Email already registered"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id} [patch]
+func (h *AdminUserHandler) UpdateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := userIDParam(c)
+		if !ok {
+			return
+		}
+		var req user.UpdateUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.adminUserService.UpdateUser(c.Request.Context(), userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Update user successfully")))
+	}
+}
+
+// DeactivateUser godoc
+// @Summary Deactivate user
+// @Description Disable an account, end all of its sessions and revoke its tokens. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User id"
+// @Success 200 {object} utils.Response "User deactivated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid user id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 409 {object} utils.ErrorResponse "Cannot deactivate yourself"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/deactivate [post]
+func (h *AdminUserHandler) DeactivateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+		userID, ok := userIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := h.adminUserService.DeactivateUser(c.Request.Context(), claims, userID); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Deactivate user successfully")))
+	}
+}
+
+// ReactivateUser godoc
+// @Summary Reactivate user
+// @Description Re-enable a deactivated account. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User id"
+// @Success 200 {object} utils.Response "User reactivated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid user id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/reactivate [post]
+func (h *AdminUserHandler) ReactivateUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := userIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := h.adminUserService.ReactivateUser(c.Request.Context(), userID); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Reactivate user successfully")))
+	}
+}
+
+// SetRoles godoc
+// @Summary Set user roles
+// @Description Replace the roles assigned to a user. They apply the next time the user's tokens are refreshed. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User id"
+// @Param request body user.SetRolesRequest true "Role names"
+// @Success 200 {object} utils.Response{data=user.UserResponse} "Roles updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or unknown role"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 409 {object} utils.ErrorResponse "Cannot drop your own admin role"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/roles [put]
+func (h *AdminUserHandler) SetRoles() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+		userID, ok := userIDParam(c)
+		if !ok {
+			return
+		}
+		var req user.SetRolesRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.adminUserService.SetRoles(c.Request.Context(), claims, userID, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Set roles successfully")))
+	}
+}
+
+// ForcePasswordReset godoc
+// @Summary Force password reset
+// @Description Block password login until the user resets their password, sign them out everywhere and email them a reset OTP. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User id"
+// @Success 200 {object} utils.Response "Password reset required"
+// @Failure 400 {object} utils.ErrorResponse "Invalid user id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "User not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/password-reset [post]
+func (h *AdminUserHandler) ForcePasswordReset() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := userIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := h.adminUserService.ForcePasswordReset(c.Request.Context(), userID); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Password reset required, the user has been emailed a reset code")))
+	}
+}
+
+func userIDParam(c *gin.Context) (int, bool) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || userID <= 0 {
+		c.Error(errInvalidUserID)
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type MockAdminUserService struct {
+	mock.Mock
+}
+
+func (m *MockAdminUserService) ListUsers(ctx context.Context, req user.ListUsersRequest) (*user.UserListResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.UserListResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) CreateUser(ctx context.Context, req user.CreateUserRequest) (*user.UserResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.UserResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) GetUser(ctx context.Context, userID int) (*user.UserResponse, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.UserResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) UpdateUser(ctx context.Context, userID int, req user.UpdateUserRequest) (*user.UserResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.UserResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) DeactivateUser(ctx context.Context, claims *utils.JWTClaim, userID int) error {
+	args := m.Called(ctx, claims, userID)
+	return args.Error(0)
+}
+
+func (m *MockAdminUserService) ReactivateUser(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAdminUserService) SetRoles(ctx context.Context, claims *utils.JWTClaim, userID int, req user.SetRolesRequest) (*user.UserResponse, error) {
+	args := m.Called(ctx, claims, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.UserResponse), args.Error(1)
+}
+
+func (m *MockAdminUserService) ForcePasswordReset(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func newAdminUserRouter(h *AdminUserHandler) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	api := router.Group("/admin/users", middleware.Authenticate(), middleware.RequireRole("admin"))
+	api.GET("/:id", h.GetUser())
+	api.POST("/:id/deactivate", h.DeactivateUser())
+	return router
+}
+
+func TestAdminGetUser_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockAdminUserService)
+	router := newAdminUserRouter(NewAdminUserHandler(mockSvc))
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"admin"})
+	assert.NoError(t, err)
+	mockSvc.On("GetUser", mock.Anything, 42).Return(nil, services.ErrUserNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users/42", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAdminDeactivateUser_RequiresAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockAdminUserService)
+	router := newAdminUserRouter(NewAdminUserHandler(mockSvc))
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/2/deactivate", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockSvc.AssertNotCalled(t, "DeactivateUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminDeactivateUser_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockAdminUserService)
+	router := newAdminUserRouter(NewAdminUserHandler(mockSvc))
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"admin"})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/abc/deactivate", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
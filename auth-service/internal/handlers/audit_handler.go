@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListEvents godoc
+// @Summary List audit events
+// @Description List security audit events, newest first, filtered by user, action, outcome and time range. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page, starting at 1"
+// @Param page_size query int false "Page size, at most 200"
+// @Param user_id query int false "User who acted or was acted on"
+// @Param action query string false "Action, e.g. login or user.roles_changed"
+// @Param outcome query string false "success, failure or mfa_required"
+// @Param from query string false "Inclusive start, RFC 3339"
+// @Param to query string false "Exclusive end, RFC 3339"
+// @Success 200 {object} utils.Response{data=audit.EventListResponse} "Audit events"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/audit-events [get]
+func (h *AuditHandler) ListEvents() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req audit.ListEventsRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request query", err))
+			return
+		}
+
+		resp, err := h.auditService.ListEvents(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "List audit events successfully")))
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) ListEvents(ctx context.Context, req audit.ListEventsRequest) (*audit.EventListResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*audit.EventListResponse), args.Error(1)
+}
+
+func newAuditRouter(mockSvc *MockAuditService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewAuditHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.GET("/audit-events", h.ListEvents())
+	return router
+}
+
+func TestListEvents_BindsFilters(t *testing.T) {
+	mockSvc := new(MockAuditService)
+	router := newAuditRouter(mockSvc)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockSvc.On("ListEvents", mock.Anything, mock.MatchedBy(func(req audit.ListEventsRequest) bool {
+		return req.UserID == 7 && req.Action == audit.ActionLogin && req.From.Equal(from) && req.To.IsZero()
+	})).Return(&audit.EventListResponse{Events: []audit.EventResponse{}, Page: 1, PageSize: 50}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/audit-events?user_id=7&action=login&from=2025-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestListEvents_InvalidTimeRange(t *testing.T) {
+	mockSvc := new(MockAuditService)
+	router := newAuditRouter(mockSvc)
+
+	mockSvc.On("ListEvents", mock.Anything, mock.Anything).Return(nil, services.ErrInvalidTimeRange)
+
+	req := httptest.NewRequest(http.MethodGet, "/audit-events?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
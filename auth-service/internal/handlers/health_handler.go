@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusDegraded     = "degraded"
+	HealthStatusDown         = "down"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+// healthErrorUnavailable replaces the error of a failed check in responses:
+// probes are unauthenticated and raw errors name internal hosts and ports.
+const healthErrorUnavailable = "unavailable"
+
+// HealthCheck pings one dependency the service needs to serve requests.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies,omitempty"`
+}
+
+type HealthHandler struct {
+	checks       []HealthCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+	log          *logrus.Logger
+}
+
+func NewHealthHandler(timeout time.Duration, log *logrus.Logger, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+		log:     log,
+	}
+}
+
+// ShutDown makes readiness fail so that load balancers stop routing new
+// requests here while in-flight ones drain.
+func (h *HealthHandler) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness reports that the process is up. It checks no dependencies, so an
+// outage of Postgres or Redis does not get the pod restarted.
+func (h *HealthHandler) Liveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, ReadinessResponse{Status: HealthStatusOK})
+	}
+}
+
+// Readiness pings every dependency concurrently, each within the configured
+// timeout, and answers 503 with the status of each one unless all are up.
+// Like the JWKS endpoint it skips the response envelope, as probes only look
+// at the status code.
+func (h *HealthHandler) Readiness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.shuttingDown.Load() {
+			c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Status: HealthStatusShuttingDown})
+			return
+		}
+
+		resp := ReadinessResponse{
+			Status:       HealthStatusOK,
+			Dependencies: make(map[string]DependencyStatus, len(h.checks)),
+		}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, check := range h.checks {
+			wg.Add(1)
+			go func(check HealthCheck) {
+				defer wg.Done()
+				status := h.run(c.Request.Context(), check)
+
+				mu.Lock()
+				defer mu.Unlock()
+				resp.Dependencies[check.Name] = status
+				if status.Status != HealthStatusOK {
+					resp.Status = HealthStatusDegraded
+				}
+			}(check)
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		if resp.Status != HealthStatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, resp)
+	}
+}
+
+func (h *HealthHandler) run(ctx context.Context, check HealthCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	status := DependencyStatus{Status: HealthStatusOK, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		utils.LoggerFromContext(ctx, h.log).WithField("dependency", check.Name).Warn("Readiness check failed: ", err)
+		status.Status = HealthStatusDown
+		status.Error = healthErrorUnavailable
+	}
+	return status
+}
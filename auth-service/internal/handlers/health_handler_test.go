@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupHealthRouter(h *HealthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/healthz", h.Liveness())
+	router.GET("/readyz", h.Readiness())
+	return router
+}
+
+func up(ctx context.Context) error { return nil }
+
+func TestReadiness_AllDependenciesUp(t *testing.T) {
+	router := setupHealthRouter(NewHealthHandler(time.Second, logrus.New(),
+		HealthCheck{Name: "postgres", Check: up},
+		HealthCheck{Name: "redis", Check: up},
+	))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp ReadinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, HealthStatusOK, resp.Status)
+	assert.Equal(t, HealthStatusOK, resp.Dependencies["postgres"].Status)
+	assert.Equal(t, HealthStatusOK, resp.Dependencies["redis"].Status)
+}
+
+func TestReadiness_ReportsDegradedDependency(t *testing.T) {
+	router := setupHealthRouter(NewHealthHandler(20*time.Millisecond, logrus.New(),
+		HealthCheck{Name: "postgres", Check: up},
+		HealthCheck{Name: "redis", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	var resp ReadinessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, HealthStatusDegraded, resp.Status)
+	assert.Equal(t, HealthStatusOK, resp.Dependencies["postgres"].Status)
+	assert.Equal(t, HealthStatusDown, resp.Dependencies["redis"].Status)
+	assert.Equal(t, "unavailable", resp.Dependencies["redis"].Error)
+	assert.NotContains(t, w.Body.String(), context.DeadlineExceeded.Error())
+}
+
+func TestReadiness_FailsWhileShuttingDown(t *testing.T) {
+	h := NewHealthHandler(time.Second, logrus.New(), HealthCheck{Name: "postgres", Check: up})
+	router := setupHealthRouter(h)
+	h.ShutDown()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.JSONEq(t, `{"status":"shutting_down"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLiveness_IgnoresDependencies(t *testing.T) {
+	router := setupHealthRouter(NewHealthHandler(time.Second, logrus.New(),
+		HealthCheck{Name: "postgres", Check: func(ctx context.Context) error { return errors.New("connection refused") }},
+	))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
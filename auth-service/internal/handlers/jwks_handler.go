@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type JWKSHandler struct{}
+
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{}
+}
+
+// JWKS publishes the public keys that verify tokens issued by this service,
+// including retired keys whose tokens may still be valid. The body is a plain
+// RFC 7517 key set, not the usual response envelope, so that standard JWT
+// libraries can consume it.
+func (h *JWKSHandler) JWKS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, utils.PublicJWKS())
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	userprovider "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user_provider"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+var errAuthorizationDenied = utils.ErrBadRequest.WithMessage("Authorization denied by provider")
+
+type OAuthHandler struct {
+	oauthService services.OAuthService
+}
+
+func NewOAuthHandler(oauthService services.OAuthService) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+	}
+}
+
+// Start godoc
+// @Summary Start social login
+// @Description Redirect to the authorization page of the provider (google, apple, facebook)
+// @Tags oauth
+// @Param provider path string true "Provider name"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} utils.ErrorResponse "Unknown provider"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/oauth/{provider}/start [get]
+func (h *OAuthHandler) Start() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authURL, err := h.oauthService.AuthCodeURL(c.Request.Context(), c.Param("provider"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// Callback godoc
+// @Summary Social login callback
+// @Description Finish the authorization-code flow and log in the linked user
+// @Tags oauth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} utils.Response{data=user.LoginResponse} "Login successful"
+// @Failure 400 {object} utils.ErrorResponse "Invalid callback"
+// @Failure 401 {object} utils.ErrorResponse "Provider authentication failed"
+// @Failure 403 {object} utils.ErrorResponse "Email not verified"
+// @Failure 404 {object} utils.ErrorResponse "Unknown provider"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/oauth/{provider}/callback [get]
+func (h *OAuthHandler) Callback() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req userprovider.OAuthCallbackRequest
+		if err := c.ShouldBind(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid callback", err))
+			return
+		}
+		if req.Error != "" || req.Code == "" {
+			c.Error(errAuthorizationDenied)
+			return
+		}
+
+		resp, err := h.oauthService.HandleCallback(c.Request.Context(), c.Param("provider"), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(
+			true,
+			resp,
+			middleware.Translate(c, "Login with provider successfully"),
+		))
+	}
+}
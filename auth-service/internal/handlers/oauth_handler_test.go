@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	userprovider "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user_provider"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+)
+
+type MockOAuthService struct {
+	mock.Mock
+}
+
+func (m *MockOAuthService) AuthCodeURL(ctx context.Context, provider string) (string, error) {
+	args := m.Called(ctx, provider)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOAuthService) HandleCallback(ctx context.Context, provider string, req userprovider.OAuthCallbackRequest) (*user.LoginResponse, error) {
+	args := m.Called(ctx, provider, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.LoginResponse), args.Error(1)
+}
+
+func setupOAuthRouter(h *OAuthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.GET("/oauth/:provider/start", h.Start())
+	router.GET("/oauth/:provider/callback", h.Callback())
+	router.POST("/oauth/:provider/callback", h.Callback())
+	return router
+}
+
+func TestOAuthStart_Redirects(t *testing.T) {
+	mockSvc := new(MockOAuthService)
+	mockSvc.On("AuthCodeURL", mock.Anything, "google").Return("https://accounts.google.com/o/oauth2/v2/auth?state=abc", nil)
+	router := setupOAuthRouter(NewOAuthHandler(mockSvc))
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/google/start", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://accounts.google.com/o/oauth2/v2/auth?state=abc", w.Header().Get("Location"))
+}
+
+func TestOAuthStart_UnknownProvider(t *testing.T) {
+	mockSvc := new(MockOAuthService)
+	mockSvc.On("AuthCodeURL", mock.Anything, "github").Return("", services.ErrUnknownOAuthProvider)
+	router := setupOAuthRouter(NewOAuthHandler(mockSvc))
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/github/start", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOAuthCallback_Success(t *testing.T) {
+	mockSvc := new(MockOAuthService)
+	reqData := userprovider.OAuthCallbackRequest{Code: "code", State: "state"}
+	mockSvc.On("HandleCallback", mock.Anything, "google", reqData).Return(&user.LoginResponse{AccessToken: "jwt-token"}, nil)
+	router := setupOAuthRouter(NewOAuthHandler(mockSvc))
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/google/callback?code=code&state=state", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "jwt-token")
+}
+
+func TestOAuthCallback_ProviderError(t *testing.T) {
+	mockSvc := new(MockOAuthService)
+	router := setupOAuthRouter(NewOAuthHandler(mockSvc))
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth/google/callback?error=access_denied&state=state", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "HandleCallback", mock.Anything, mock.Anything, mock.Anything)
+}
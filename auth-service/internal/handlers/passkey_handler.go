@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/passkey"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+var errInvalidPasskeyID = utils.ErrBadRequest.WithMessage("Invalid passkey id")
+
+type PasskeyHandler struct {
+	passkeyService services.PasskeyService
+}
+
+func NewPasskeyHandler(passkeyService services.PasskeyService) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+	}
+}
+
+// BeginRegistration godoc
+// @Summary Begin passkey registration
+// @Description Get the options for navigator.credentials.create()
+// @Tags passkeys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body passkey.BeginRegistrationRequest false "Passkey name"
+// @Success 200 {object} utils.Response{data=passkey.BeginResponse} "Registration options"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "Passkeys not configured"
+// @Router /auth/passkeys/register/begin [post]
+func (h *PasskeyHandler) BeginRegistration() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		var req passkey.BeginRegistrationRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.Error(utils.InvalidRequest("Invalid request body", err))
+				return
+			}
+		}
+
+		resp, err := h.passkeyService.BeginRegistration(c.Request.Context(), claims, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Passkey registration started")))
+	}
+}
+
+// FinishRegistration godoc
+// @Summary Finish passkey registration
+// @Description Verify the attestation returned by navigator.credentials.create() and save the passkey
+// @Tags passkeys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body passkey.FinishRegistrationRequest true "Session and credential"
+// @Success 201 {object} utils.Response{data=passkey.PasskeyResponse} "Passkey registered"
+// @Failure 400 {object} utils.ErrorResponse "Invalid session or attestation"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "Passkeys not configured"
+// @Router /auth/passkeys/register/finish [post]
+func (h *PasskeyHandler) FinishRegistration() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		var req passkey.FinishRegistrationRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.passkeyService.FinishRegistration(c.Request.Context(), claims, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, utils.ResponseFull(true, resp, middleware.Translate(c, "Register passkey successfully")))
+	}
+}
+
+// BeginLogin godoc
+// @Summary Begin passkey login
+// @Description Get the options for navigator.credentials.get() with a discoverable passkey
+// @Tags passkeys
+// @Produce json
+// @Success 200 {object} utils.Response{data=passkey.BeginResponse} "Assertion options"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "Passkeys not configured"
+// @Router /auth/passkeys/login/begin [post]
+func (h *PasskeyHandler) BeginLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.passkeyService.BeginLogin(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Passkey login started")))
+	}
+}
+
+// FinishLogin godoc
+// @Summary Finish passkey login
+// @Description Verify the assertion returned by navigator.credentials.get() and log in
+// @Tags passkeys
+// @Accept json
+// @Produce json
+// @Param request body passkey.FinishLoginRequest true "Session and credential"
+// @Success 200 {object} utils.Response{data=user.LoginResponse} "Login successful"
+// @Failure 400 {object} utils.ErrorResponse "Invalid session"
+// @Failure 401 {object} utils.ErrorResponse "Passkey verification failed"
+// @Failure 403 {object} utils.ErrorResponse "Email not verified"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "Passkeys not configured"
+// @Router /auth/passkeys/login/finish [post]
+func (h *PasskeyHandler) FinishLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req passkey.FinishLoginRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.passkeyService.FinishLogin(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Login with passkey successfully")))
+	}
+}
+
+// BeginSecondFactor godoc
+// @Summary Begin passkey second factor
+// @Description Get assertion options for a login that returned mfa_required
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param request body passkey.BeginSecondFactorRequest true "Challenge token"
+// @Success 200 {object} utils.Response{data=passkey.BeginResponse} "Assertion options"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or no passkey"
+// @Failure 401 {object} utils.ErrorResponse "Invalid challenge"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "Passkeys not configured"
+// @Router /auth/2fa/webauthn/begin [post]
+func (h *PasskeyHandler) BeginSecondFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req passkey.BeginSecondFactorRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.passkeyService.BeginSecondFactor(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Passkey verification started")))
+	}
+}
+
+// FinishSecondFactor godoc
+// @Summary Finish passkey second factor
+// @Description Verify the passkey assertion and finish the login
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param request body passkey.FinishSecondFactorRequest true "Challenge token, session and credential"
+// @Success 200 {object} utils.Response{data=user.LoginResponse} "Login successful"
+// @Failure 400 {object} utils.ErrorResponse "Invalid session"
+// @Failure 401 {object} utils.ErrorResponse "Invalid challenge or passkey"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "Passkeys not configured"
+// @Router /auth/2fa/webauthn/finish [post]
+func (h *PasskeyHandler) FinishSecondFactor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req passkey.FinishSecondFactorRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.passkeyService.FinishSecondFactor(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Login with passkey successfully")))
+	}
+}
+
+// List godoc
+// @Summary List passkeys
+// @Description List the passkeys of the current user
+// @Tags passkeys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]passkey.PasskeyResponse} "Passkeys"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/passkeys [get]
+func (h *PasskeyHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		resp, err := h.passkeyService.List(c.Request.Context(), claims)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "List passkeys successfully")))
+	}
+}
+
+// Delete godoc
+// @Summary Delete passkey
+// @Description Remove a passkey of the current user
+// @Tags passkeys
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Passkey id"
+// @Success 200 {object} utils.Response "Passkey deleted"
+// @Failure 400 {object} utils.ErrorResponse "Invalid id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 404 {object} utils.ErrorResponse "Passkey not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/passkeys/{id} [delete]
+func (h *PasskeyHandler) Delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.Error(errInvalidPasskeyID)
+			return
+		}
+
+		err = h.passkeyService.Delete(c.Request.Context(), claims, id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Delete passkey successfully")))
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/passkey"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type MockPasskeyService struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyService) BeginRegistration(ctx context.Context, claims *utils.JWTClaim, req passkey.BeginRegistrationRequest) (*passkey.BeginResponse, error) {
+	args := m.Called(ctx, claims, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*passkey.BeginResponse), args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishRegistration(ctx context.Context, claims *utils.JWTClaim, req passkey.FinishRegistrationRequest) (*passkey.PasskeyResponse, error) {
+	args := m.Called(ctx, claims, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*passkey.PasskeyResponse), args.Error(1)
+}
+
+func (m *MockPasskeyService) BeginLogin(ctx context.Context) (*passkey.BeginResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*passkey.BeginResponse), args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishLogin(ctx context.Context, req passkey.FinishLoginRequest) (*user.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.LoginResponse), args.Error(1)
+}
+
+func (m *MockPasskeyService) BeginSecondFactor(ctx context.Context, req passkey.BeginSecondFactorRequest) (*passkey.BeginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*passkey.BeginResponse), args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishSecondFactor(ctx context.Context, req passkey.FinishSecondFactorRequest) (*user.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.LoginResponse), args.Error(1)
+}
+
+func (m *MockPasskeyService) List(ctx context.Context, claims *utils.JWTClaim) ([]passkey.PasskeyResponse, error) {
+	args := m.Called(ctx, claims)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]passkey.PasskeyResponse), args.Error(1)
+}
+
+func (m *MockPasskeyService) Delete(ctx context.Context, claims *utils.JWTClaim, id int) error {
+	args := m.Called(ctx, claims, id)
+	return args.Error(0)
+}
+
+func TestPasskeyBeginLogin_Unavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockPasskeyService)
+	h := NewPasskeyHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/passkeys/login/begin", h.BeginLogin())
+
+	mockSvc.On("BeginLogin", mock.Anything).Return(nil, services.ErrPasskeysUnavailable)
+
+	req := httptest.NewRequest(http.MethodPost, "/passkeys/login/begin", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestPasskeyDelete_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockPasskeyService)
+	h := NewPasskeyHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.DELETE("/passkeys/:id", middleware.Authenticate(), h.Delete())
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+	mockSvc.On("Delete", mock.Anything, mock.AnythingOfType("*utils.JWTClaim"), 7).Return(services.ErrPasskeyNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/passkeys/7", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
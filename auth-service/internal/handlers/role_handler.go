@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+var errInvalidID = utils.ErrBadRequest.WithMessage("Invalid id")
+
+type RoleHandler struct {
+	roleService services.RoleService
+}
+
+func NewRoleHandler(roleService services.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description List the role catalogue with each role's parent and direct permissions. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]role.RoleResponse} "Roles"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/roles [get]
+func (h *RoleHandler) ListRoles() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.roleService.ListRoles(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "List roles successfully")))
+	}
+}
+
+// GetRole godoc
+// @Summary Get role
+// @Description Get one role. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role id"
+// @Success 200 {object} utils.Response{data=role.RoleResponse} "Role"
+// @Failure 400 {object} utils.ErrorResponse "Invalid role id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "Role not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/roles/{id} [get]
+func (h *RoleHandler) GetRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogueIDParam(c)
+		if !ok {
+			return
+		}
+
+		resp, err := h.roleService.GetRole(c.Request.Context(), id)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Get role successfully")))
+	}
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Description Create a role, optionally inheriting from a parent role. Permissions must already exist. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body role.CreateRoleRequest true "New role"
+// @Success 201 {object} utils.Response{data=role.RoleResponse} "Role created"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request, unknown parent or permission"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 409 {object} utils.ErrorResponse "Role already exists"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/roles [post]
+func (h *RoleHandler) CreateRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req role.CreateRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.roleService.CreateRole(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, utils.ResponseFull(true, resp, middleware.Translate(c, "Create role successfully")))
+	}
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Change the description or parent of a role. An empty parent removes it. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role id"
+// @Param request body role.UpdateRoleRequest true "Role fields"
+// @Success 200 {object} utils.Response{data=role.RoleResponse} "Role updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request, unknown parent or inheritance cycle"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "Role not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/roles/{id} [patch]
+func (h *RoleHandler) UpdateRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogueIDParam(c)
+		if !ok {
+			return
+		}
+		var req role.UpdateRoleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.roleService.UpdateRole(c.Request.Context(), id, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Update role successfully")))
+	}
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Delete a role and unassign it from every user. The user and admin roles cannot be deleted. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role id"
+// @Success 200 {object} utils.Response "Role deleted"
+// @Failure 400 {object} utils.ErrorResponse "Invalid role id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "Role not found"
+// @Failure 409 {object} utils.ErrorResponse "Built-in role"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/roles/{id} [delete]
+func (h *RoleHandler) DeleteRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogueIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := h.roleService.DeleteRole(c.Request.Context(), id); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Delete role successfully")))
+	}
+}
+
+// SetRolePermissions godoc
+// @Summary Set role permissions
+// @Description Replace the permissions granted directly to a role. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role id"
+// @Param request body role.SetPermissionsRequest true "Permission names"
+// @Success 200 {object} utils.Response{data=role.RoleResponse} "Permissions updated"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or unknown permission"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "Role not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/roles/{id}/permissions [put]
+func (h *RoleHandler) SetRolePermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogueIDParam(c)
+		if !ok {
+			return
+		}
+		var req role.SetPermissionsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.roleService.SetRolePermissions(c.Request.Context(), id, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Set role permissions successfully")))
+	}
+}
+
+// ListPermissions godoc
+// @Summary List permissions
+// @Description List the permission catalogue. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]role.PermissionResponse} "Permissions"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/permissions [get]
+func (h *RoleHandler) ListPermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := h.roleService.ListPermissions(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "List permissions successfully")))
+	}
+}
+
+// CreatePermission godoc
+// @Summary Create permission
+// @Description Add a permission to the catalogue so that it can be granted to roles. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body role.CreatePermissionRequest true "New permission"
+// @Success 201 {object} utils.Response{data=role.PermissionResponse} "Permission created"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 409 {object} utils.ErrorResponse "Permission already exists"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/permissions [post]
+func (h *RoleHandler) CreatePermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req role.CreatePermissionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.roleService.CreatePermission(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, utils.ResponseFull(true, resp, middleware.Translate(c, "Create permission successfully")))
+	}
+}
+
+// DeletePermission godoc
+// @Summary Delete permission
+// @Description Delete a permission and revoke it from every role. Admin only
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Permission id"
+// @Success 200 {object} utils.Response "Permission deleted"
+// @Failure 400 {object} utils.ErrorResponse "Invalid permission id"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 403 {object} utils.ErrorResponse "Admin role required"
+// @Failure 404 {object} utils.ErrorResponse "Permission not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/permissions/{id} [delete]
+func (h *RoleHandler) DeletePermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := catalogueIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := h.roleService.DeletePermission(c.Request.Context(), id); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Delete permission successfully")))
+	}
+}
+
+func catalogueIDParam(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.Error(errInvalidID)
+		return 0, false
+	}
+	return id, true
+}
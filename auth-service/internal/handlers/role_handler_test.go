@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+)
+
+type MockRoleService struct {
+	mock.Mock
+}
+
+func (m *MockRoleService) ListRoles(ctx context.Context) ([]role.RoleResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]role.RoleResponse), args.Error(1)
+}
+
+func (m *MockRoleService) GetRole(ctx context.Context, id int) (*role.RoleResponse, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*role.RoleResponse), args.Error(1)
+}
+
+func (m *MockRoleService) CreateRole(ctx context.Context, req role.CreateRoleRequest) (*role.RoleResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*role.RoleResponse), args.Error(1)
+}
+
+func (m *MockRoleService) UpdateRole(ctx context.Context, id int, req role.UpdateRoleRequest) (*role.RoleResponse, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*role.RoleResponse), args.Error(1)
+}
+
+func (m *MockRoleService) DeleteRole(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) SetRolePermissions(ctx context.Context, id int, req role.SetPermissionsRequest) (*role.RoleResponse, error) {
+	args := m.Called(ctx, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*role.RoleResponse), args.Error(1)
+}
+
+func (m *MockRoleService) ListPermissions(ctx context.Context) ([]role.PermissionResponse, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]role.PermissionResponse), args.Error(1)
+}
+
+func (m *MockRoleService) CreatePermission(ctx context.Context, req role.CreatePermissionRequest) (*role.PermissionResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*role.PermissionResponse), args.Error(1)
+}
+
+func (m *MockRoleService) DeletePermission(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCreateRole_Conflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockRoleService)
+	h := NewRoleHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/roles", h.CreateRole())
+
+	mockSvc.On("CreateRole", mock.Anything, role.CreateRoleRequest{Name: "doctor"}).Return(nil, services.ErrRoleExists)
+
+	req := httptest.NewRequest(http.MethodPost, "/roles", strings.NewReader(`{"name":"doctor"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestSetRolePermissions_UnknownPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockRoleService)
+	h := NewRoleHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.PUT("/roles/:id/permissions", h.SetRolePermissions())
+
+	mockSvc.On("SetRolePermissions", mock.Anything, 3, role.SetPermissionsRequest{Permissions: []string{"nope"}}).
+		Return(nil, services.ErrUnknownPermission)
+
+	req := httptest.NewRequest(http.MethodPut, "/roles/3/permissions", strings.NewReader(`{"permissions":["nope"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type SessionHandler struct {
+	sessionService services.SessionService
+}
+
+func NewSessionHandler(sessionService services.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// List godoc
+// @Summary List sessions
+// @Description List the devices the current user is signed in on
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]session.SessionResponse} "Sessions"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/sessions [get]
+func (h *SessionHandler) List() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		resp, err := h.sessionService.List(c.Request.Context(), claims)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "List sessions successfully")))
+	}
+}
+
+// Revoke godoc
+// @Summary Revoke session
+// @Description Sign the current user out of one device
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session id"
+// @Success 200 {object} utils.Response "Session revoked"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 404 {object} utils.ErrorResponse "Session not found"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/sessions/{id} [delete]
+func (h *SessionHandler) Revoke() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		err := h.sessionService.Revoke(c.Request.Context(), claims, c.Param("id"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Revoke session successfully")))
+	}
+}
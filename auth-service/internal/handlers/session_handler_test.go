@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) List(ctx context.Context, claims *utils.JWTClaim) ([]session.SessionResponse, error) {
+	args := m.Called(ctx, claims)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]session.SessionResponse), args.Error(1)
+}
+
+func (m *MockSessionService) Revoke(ctx context.Context, claims *utils.JWTClaim, id string) error {
+	args := m.Called(ctx, claims, id)
+	return args.Error(0)
+}
+
+func TestSessionList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockSessionService)
+	h := NewSessionHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.GET("/sessions", middleware.Authenticate(), h.List())
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+	mockSvc.On("List", mock.Anything, mock.MatchedBy(func(claims *utils.JWTClaim) bool {
+		return claims.SessionID == "sid-1"
+	})).Return([]session.SessionResponse{{ID: "sid-1", DeviceName: "Chrome on Windows", Current: true}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data []session.SessionResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Data, 1)
+	assert.True(t, body.Data[0].Current)
+}
+
+func TestSessionRevoke_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockSessionService)
+	h := NewSessionHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.DELETE("/sessions/:id", middleware.Authenticate(), h.Revoke())
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+	mockSvc.On("Revoke", mock.Anything, mock.AnythingOfType("*utils.JWTClaim"), "sid-9").Return(services.ErrSessionNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/sessions/sid-9", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
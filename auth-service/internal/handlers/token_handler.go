@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/token"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type TokenHandler struct {
+	tokenService services.TokenService
+	cacheTTL     time.Duration
+}
+
+func NewTokenHandler(tokenService services.TokenService, cacheTTL time.Duration) *TokenHandler {
+	return &TokenHandler{
+		tokenService: tokenService,
+		cacheTTL:     cacheTTL,
+	}
+}
+
+// Introspect godoc
+// @Summary Token introspection
+// @Description RFC 7662 token introspection for internal services, authenticated with client credentials
+// @Tags token
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BasicAuth
+// @Param token formData string true "Access or refresh token"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} token.IntrospectionResponse "Introspection result"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid client credentials"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/introspect [post]
+func (h *TokenHandler) Introspect() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req token.IntrospectionRequest
+		if err := c.ShouldBind(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.tokenService.Introspect(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", h.maxAge(resp)))
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// maxAge never lets a cached active response outlive the token itself.
+func (h *TokenHandler) maxAge(resp *token.IntrospectionResponse) int {
+	ttl := h.cacheTTL
+	if resp.Active {
+		if remaining := time.Until(time.Unix(resp.Exp, 0)); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	return int(ttl / time.Second)
+}
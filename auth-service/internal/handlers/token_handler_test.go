@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/token"
+)
+
+type MockTokenService struct {
+	mock.Mock
+}
+
+func (m *MockTokenService) Introspect(ctx context.Context, req token.IntrospectionRequest) (*token.IntrospectionResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*token.IntrospectionResponse), args.Error(1)
+}
+
+func setupTokenRouter(h *TokenHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/introspect", middleware.ClientCredentials(map[string]string{"profile-service": "s3cret"}), h.Introspect())
+	return router
+}
+
+func introspectRequest(tokenValue string) *http.Request {
+	form := url.Values{"token": {tokenValue}}
+	req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestIntrospect_InvalidClient(t *testing.T) {
+	mockSvc := new(MockTokenService)
+	router := setupTokenRouter(NewTokenHandler(mockSvc, 30*time.Second))
+
+	req := introspectRequest("jwt-token")
+	req.SetBasicAuth("profile-service", "wrong")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSvc.AssertNotCalled(t, "Introspect", mock.Anything, mock.Anything)
+}
+
+func TestIntrospect_ActiveToken(t *testing.T) {
+	mockSvc := new(MockTokenService)
+	exp := time.Now().Add(10 * time.Second).Unix()
+	mockSvc.On("Introspect", mock.Anything, token.IntrospectionRequest{Token: "jwt-token"}).
+		Return(&token.IntrospectionResponse{Active: true, Sub: "1", Exp: exp}, nil)
+	router := setupTokenRouter(NewTokenHandler(mockSvc, 30*time.Second))
+
+	req := introspectRequest("jwt-token")
+	req.SetBasicAuth("profile-service", "s3cret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"active":true`)
+	// The cache lifetime is capped by the token expiry.
+	cacheControl := w.Header().Get("Cache-Control")
+	assert.True(t, cacheControl == "private, max-age=9" || cacheControl == "private, max-age=10", cacheControl)
+}
+
+func TestIntrospect_InactiveToken(t *testing.T) {
+	mockSvc := new(MockTokenService)
+	mockSvc.On("Introspect", mock.Anything, token.IntrospectionRequest{Token: "jwt-token"}).
+		Return(&token.IntrospectionResponse{Active: false}, nil)
+	router := setupTokenRouter(NewTokenHandler(mockSvc, 30*time.Second))
+
+	req := introspectRequest("jwt-token")
+	req.SetBasicAuth("profile-service", "s3cret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
+	assert.Equal(t, "private, max-age=30", w.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	twofactor "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/two_factor"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+// Enroll godoc
+// @Summary Start 2FA enrollment
+// @Description Generate a TOTP secret and the otpauth:// URI to show as a QR code
+// @Tags 2fa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=twofactor.EnrollResponse} "Secret generated"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 409 {object} utils.ErrorResponse "2FA already enabled"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Failure 503 {object} utils.ErrorResponse "2FA not configured"
+// @Router /auth/2fa/enroll [post]
+func (h *TwoFactorHandler) Enroll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		resp, err := h.twoFactorService.Enroll(c.Request.Context(), claims)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Scan the QR code and confirm with a code")))
+	}
+}
+
+// Confirm godoc
+// @Summary Confirm 2FA enrollment
+// @Description Enable 2FA with the first code of the authenticator app and get the recovery codes
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body twofactor.ConfirmRequest true "TOTP code"
+// @Success 200 {object} utils.Response{data=twofactor.RecoveryCodesResponse} "2FA enabled"
+// @Failure 400 {object} utils.ErrorResponse "Invalid code or not enrolled"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 409 {object} utils.ErrorResponse "2FA already enabled"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/2fa/confirm [post]
+func (h *TwoFactorHandler) Confirm() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		var req twofactor.ConfirmRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.twoFactorService.Confirm(c.Request.Context(), claims, req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Two-factor authentication enabled")))
+	}
+}
+
+// Disable godoc
+// @Summary Disable 2FA
+// @Description Turn 2FA off with a current TOTP code or a recovery code
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body twofactor.DisableRequest true "TOTP or recovery code"
+// @Success 200 {object} utils.Response "2FA disabled"
+// @Failure 400 {object} utils.ErrorResponse "Invalid code or 2FA not enabled"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/2fa/disable [post]
+func (h *TwoFactorHandler) Disable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		var req twofactor.DisableRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if err := h.twoFactorService.Disable(c.Request.Context(), claims, req); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Two-factor authentication disabled")))
+	}
+}
+
+// Verify godoc
+// @Summary Verify second factor
+// @Description Finish a login that returned mfa_required with a TOTP code or a recovery code
+// @Tags 2fa
+// @Accept json
+// @Produce json
+// @Param request body twofactor.VerifyRequest true "Challenge token and code"
+// @Success 200 {object} utils.Response{data=user.LoginResponse} "Login successful"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid code or challenge"
+// @Failure 403 {object} utils.ErrorResponse "Account disabled"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/2fa/verify [post]
+func (h *TwoFactorHandler) Verify() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req twofactor.VerifyRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.twoFactorService.Verify(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Login with email successfully")))
+	}
+}
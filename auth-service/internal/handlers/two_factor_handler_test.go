@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
+	twofactor "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/two_factor"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) Enroll(ctx context.Context, claims *utils.JWTClaim) (*twofactor.EnrollResponse, error) {
+	args := m.Called(ctx, claims)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*twofactor.EnrollResponse), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Confirm(ctx context.Context, claims *utils.JWTClaim, req twofactor.ConfirmRequest) (*twofactor.RecoveryCodesResponse, error) {
+	args := m.Called(ctx, claims, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*twofactor.RecoveryCodesResponse), args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(ctx context.Context, claims *utils.JWTClaim, req twofactor.DisableRequest) error {
+	args := m.Called(ctx, claims, req)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) Verify(ctx context.Context, req twofactor.VerifyRequest) (*user.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.LoginResponse), args.Error(1)
+}
+
+func TestTwoFactorEnroll_AlreadyEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockTwoFactorService)
+	h := NewTwoFactorHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/2fa/enroll", middleware.Authenticate(), h.Enroll())
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+	mockSvc.On("Enroll", mock.Anything, mock.AnythingOfType("*utils.JWTClaim")).Return(nil, services.ErrTwoFactorAlreadyEnabled)
+
+	req := httptest.NewRequest(http.MethodPost, "/2fa/enroll", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestTwoFactorVerify_InvalidCode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockTwoFactorService)
+	h := NewTwoFactorHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/2fa/verify", h.Verify())
+
+	reqData := twofactor.VerifyRequest{ChallengeToken: "challenge", Code: "123456"}
+	mockSvc.On("Verify", mock.Anything, reqData).Return(nil, services.ErrInvalidTwoFactorCode)
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/2fa/verify", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestTwoFactorVerify_RequiresACode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockTwoFactorService)
+	h := NewTwoFactorHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/2fa/verify", h.Verify())
+
+	req := httptest.NewRequest(http.MethodPost, "/2fa/verify", bytes.NewBufferString(`{"challenge_token":"challenge"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything)
+}
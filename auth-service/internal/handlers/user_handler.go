@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
@@ -21,33 +25,290 @@ func NewUserHandler(userService services.UserService) *UserHandler {
 
 // LoginWithEmail godoc
 // @Summary Login with email
-// @Description Login with email
+// @Description Login with email. When 2FA is enabled the response has mfa_required and a challenge_token for POST /auth/2fa/verify instead of the token pair
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body user.AuthRequest true "Login request"
 // @Success 200 {object} utils.Response{data=user.LoginResponse} "Login successful"
 // @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid email or password"
+// @Failure 403 {object} utils.ErrorResponse "Email not verified, account disabled or password reset required"
+// @Failure 429 {object} utils.ErrorResponse "Too many failed attempts or account locked"
 // @Failure 500 {object} utils.ErrorResponse "Internal server error"
 // @Router /auth/login [post]
 func (h *UserHandler) LoginWithEmail() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req user.AuthRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, utils.ErrorResponseFull(false, "Invalid request body: "+ err.Error()))
+			c.Error(utils.InvalidRequest("Invalid request body", err))
 			return
 		}
 
 		resp, err := h.userService.LoginWithEmail(c.Request.Context(), req)
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, utils.ErrorResponseFull(false, "Login failed: "+ err.Error()))
+			c.Error(err)
+			return
+		}
+		if resp.MFARequired {
+			c.JSON(http.StatusOK, utils.ResponseFull(true, resp, middleware.Translate(c, "Two-factor verification required")))
 			return
 		}
 
 		c.JSON(http.StatusOK, utils.ResponseFull(
 			true,
 			resp,
-			"Login with email successfully",
+			middleware.Translate(c, "Login with email successfully"),
+		))
+	}
+}
+
+// Register godoc
+// @Summary Register with email
+// @Description Create an inactive account and send a verification OTP to the email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body user.RegisterRequest true "Register request"
+// @Success 201 {object} utils.Response{data=user.RegisterResponse} "Register successful"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 409 {object} utils.ErrorResponse "Email already registered"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/register [post]
+func (h *UserHandler) Register() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.RegisterRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.userService.Register(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, utils.ResponseFull(
+			true,
+			resp,
+			middleware.Translate(c, "Register successfully, please check your email for the verification code"),
 		))
 	}
-}
\ No newline at end of file
+}
+
+// VerifyEmail godoc
+// @Summary Verify email
+// @Description Activate an account with the OTP sent by email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body user.VerifyEmailRequest true "Verify email request"
+// @Success 200 {object} utils.Response "Email verified"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or OTP"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/verify-email [post]
+func (h *UserHandler) VerifyEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.VerifyEmailRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		err := h.userService.VerifyEmail(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Verify email successfully")))
+	}
+}
+
+// RefreshToken godoc
+// @Summary Refresh token
+// @Description Exchange a refresh token for a new token pair. The refresh token is rotated on every call
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body user.RefreshTokenRequest true "Refresh token request"
+// @Success 200 {object} utils.Response{data=user.LoginResponse} "Refresh token successful"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Invalid or reused refresh token"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/refresh [post]
+func (h *UserHandler) RefreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.RefreshTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		resp, err := h.userService.RefreshToken(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseFull(
+			true,
+			resp,
+			middleware.Translate(c, "Refresh token successfully"),
+		))
+	}
+}
+
+// ForgotPassword godoc
+// @Summary Forgot password
+// @Description Send a password reset OTP to the email. The response is the same whether or not the email is registered
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body user.ForgotPasswordRequest true "Forgot password request"
+// @Success 200 {object} utils.Response "Reset code sent if the email is registered"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/password/forgot [post]
+func (h *UserHandler) ForgotPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.ForgotPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if err := h.userService.ForgotPassword(c.Request.Context(), req); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "If the email is registered, a password reset code has been sent")))
+	}
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password with the OTP sent by the forgot password endpoint
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body user.ResetPasswordRequest true "Reset password request"
+// @Success 200 {object} utils.Response "Password reset"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request or OTP"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/password/reset [post]
+func (h *UserHandler) ResetPassword() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.ResetPasswordRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		err := h.userService.ResetPassword(c.Request.Context(), req)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Reset password successfully")))
+	}
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current access token and the refresh token
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response "Logout successful"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/logout [post]
+func (h *UserHandler) Logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		if err := h.userService.Logout(c.Request.Context(), claims); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Logout successfully")))
+	}
+}
+
+// LogoutAll godoc
+// @Summary Logout from all devices
+// @Description Revoke every token issued to the current user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response "Logout successful"
+// @Failure 401 {object} utils.ErrorResponse "Invalid access token"
+// @Failure 429 {object} utils.ErrorResponse "Too many requests"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /auth/logout-all [post]
+func (h *UserHandler) LogoutAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.GetClaims(c)
+		if !ok {
+			c.Error(utils.ErrUnauthorized)
+			return
+		}
+
+		if err := h.userService.LogoutAll(c.Request.Context(), claims); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Logout from all devices successfully")))
+	}
+}
+
+// UnlockAccount godoc
+// @Summary Unlock account
+// @Description Clear the login lockout of an email, and optionally of an IP. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body user.UnlockAccountRequest true "Unlock request"
+// @Success 200 {object} utils.Response "Account unlocked"
+// @Failure 400 {object} utils.ErrorResponse "Invalid request"
+// @Failure 401 {object} utils.ErrorResponse "Unauthorized"
+// @Failure 403 {object} utils.ErrorResponse "Forbidden"
+// @Failure 500 {object} utils.ErrorResponse "Internal server error"
+// @Router /admin/lockouts/unlock [post]
+func (h *UserHandler) UnlockAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req user.UnlockAccountRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(utils.InvalidRequest("Invalid request body", err))
+			return
+		}
+
+		if err := h.userService.UnlockAccount(c.Request.Context(), req); err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, middleware.Translate(c, "Unlock account successfully")))
+	}
+}
@@ -7,11 +7,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
 )
 
 type MockUserService struct {
@@ -27,6 +34,58 @@ func (m *MockUserService) LoginWithEmail(ctx context.Context, req user.AuthReque
 	return args.Get(0).(*user.LoginResponse), args.Error(1)
 }
 
+func (m *MockUserService) Register(ctx context.Context, req user.RegisterRequest) (*user.RegisterResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.RegisterResponse), args.Error(1)
+}
+
+func (m *MockUserService) VerifyEmail(ctx context.Context, req user.VerifyEmailRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RefreshToken(ctx context.Context, req user.RefreshTokenRequest) (*user.LoginResponse, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.LoginResponse), args.Error(1)
+}
+
+func (m *MockUserService) Logout(ctx context.Context, claims *utils.JWTClaim) error {
+	args := m.Called(ctx, claims)
+	return args.Error(0)
+}
+
+func (m *MockUserService) LogoutAll(ctx context.Context, claims *utils.JWTClaim) error {
+	args := m.Called(ctx, claims)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ForgotPassword(ctx context.Context, req user.ForgotPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ResetPassword(ctx context.Context, req user.ResetPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockUserService) UnlockAccount(ctx context.Context, req user.UnlockAccountRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func setupRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	config.InitRedisServer(&config.Config{RedisHost: mr.Host(), RedisPort: mr.Port()})
+	return mr
+}
+
 func TestLoginWithEmail_InvalidJSON(t *testing.T){
 	gin.SetMode(gin.TestMode)
 
@@ -34,6 +93,7 @@ func TestLoginWithEmail_InvalidJSON(t *testing.T){
 	h := NewUserHandler(mockService)
 
 	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
 	router.POST("/login", h.LoginWithEmail())
 
 	reqBody := `{"email":"test@example.com","password":"password"`
@@ -53,6 +113,7 @@ func TestLoginWithEmail_LoginFailed(t *testing.T) {
 	h := NewUserHandler(mockSvc)
 
 	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
 	router.POST("/login", h.LoginWithEmail())
 
 	reqData := user.AuthRequest{
@@ -72,12 +133,36 @@ func TestLoginWithEmail_LoginFailed(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "error")
 }
 
+func TestLoginWithEmail_InvalidCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/login", h.LoginWithEmail())
+
+	reqData := user.AuthRequest{Email: "test@example.com", Password: "wrongpass"}
+	mockSvc.On("LoginWithEmail", mock.Anything, reqData).Return(nil, services.ErrInvalidCredentials.Wrap(utils.ErrPasswordMismatch))
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.JSONEq(t, `{"status":false,"code":"invalid_credentials","message":"Invalid email or password"}`, w.Body.String())
+}
+
 func TestLoginWithEmail_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockSvc := new(MockUserService)
 	h := NewUserHandler(mockSvc)
 
 	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
 	router.POST("/login", h.LoginWithEmail())
 
 	reqData := user.AuthRequest{
@@ -100,4 +185,285 @@ func TestLoginWithEmail_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "Login with email successfully")
 	assert.Contains(t, w.Body.String(), "jwt-token")
-}
\ No newline at end of file
+}
+
+func TestLoginWithEmail_NotVerified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/login", h.LoginWithEmail())
+
+	reqData := user.AuthRequest{Email: "test@example.com", Password: "123456"}
+	mockSvc.On("LoginWithEmail", mock.Anything, reqData).Return(nil, services.ErrEmailNotVerified)
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRegister_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/register", h.Register())
+
+	reqData := user.RegisterRequest{Email: "new@example.com", Password: "Passw0rd123"}
+	mockSvc.On("Register", mock.Anything, reqData).Return(&user.RegisterResponse{UserID: 1, Email: reqData.Email}, nil)
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), "new@example.com")
+}
+
+func TestRegister_EmailAlreadyRegistered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/register", h.Register())
+
+	reqData := user.RegisterRequest{Email: "test@example.com", Password: "Passw0rd123"}
+	mockSvc.On("Register", mock.Anything, reqData).Return(nil, services.ErrEmailAlreadyRegistered)
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestRegister_ValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/register", h.Register())
+
+	reqBody := `{"email":"not-an-email","password":"123456"}`
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"status": false,
+		"code": "validation_failed",
+		"message": "Invalid request body",
+		"errors": [
+			{"field": "email", "rule": "email", "message": "email must be a valid email address"},
+			{"field": "password", "rule": "password", "message": "password must be at least 8 characters long and contain an upper case letter, a lower case letter and a digit"}
+		]
+	}`, w.Body.String())
+	mockSvc.AssertNotCalled(t, "Register", mock.Anything, mock.Anything)
+}
+
+func TestRegister_ValidationErrorsInVietnamese(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/register", h.Register())
+
+	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "vi")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"status": false,
+		"code": "validation_failed",
+		"message": "Nội dung yêu cầu không hợp lệ",
+		"errors": [
+			{"field": "email", "rule": "required", "message": "email là bắt buộc"},
+			{"field": "password", "rule": "required", "message": "password là bắt buộc"}
+		]
+	}`, w.Body.String())
+}
+
+func TestVerifyEmail_InvalidOTP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/verify-email", h.VerifyEmail())
+
+	reqData := user.VerifyEmailRequest{Email: "test@example.com", OTP: "123456"}
+	mockSvc.On("VerifyEmail", mock.Anything, reqData).Return(services.ErrInvalidOTP)
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/verify-email", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRefreshToken_Reused(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/refresh", h.RefreshToken())
+
+	reqData := user.RefreshTokenRequest{RefreshToken: "old-refresh-token"}
+	mockSvc.On("RefreshToken", mock.Anything, reqData).Return(nil, services.ErrRefreshTokenReused)
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogout_MissingToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/logout", middleware.Authenticate(), h.Logout())
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSvc.AssertNotCalled(t, "Logout", mock.Anything, mock.Anything)
+}
+
+func TestLogout_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/logout", middleware.Authenticate(), h.Logout())
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+	mockSvc.On("Logout", mock.Anything, mock.AnythingOfType("*utils.JWTClaim")).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSvc.AssertCalled(t, "Logout", mock.Anything, mock.AnythingOfType("*utils.JWTClaim"))
+}
+
+func TestLogoutAll_RevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupRedis(t)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/logout-all", middleware.Authenticate(), h.LogoutAll())
+
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+	claims, err := utils.ValidateJwtToken(accessToken)
+	assert.NoError(t, err)
+	assert.NoError(t, config.RevokeToken(claims.ID, utils.AccessTokenTTL))
+
+	req := httptest.NewRequest(http.MethodPost, "/logout-all", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSvc.AssertNotCalled(t, "LogoutAll", mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_InvalidOTP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/password/reset", h.ResetPassword())
+
+	reqData := user.ResetPasswordRequest{Email: "test@example.com", OTP: "123456", NewPassword: "NewPassw0rd"}
+	mockSvc.On("ResetPassword", mock.Anything, reqData).Return(services.ErrInvalidOTP)
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestLoginWithEmail_Locked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := new(MockUserService)
+	h := NewUserHandler(mockSvc)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(logrus.New()))
+	router.POST("/login", h.LoginWithEmail())
+
+	reqData := user.AuthRequest{Email: "test@example.com", Password: "123456"}
+	mockSvc.On("LoginWithEmail", mock.Anything, reqData).Return(nil, &services.LoginLockedError{Locked: true, RetryAfter: 90 * time.Second})
+
+	body, _ := json.Marshal(reqData)
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+}
@@ -0,0 +1,70 @@
+// Package i18n holds the catalogue of user-facing API messages. Messages are
+// written in English in the code and looked up here by that English text, so
+// a message without a translation falls back to English.
+package i18n
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Language is a language the API can answer in.
+type Language string
+
+const (
+	English    Language = "en"
+	Vietnamese Language = "vi"
+)
+
+// Default is used when Accept-Language names no supported language.
+const Default = English
+
+var (
+	supported = []Language{English, Vietnamese}
+	matcher   = language.NewMatcher([]language.Tag{language.English, language.Vietnamese})
+)
+
+var catalogues = map[Language]map[string]string{
+	Vietnamese: vietnamese,
+}
+
+// Negotiate picks the supported language preferred by an Accept-Language
+// header, honouring q-values.
+func Negotiate(acceptLanguage string) Language {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return Default
+	}
+	return supported[index]
+}
+
+// T translates message into lang, or returns it unchanged when the catalogue
+// has no translation.
+func T(lang Language, message string) string {
+	if translated, ok := catalogues[lang][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// FieldMessage describes a failed validation rule on field. kind is "string",
+// "number" or "items" and selects the wording of size rules such as min.
+func FieldMessage(lang Language, field string, rule string, param string, kind string) string {
+	templates, ok := fieldTemplates[lang]
+	if !ok {
+		templates = fieldTemplates[Default]
+	}
+	template, ok := templates[rule+"."+kind]
+	if !ok {
+		template, ok = templates[rule]
+	}
+	if !ok {
+		template = templates["default"]
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(template)
+}
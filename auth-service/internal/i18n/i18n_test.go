@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Language
+	}{
+		{"vi-VN,vi;q=0.9,en;q=0.8", Vietnamese},
+		{"en-US,en;q=0.9", English},
+		{"fr-FR,vi;q=0.5", Vietnamese},
+		{"en;q=0.3,vi;q=0.7", Vietnamese},
+		{"fr", English},
+		{"", English},
+		{"not a header;;", English},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Negotiate(tt.header), tt.header)
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "Email hoặc mật khẩu không đúng", T(Vietnamese, "Invalid email or password"))
+	assert.Equal(t, "Invalid email or password", T(English, "Invalid email or password"))
+	assert.Equal(t, "Something new", T(Vietnamese, "Something new"))
+}
+
+func TestFieldMessage(t *testing.T) {
+	assert.Equal(t, "email is required", FieldMessage(English, "email", "required", "", "string"))
+	assert.Equal(t, "otp phải có đúng 6 ký tự", FieldMessage(Vietnamese, "otp", "len", "6", "string"))
+	assert.Equal(t, "limit must be at most 100", FieldMessage(English, "limit", "max", "100", "number"))
+	assert.Equal(t, "role must contain at least 1 items", FieldMessage(English, "role", "min", "1", "items"))
+	assert.Equal(t, "code is invalid", FieldMessage(English, "code", "uuid", "", "string"))
+	assert.Equal(t, "code is invalid", FieldMessage(Language("de"), "code", "uuid", "", "string"))
+}
+
+// messageArgs maps the calls that take a user-facing message to the index of
+// that argument.
+var messageArgs = map[string]int{
+	"NewAppError":    2,
+	"WithMessage":    0,
+	"InvalidRequest": 0,
+	"Translate":      1,
+}
+
+func TestCatalogueIsComplete(t *testing.T) {
+	root := filepath.Join("..", "..")
+	fset := token.NewFileSet()
+	var checked int
+	var missing []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "docs" || d.Name() == "tests") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			index, ok := messageArgs[calleeName(call.Fun)]
+			if !ok || index >= len(call.Args) {
+				return true
+			}
+			lit, ok := call.Args[index].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			message, _ := strconv.Unquote(lit.Value)
+			checked++
+			if _, ok := vietnamese[message]; !ok {
+				missing = append(missing, fset.Position(lit.Pos()).String()+": "+message)
+			}
+			return true
+		})
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, checked, 100)
+	assert.Empty(t, missing, "messages without a Vietnamese translation")
+}
+
+func calleeName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return f.Sel.Name
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package i18n
+
+// vietnamese translates the English API messages. TestCatalogueIsComplete
+// fails when an error or response message in the code has no entry here.
+var vietnamese = map[string]string{
+	// Generic errors.
+	"Invalid request":       "Yêu cầu không hợp lệ",
+	"Invalid request body":  "Nội dung yêu cầu không hợp lệ",
+	"Invalid request query": "Tham số truy vấn không hợp lệ",
+	"Invalid callback":      "Callback không hợp lệ",
+	"Unauthorized":          "Chưa xác thực",
+	"Forbidden":             "Không có quyền truy cập",
+	"Not found":             "Không tìm thấy",
+	"Too many requests, please try again later": "Quá nhiều yêu cầu, vui lòng thử lại sau",
+	"Internal server error":                     "Lỗi máy chủ nội bộ",
+
+	// Authentication.
+	"Missing bearer token":                                   "Thiếu bearer token",
+	"Invalid access token":                                   "Access token không hợp lệ",
+	"Access token has been revoked":                          "Access token đã bị thu hồi",
+	"Insufficient role":                                      "Không đủ vai trò",
+	"Insufficient permission":                                "Không đủ quyền",
+	"Invalid client credentials":                             "Thông tin xác thực client không hợp lệ",
+	"Invalid email or password":                              "Email hoặc mật khẩu không đúng",
+	"Account temporarily locked, please try again later":     "Tài khoản tạm thời bị khoá, vui lòng thử lại sau",
+	"Too many failed login attempts, please try again later": "Đăng nhập sai quá nhiều lần, vui lòng thử lại sau",
+	"Email already registered":                               "Email đã được đăng ký",
+	"Email not verified":                                     "Email chưa được xác minh",
+	"Invalid or expired OTP":                                 "OTP không hợp lệ hoặc đã hết hạn",
+	"Invalid refresh token":                                  "Refresh token không hợp lệ",
+	"Refresh token reuse detected":                           "Phát hiện refresh token bị sử dụng lại",
+	"Account disabled":                                       "Tài khoản đã bị vô hiệu hoá",
+	"Password reset required":                                "Cần đặt lại mật khẩu",
+	"Login with email successfully":                          "Đăng nhập bằng email thành công",
+	"Two-factor verification required":                       "Cần xác minh hai bước",
+	"Register successfully, please check your email for the verification code": "Đăng ký thành công, vui lòng kiểm tra email để lấy mã xác minh",
+	"Verify email successfully":                                       "Xác minh email thành công",
+	"Refresh token successfully":                                      "Làm mới token thành công",
+	"If the email is registered, a password reset code has been sent": "Nếu email đã được đăng ký, mã đặt lại mật khẩu đã được gửi",
+	"Reset password successfully":                                     "Đặt lại mật khẩu thành công",
+	"Logout successfully":                                             "Đăng xuất thành công",
+	"Logout from all devices successfully":                            "Đăng xuất khỏi mọi thiết bị thành công",
+	"Unlock account successfully":                                     "Mở khoá tài khoản thành công",
+
+	// Social login.
+	"Authorization denied by provider":         "Nhà cung cấp đã từ chối uỷ quyền",
+	"Unknown OAuth provider":                   "Nhà cung cấp OAuth không được hỗ trợ",
+	"Invalid or expired OAuth state":           "OAuth state không hợp lệ hoặc đã hết hạn",
+	"OAuth code exchange failed":               "Trao đổi mã OAuth thất bại",
+	"Provider did not return a verified email": "Nhà cung cấp không trả về email đã xác minh",
+	"Login with provider successfully":         "Đăng nhập bằng nhà cung cấp thành công",
+
+	// Two-factor authentication.
+	"Two-factor authentication is not configured": "Xác thực hai bước chưa được cấu hình",
+	"Two-factor authentication already enabled":   "Xác thực hai bước đã được bật",
+	"Two-factor authentication not enrolled":      "Chưa đăng ký xác thực hai bước",
+	"Two-factor authentication not enabled":       "Xác thực hai bước chưa được bật",
+	"Invalid two-factor code":                     "Mã xác thực hai bước không đúng",
+	"Invalid or expired MFA challenge":            "Phiên xác thực hai bước không hợp lệ hoặc đã hết hạn",
+	"Scan the QR code and confirm with a code":    "Quét mã QR và xác nhận bằng mã",
+	"Two-factor authentication enabled":           "Đã bật xác thực hai bước",
+	"Two-factor authentication disabled":          "Đã tắt xác thực hai bước",
+
+	// Passkeys.
+	"Passkeys are not configured":                                            "Passkey chưa được cấu hình",
+	"Invalid or expired WebAuthn session":                                    "Phiên WebAuthn không hợp lệ hoặc đã hết hạn",
+	"Passkey verification failed":                                            "Xác minh passkey thất bại",
+	"Passkey sign counter did not increase, the authenticator may be cloned": "Bộ đếm chữ ký của passkey không tăng, thiết bị xác thực có thể đã bị sao chép",
+	"Passkey not found":                                                      "Không tìm thấy passkey",
+	"Invalid passkey id":                                                     "Mã passkey không hợp lệ",
+	"Passkey registration started":                                           "Bắt đầu đăng ký passkey",
+	"Register passkey successfully":                                          "Đăng ký passkey thành công",
+	"Passkey login started":                                                  "Bắt đầu đăng nhập bằng passkey",
+	"Login with passkey successfully":                                        "Đăng nhập bằng passkey thành công",
+	"Passkey verification started":                                           "Bắt đầu xác minh passkey",
+	"List passkeys successfully":                                             "Lấy danh sách passkey thành công",
+	"Delete passkey successfully":                                            "Xoá passkey thành công",
+
+	// Sessions.
+	"Session not found":           "Không tìm thấy phiên đăng nhập",
+	"List sessions successfully":  "Lấy danh sách phiên đăng nhập thành công",
+	"Revoke session successfully": "Thu hồi phiên đăng nhập thành công",
+
+	// User administration.
+	"User not found":  "Không tìm thấy người dùng",
+	"Invalid user id": "Mã người dùng không hợp lệ",
+	"Admins cannot deactivate themselves or drop their own admin role": "Quản trị viên không thể tự vô hiệu hoá hoặc tự bỏ vai trò admin",
+	"List users successfully":                                         "Lấy danh sách người dùng thành công",
+	"Create user successfully":                                        "Tạo người dùng thành công",
+	"Get user successfully":                                           "Lấy thông tin người dùng thành công",
+	"Update user successfully":                                        "Cập nhật người dùng thành công",
+	"Deactivate user successfully":                                    "Vô hiệu hoá người dùng thành công",
+	"Reactivate user successfully":                                    "Kích hoạt lại người dùng thành công",
+	"Set roles successfully":                                          "Gán vai trò thành công",
+	"Password reset required, the user has been emailed a reset code": "Đã yêu cầu đặt lại mật khẩu, mã đặt lại đã được gửi tới email người dùng",
+
+	// Roles and permissions.
+	"Role not found":            "Không tìm thấy vai trò",
+	"Permission not found":      "Không tìm thấy quyền",
+	"Unknown role":              "Vai trò không tồn tại",
+	"Unknown permission":        "Quyền không tồn tại",
+	"Role already exists":       "Vai trò đã tồn tại",
+	"Permission already exists": "Quyền đã tồn tại",
+	"Role cannot inherit from itself or its descendants": "Vai trò không thể kế thừa chính nó hoặc vai trò con của nó",
+	"Built-in roles cannot be deleted":                   "Không thể xoá vai trò có sẵn",
+	"Invalid id":                                         "Mã không hợp lệ",
+	"List roles successfully":                            "Lấy danh sách vai trò thành công",
+	"Get role successfully":                              "Lấy thông tin vai trò thành công",
+	"Create role successfully":                           "Tạo vai trò thành công",
+	"Update role successfully":                           "Cập nhật vai trò thành công",
+	"Delete role successfully":                           "Xoá vai trò thành công",
+	"Set role permissions successfully":                  "Gán quyền cho vai trò thành công",
+	"List permissions successfully":                      "Lấy danh sách quyền thành công",
+	"Create permission successfully":                     "Tạo quyền thành công",
+	"Delete permission successfully":                     "Xoá quyền thành công",
+
+	// Audit log.
+	"From must be before to":         "Thời điểm bắt đầu phải trước thời điểm kết thúc",
+	"List audit events successfully": "Lấy nhật ký kiểm toán thành công",
+}
+
+// fieldTemplates describe failed validation rules. {field} is the JSON or
+// form name of the field and {param} the parameter of the rule.
+var fieldTemplates = map[Language]map[string]string{
+	English: {
+		"required":         "{field} is required",
+		"required_without": "{field} is required",
+		"email":            "{field} must be a valid email address",
+		"min.string":       "{field} must be at least {param} characters long",
+		"min.number":       "{field} must be at least {param}",
+		"min.items":        "{field} must contain at least {param} items",
+		"max.string":       "{field} must be at most {param} characters long",
+		"max.number":       "{field} must be at most {param}",
+		"max.items":        "{field} must contain at most {param} items",
+		"len":              "{field} must be exactly {param} characters long",
+		"numeric":          "{field} must contain only digits",
+		"oneof":            "{field} must be one of: {param}",
+		"ip":               "{field} must be a valid IP address",
+		"password":         "{field} must be at least 8 characters long and contain an upper case letter, a lower case letter and a digit",
+		"vnphone":          "{field} must be a valid Vietnamese phone number",
+		"type":             "{field} has the wrong type",
+		"default":          "{field} is invalid",
+	},
+	Vietnamese: {
+		"required":         "{field} là bắt buộc",
+		"required_without": "{field} là bắt buộc",
+		"email":            "{field} phải là địa chỉ email hợp lệ",
+		"min.string":       "{field} phải có ít nhất {param} ký tự",
+		"min.number":       "{field} phải lớn hơn hoặc bằng {param}",
+		"min.items":        "{field} phải có ít nhất {param} phần tử",
+		"max.string":       "{field} không được dài quá {param} ký tự",
+		"max.number":       "{field} phải nhỏ hơn hoặc bằng {param}",
+		"max.items":        "{field} không được có quá {param} phần tử",
+		"len":              "{field} phải có đúng {param} ký tự",
+		"numeric":          "{field} chỉ được chứa chữ số",
+		"oneof":            "{field} phải là một trong các giá trị: {param}",
+		"ip":               "{field} phải là địa chỉ IP hợp lệ",
+		"password":         "{field} phải có ít nhất 8 ký tự, gồm chữ hoa, chữ thường và chữ số",
+		"vnphone":          "{field} phải là số điện thoại Việt Nam hợp lệ",
+		"type":             "{field} sai kiểu dữ liệu",
+		"default":          "{field} không hợp lệ",
+	},
+}
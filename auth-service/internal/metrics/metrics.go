@@ -0,0 +1,103 @@
+// Package metrics defines the Prometheus metrics of the auth service. They
+// are registered with the default registry, which /metrics serves.
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "auth"
+
+// Outcomes of a login attempt.
+const (
+	LoginSuccess               = "success"
+	LoginMFARequired           = "mfa_required"
+	LoginBadPassword           = "bad_password"
+	LoginNotFound              = "not_found"
+	LoginLocked                = "locked"
+	LoginUnverified            = "unverified"
+	LoginDisabled              = "disabled"
+	LoginPasswordResetRequired = "password_reset_required"
+	LoginBadSecondFactor       = "bad_second_factor"
+	LoginBadPasskey            = "bad_passkey"
+	LoginError                 = "error"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	LoginAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "Login attempts by method and outcome.",
+	}, []string{"method", "outcome"})
+
+	TokensIssued = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_issued_total",
+		Help:      "JWTs issued by type (access or refresh).",
+	}, []string{"type"})
+
+	OTPSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "otp_sent_total",
+		Help:      "OTPs emailed by purpose.",
+	}, []string{"purpose"})
+
+	OTPVerified = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "otp_verified_total",
+		Help:      "OTP checks by purpose and result (valid or invalid).",
+	}, []string{"purpose", "result"})
+
+	// PasswordHashDuration covers both hashing a new password and comparing a
+	// password with a stored hash, which is most of the cost of a login.
+	PasswordHashDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "password_hash_duration_seconds",
+		Help:      "Duration of password hashing by operation (hash or compare).",
+		Buckets:   []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"operation"})
+)
+
+// RegisterDBPool exports the connection pool statistics of db.
+func RegisterDBPool(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
+
+// RegisterRedisPool exports the connection pool statistics returned by stats.
+func RegisterRedisPool(stats func() *redis.PoolStats) {
+	gauge := func(name string, help string, value func(*redis.PoolStats) uint32) {
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "redis_pool",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(stats())) })
+	}
+	counter := func(name string, help string, value func(*redis.PoolStats) uint32) {
+		promauto.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redis_pool",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return float64(value(stats())) })
+	}
+
+	gauge("connections", "Connections in the Redis pool.", func(s *redis.PoolStats) uint32 { return s.TotalConns })
+	gauge("idle_connections", "Idle connections in the Redis pool.", func(s *redis.PoolStats) uint32 { return s.IdleConns })
+	counter("hits_total", "Times a free connection was found in the Redis pool.", func(s *redis.PoolStats) uint32 { return s.Hits })
+	counter("misses_total", "Times no free connection was found in the Redis pool.", func(s *redis.PoolStats) uint32 { return s.Misses })
+	counter("timeouts_total", "Times waiting for a Redis pool connection timed out.", func(s *redis.PoolStats) uint32 { return s.Timeouts })
+	counter("stale_connections_total", "Stale connections removed from the Redis pool.", func(s *redis.PoolStats) uint32 { return s.StaleConns })
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+const claimsKey = "claims"
+
+var (
+	errMissingBearerToken     = utils.ErrUnauthorized.WithMessage("Missing bearer token")
+	errInvalidAccessToken     = utils.ErrUnauthorized.WithMessage("Invalid access token")
+	errTokenRevoked           = utils.ErrUnauthorized.WithMessage("Access token has been revoked")
+	errInsufficientRole       = utils.ErrForbidden.WithMessage("Insufficient role")
+	errInsufficientPermission = utils.ErrForbidden.WithMessage("Insufficient permission")
+)
+
+// Authenticate validates the bearer access token, checks it against the
+// revocation denylist and stores its claims in the gin context and in the
+// request context.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := utils.ExtractBearerToken(c.GetHeader("Authorization"))
+		if !ok {
+			abortWithError(c, errMissingBearerToken)
+			return
+		}
+
+		claims, err := utils.ValidateJwtToken(token)
+		if err != nil {
+			abortWithError(c, errInvalidAccessToken.Wrap(err))
+			return
+		}
+
+		revoked, err := config.IsTokenRevoked(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+		if err != nil {
+			abortWithError(c, utils.Internal(err))
+			return
+		}
+		if revoked {
+			abortWithError(c, errTokenRevoked)
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		ctx := utils.WithClaims(c.Request.Context(), claims)
+		ctx = utils.WithLogFields(ctx, logrus.Fields{"user_id": claims.UserID})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequireRole allows the request when the token has at least one of roles.
+// It must run after Authenticate.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			abortWithError(c, utils.ErrUnauthorized)
+			return
+		}
+
+		for _, role := range roles {
+			if contains(claims.Role, role) {
+				c.Next()
+				return
+			}
+		}
+
+		abortWithError(c, errInsufficientRole)
+	}
+}
+
+// RequirePermission allows the request only when the token has every one of
+// permissions. It must run after Authenticate.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			abortWithError(c, utils.ErrUnauthorized)
+			return
+		}
+
+		for _, permission := range permissions {
+			if !contains(claims.Permission, permission) {
+				abortWithError(c, errInsufficientPermission)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// GetClaims returns the claims stored by Authenticate.
+func GetClaims(c *gin.Context) (*utils.JWTClaim, bool) {
+	value, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*utils.JWTClaim)
+	return claims, ok
+}
+
+func contains(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
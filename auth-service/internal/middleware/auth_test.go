@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+func setupRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	config.InitRedisServer(&config.Config{RedisHost: mr.Host(), RedisPort: mr.Port()})
+	return mr
+}
+
+func setupRouter(guards ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(logrus.New()))
+	handlers := append([]gin.HandlerFunc{Authenticate()}, guards...)
+	handlers = append(handlers, func(c *gin.Context) {
+		claims, _ := GetClaims(c)
+		c.JSON(http.StatusOK, utils.ResponseFull(true, claims.UserID, "ok"))
+	})
+	router.GET("/protected", handlers...)
+	return router
+}
+
+func doRequest(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthenticate_MissingToken(t *testing.T) {
+	setupRedis(t)
+	w := doRequest(setupRouter(), "")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":false`)
+}
+
+func TestAuthenticate_RejectsRefreshToken(t *testing.T) {
+	setupRedis(t)
+	_, refreshToken, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	w := doRequest(setupRouter(), refreshToken)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticate_Success(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	w := doRequest(setupRouter(), accessToken)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequireRole(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusForbidden, doRequest(setupRouter(RequireRole("admin")), accessToken).Code)
+	assert.Equal(t, http.StatusOK, doRequest(setupRouter(RequireRole("admin", "user")), accessToken).Code)
+}
+
+func TestRequirePermission(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "", []string{"user:read"}, []string{"user"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, doRequest(setupRouter(RequirePermission("user:read")), accessToken).Code)
+	assert.Equal(t, http.StatusForbidden, doRequest(setupRouter(RequirePermission("user:read", "user:write")), accessToken).Code)
+}
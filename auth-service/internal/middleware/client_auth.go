@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+const clientIDKey = "client_id"
+
+var errInvalidClient = utils.ErrUnauthorized.WithMessage("Invalid client credentials")
+
+// ClientCredentials authenticates internal services with HTTP Basic client
+// credentials, or client_id/client_secret form fields as allowed by RFC 6749.
+func ClientCredentials(clients map[string]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientID, clientSecret, ok := c.Request.BasicAuth()
+		if !ok {
+			clientID, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+		}
+
+		expected, known := clients[clientID]
+		if clientID == "" || !known || subtle.ConstantTimeCompare([]byte(expected), []byte(clientSecret)) != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="healthmate"`)
+			abortWithError(c, errInvalidClient)
+			return
+		}
+
+		c.Set(clientIDKey, clientID)
+		c.Next()
+	}
+}
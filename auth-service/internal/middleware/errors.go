@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/validation"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+// ErrorHandler renders the last error added with c.Error once the rest of the
+// chain has run, unless a response was already written. An AppError is
+// rendered with its status, code and public message in the language of the
+// request, along with the fields that failed validation; any other error is
+// logged and answered with a generic internal error, so that repository and
+// crypto errors never reach clients. It must run before the handlers and
+// middleware that report errors.
+func ErrorHandler(log *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		appErr := utils.AsAppError(err)
+		if appErr.Status >= http.StatusInternalServerError {
+			utils.LoggerFromContext(c.Request.Context(), log).WithError(err).Error("Request failed")
+		}
+		message := Translate(c, appErr.Message)
+		if appErr.Detail != "" {
+			message += ": " + appErr.Detail
+		}
+		resp := &utils.ErrorResponse{Status: false, Code: appErr.Code, Message: message}
+		if appErr.Code == utils.ErrorValidation {
+			resp.Errors = validation.FieldErrors(err, Language(c))
+		}
+		c.JSON(appErr.Status, resp)
+	}
+}
+
+// abortWithError stops the chain and leaves err for ErrorHandler to render.
+func abortWithError(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+func setupErrorRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	router := gin.New()
+	router.Use(ErrorHandler(log))
+	router.GET("/", handler)
+	return router
+}
+
+func serve(router *gin.Engine) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestErrorHandler_RendersAppError(t *testing.T) {
+	errGone := utils.NewAppError("thing_gone", http.StatusGone, "Thing is gone")
+	router := setupErrorRouter(func(c *gin.Context) {
+		c.Error(fmt.Errorf("load thing: %w", errGone.Wrap(errors.New("row deleted"))))
+	})
+
+	w := serve(router)
+
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.JSONEq(t, `{"status":false,"code":"thing_gone","message":"Thing is gone"}`, w.Body.String())
+}
+
+func TestErrorHandler_HidesUnknownErrors(t *testing.T) {
+	router := setupErrorRouter(func(c *gin.Context) {
+		c.Error(errors.New("pq: relation \"users\" does not exist"))
+	})
+
+	w := serve(router)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"status":false,"code":"internal_error","message":"Internal server error"}`, w.Body.String())
+}
+
+func TestErrorHandler_KeepsWrittenResponse(t *testing.T) {
+	router := setupErrorRouter(func(c *gin.Context) {
+		c.Error(errors.New("rate limiter unavailable"))
+		c.JSON(http.StatusOK, utils.ResponseNotData(true, "ok"))
+	})
+
+	w := serve(router)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":true,"message":"ok"}`, w.Body.String())
+}
+
+func TestErrorHandler_TranslatesMessage(t *testing.T) {
+	router := setupErrorRouter(func(c *gin.Context) {
+		c.Error(utils.ErrTooManyRequests)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "vi-VN,vi;q=0.9,en;q=0.8")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "vi", w.Header().Get("Content-Language"))
+	assert.JSONEq(t, `{"status":false,"code":"too_many_requests","message":"Quá nhiều yêu cầu, vui lòng thử lại sau"}`, w.Body.String())
+}
+
+func TestErrorHandler_KeepsDetailUntranslated(t *testing.T) {
+	errUnknown := utils.NewAppError("unknown_role", http.StatusBadRequest, "Unknown role")
+	router := setupErrorRouter(func(c *gin.Context) {
+		c.Error(errUnknown.WithDetail("auditor, nurse"))
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "vi")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{"status":false,"code":"unknown_role","message":"Vai trò không tồn tại: auditor, nurse"}`, w.Body.String())
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/i18n"
+)
+
+// Language returns the language of the response, negotiated from the
+// Accept-Language header of the request.
+func Language(c *gin.Context) i18n.Language {
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// Translate returns message in the language of the response and announces
+// that language in Content-Language.
+func Translate(c *gin.Context, message string) string {
+	lang := Language(c)
+	c.Header("Content-Language", string(lang))
+	return i18n.T(lang, message)
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/metrics"
+)
+
+func TestMetrics_LabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	before := testutil.CollectAndCount(metrics.HTTPRequestDuration)
+	for _, path := range []string{"/users/1", "/users/2", "/missing/a", "/missing/b"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, before+2, testutil.CollectAndCount(metrics.HTTPRequestDuration))
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+// RateLimitResult is the state of one sliding window after a request.
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// RateLimiter counts requests per key in a sliding window.
+type RateLimiter interface {
+	Allow(key string, rule config.RateLimitRule) (RateLimitResult, error)
+}
+
+// NewRateLimiter returns the limiter for backend, or nil when rate limiting
+// is turned off.
+func NewRateLimiter(backend string) RateLimiter {
+	switch backend {
+	case config.RateLimitBackendMemory:
+		return NewMemoryRateLimiter()
+	case config.RateLimitBackendOff:
+		return nil
+	default:
+		return NewRedisRateLimiter()
+	}
+}
+
+type redisRateLimiter struct{}
+
+// NewRedisRateLimiter shares its counters between instances through the
+// redis client in config.
+func NewRedisRateLimiter() RateLimiter {
+	return redisRateLimiter{}
+}
+
+func (redisRateLimiter) Allow(key string, rule config.RateLimitRule) (RateLimitResult, error) {
+	allowed, count, reset, err := config.RateLimitHit(key, rule.Limit, rule.Window, time.Now(), uuid.NewString())
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult{Allowed: allowed, Limit: rule.Limit, Remaining: rule.Limit - count, Reset: reset}, nil
+}
+
+// memorySweepInterval is how many requests pass between sweeps of idle keys.
+const memorySweepInterval = 1000
+
+type memoryRateLimiter struct {
+	mu    sync.Mutex
+	hits  map[string][]time.Time
+	calls int
+	now   func() time.Time
+}
+
+// NewMemoryRateLimiter keeps counters in process memory. It is meant for tests
+// and single-instance deployments.
+func NewMemoryRateLimiter() RateLimiter {
+	return &memoryRateLimiter{hits: make(map[string][]time.Time), now: time.Now}
+}
+
+func (l *memoryRateLimiter) Allow(key string, rule config.RateLimitRule) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.calls++
+	if l.calls%memorySweepInterval == 0 {
+		l.sweep(now, rule.Window)
+	}
+
+	hits := pruneHits(l.hits[key], now.Add(-rule.Window))
+	allowed := len(hits) < rule.Limit
+	if allowed {
+		hits = append(hits, now)
+	}
+	l.hits[key] = hits
+
+	reset := rule.Window
+	if len(hits) > 0 {
+		reset = hits[0].Add(rule.Window).Sub(now)
+	}
+	return RateLimitResult{Allowed: allowed, Limit: rule.Limit, Remaining: rule.Limit - len(hits), Reset: reset}, nil
+}
+
+// sweep drops keys without a hit inside window. Keys of rules with a longer
+// window may be dropped early, which only makes the limiter more lenient.
+func (l *memoryRateLimiter) sweep(now time.Time, window time.Duration) {
+	for key, hits := range l.hits {
+		if len(hits) == 0 || !hits[len(hits)-1].After(now.Add(-window)) {
+			delete(l.hits, key)
+		}
+	}
+}
+
+func pruneHits(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
+
+// RateLimits applies the configured rules of a route with one limiter.
+type RateLimits struct {
+	limiter RateLimiter
+	rules   map[string][]config.RateLimitRule
+}
+
+func NewRateLimits(limiter RateLimiter, rules map[string][]config.RateLimitRule) *RateLimits {
+	return &RateLimits{limiter: limiter, rules: rules}
+}
+
+// For returns a middleware enforcing every rule configured for route. A rule
+// whose key cannot be read from the request (no email in the body, no token)
+// is skipped. Limiter errors fail open and are attached to the gin context.
+func (l *RateLimits) For(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil || l.limiter == nil || len(l.rules[route]) == 0 {
+			c.Next()
+			return
+		}
+
+		var tightest *RateLimitResult
+		for _, rule := range l.rules[route] {
+			value := rateLimitKeyValue(c, rule.Key)
+			if value == "" {
+				continue
+			}
+
+			result, err := l.limiter.Allow(route+":"+rule.Key+":"+value, rule)
+			if err != nil {
+				c.Error(err)
+				continue
+			}
+			if !result.Allowed {
+				setRateLimitHeaders(c, result)
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.Reset)))
+				abortWithError(c, utils.ErrTooManyRequests)
+				return
+			}
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				tightest = &result
+			}
+		}
+
+		if tightest != nil {
+			setRateLimitHeaders(c, *tightest)
+		}
+		c.Next()
+	}
+}
+
+func rateLimitKeyValue(c *gin.Context, key string) string {
+	switch key {
+	case config.RateLimitKeyIP:
+		return c.ClientIP()
+	case config.RateLimitKeyEmail:
+		return emailFromBody(c)
+	case config.RateLimitKeyUser:
+		if claims, ok := GetClaims(c); ok {
+			return strconv.Itoa(claims.UserID)
+		}
+		if token, ok := utils.ExtractBearerToken(c.GetHeader("Authorization")); ok {
+			if claims, err := utils.ValidateJwtToken(token); err == nil {
+				return strconv.Itoa(claims.UserID)
+			}
+		}
+	}
+	return ""
+}
+
+// maxEmailBodySize bounds how much of a request body is read to find the
+// email key. Login and password reset bodies are far smaller.
+const maxEmailBodySize = 64 << 10
+
+// emailFromBody reads the "email" field of a JSON body and puts the body back
+// for the handler to bind. At most maxEmailBodySize bytes are read; a larger
+// body has no email key and the handler gets the read error when it binds.
+func emailFromBody(c *gin.Context) string {
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return ""
+	}
+
+	limited := http.MaxBytesReader(c.Writer, c.Request.Body, maxEmailBodySize)
+	body, err := io.ReadAll(limited)
+	if err != nil {
+		c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), limited))
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+func setRateLimitHeaders(c *gin.Context, result RateLimitResult) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(max(result.Remaining, 0)))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+func setupRateLimitRouter(limiter RateLimiter, rules ...config.RateLimitRule) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(logrus.New()))
+	limits := NewRateLimits(limiter, map[string][]config.RateLimitRule{"login": rules})
+	router.POST("/login", limits.For("login"), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	return router
+}
+
+func postJSON(router *gin.Engine, body string, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit_MemoryBlocksAfterLimit(t *testing.T) {
+	router := setupRateLimitRouter(NewMemoryRateLimiter(), config.RateLimitRule{Key: config.RateLimitKeyIP, Limit: 2, Window: time.Minute})
+
+	w := postJSON(router, `{}`, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("X-RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, postJSON(router, `{}`, "10.0.0.1:1234").Code)
+
+	w = postJSON(router, `{}`, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	// Other clients have their own window.
+	assert.Equal(t, http.StatusOK, postJSON(router, `{}`, "10.0.0.2:1234").Code)
+}
+
+func TestRateLimit_MemoryWindowSlides(t *testing.T) {
+	now := time.Now()
+	limiter := &memoryRateLimiter{hits: make(map[string][]time.Time), now: func() time.Time { return now }}
+	rule := config.RateLimitRule{Key: config.RateLimitKeyIP, Limit: 1, Window: time.Minute}
+
+	result, _ := limiter.Allow("k", rule)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(30 * time.Second)
+	result, _ = limiter.Allow("k", rule)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 30*time.Second, result.Reset)
+
+	now = now.Add(30 * time.Second)
+	result, _ = limiter.Allow("k", rule)
+	assert.True(t, result.Allowed)
+}
+
+func TestRateLimit_EmailKeyKeepsBody(t *testing.T) {
+	router := setupRateLimitRouter(NewMemoryRateLimiter(), config.RateLimitRule{Key: config.RateLimitKeyEmail, Limit: 1, Window: time.Minute})
+
+	w := postJSON(router, `{"email":"a@example.com"}`, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"email":"a@example.com"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusTooManyRequests, postJSON(router, `{"email":"A@example.com "}`, "10.0.0.2:1234").Code)
+	assert.Equal(t, http.StatusOK, postJSON(router, `{"email":"b@example.com"}`, "10.0.0.1:1234").Code)
+
+	// Requests without an email are not counted by an email rule.
+	assert.Equal(t, http.StatusOK, postJSON(router, `{}`, "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusOK, postJSON(router, `{}`, "10.0.0.1:1234").Code)
+}
+
+func TestRateLimit_EmailKeyBoundsBody(t *testing.T) {
+	router := setupRateLimitRouter(NewMemoryRateLimiter(), config.RateLimitRule{Key: config.RateLimitKeyEmail, Limit: 1, Window: time.Minute})
+	body := `{"email":"a@example.com","pad":"` + strings.Repeat("x", maxEmailBodySize) + `"}`
+
+	// An oversized body is not counted and the handler cannot read past the
+	// limit either.
+	w := postJSON(router, body, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Body.String(), maxEmailBodySize)
+	assert.Equal(t, http.StatusOK, postJSON(router, `{"email":"a@example.com"}`, "10.0.0.1:1234").Code)
+}
+
+func TestRateLimit_UserKeyFromJWT(t *testing.T) {
+	setupRedis(t)
+	router := setupRateLimitRouter(NewMemoryRateLimiter(), config.RateLimitRule{Key: config.RateLimitKeyUser, Limit: 1, Window: time.Minute})
+	accessToken, _, err := utils.GenerateJwtToken(7, "", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, http.StatusTooManyRequests, send())
+}
+
+func TestRateLimit_Redis(t *testing.T) {
+	setupRedis(t)
+	router := setupRateLimitRouter(NewRedisRateLimiter(),
+		config.RateLimitRule{Key: config.RateLimitKeyIP, Limit: 5, Window: time.Minute},
+		config.RateLimitRule{Key: config.RateLimitKeyEmail, Limit: 2, Window: time.Hour},
+	)
+
+	w := postJSON(router, `{"email":"a@example.com"}`, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, w.Code)
+	// The header reflects the rule closest to its limit.
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusOK, postJSON(router, `{"email":"a@example.com"}`, "10.0.0.1:1234").Code)
+
+	w = postJSON(router, `{"email":"a@example.com"}`, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "3600", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimit_NilLimiterPassesThrough(t *testing.T) {
+	router := setupRateLimitRouter(nil, config.RateLimitRule{Key: config.RateLimitKeyIP, Limit: 1, Window: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, postJSON(router, `{}`, "10.0.0.1:1234").Code)
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+const (
+	deviceNameHeader = "X-Device-Name"
+	requestIDHeader  = "X-Request-ID"
+)
+
+// validRequestID limits the request IDs accepted from clients so that they
+// cannot inject content into logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestMeta stores the client IP, user agent, the device name sent in the
+// X-Device-Name header and the request ID in the request context so that
+// services can use them without depending on gin. The request ID is taken
+// from X-Request-ID, or generated when it is missing or malformed, and is
+// echoed back in the response.
+func RequestMeta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Header(requestIDHeader, requestID)
+
+		ctx := utils.WithRequestMeta(c.Request.Context(), utils.RequestMeta{
+			IP:         c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			DeviceName: c.GetHeader(deviceNameHeader),
+			RequestID:  requestID,
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequestLogger stores a log entry carrying the request ID, route and client
+// IP in the request context, and logs each request once it completes. It must
+// run after RequestMeta. Authenticate adds the user ID to the entry.
+func RequestLogger(log *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		meta := utils.RequestMetaFromContext(c.Request.Context())
+		entry := log.WithFields(logrus.Fields{
+			"request_id": meta.RequestID,
+			"route":      c.FullPath(),
+			"client_ip":  meta.IP,
+		})
+		c.Request = c.Request.WithContext(utils.WithLogger(c.Request.Context(), entry))
+		c.Next()
+
+		// Only the path is logged, as query strings can carry OAuth codes.
+		utils.LoggerFromContext(c.Request.Context(), log).WithFields(logrus.Fields{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"status":     c.Writer.Status(),
+			"latency_ms": time.Since(start).Milliseconds(),
+		}).Info("Request completed")
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+func setupRequestLogRouter(out *bytes.Buffer) *gin.Engine {
+	log := logrus.New()
+	log.SetOutput(out)
+	log.SetFormatter(&logrus.JSONFormatter{})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestMeta(), RequestLogger(log))
+	router.GET("/users/:id", func(c *gin.Context) {
+		utils.LoggerFromContext(c.Request.Context(), log).Info("handled")
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func logLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, raw := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestRequestLogger_PropagatesRequestID(t *testing.T) {
+	var out bytes.Buffer
+	router := setupRequestLogRouter(&out)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.RemoteAddr = "203.0.113.5:4000"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, "req-123", w.Header().Get("X-Request-ID"))
+	lines := logLines(t, &out)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, "req-123", line["request_id"])
+		assert.Equal(t, "/users/:id", line["route"])
+		assert.Equal(t, "203.0.113.5", line["client_ip"])
+	}
+	assert.Equal(t, "handled", lines[0]["msg"])
+	assert.Equal(t, "Request completed", lines[1]["msg"])
+	assert.EqualValues(t, http.StatusNoContent, lines[1]["status"])
+}
+
+func TestRequestMeta_GeneratesMissingOrMalformedRequestID(t *testing.T) {
+	var out bytes.Buffer
+	router := setupRequestLogRouter(&out)
+
+	for _, header := range []string{"", "bad id\nwith newline"} {
+		req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+		req.Header.Set("X-Request-ID", header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		requestID := w.Header().Get("X-Request-ID")
+		assert.Len(t, requestID, 36)
+		assert.NotEqual(t, header, requestID)
+	}
+}
@@ -0,0 +1,35 @@
+package audit
+
+import "time"
+
+// ListEventsRequest filters the audit log. UserID matches events where the
+// user is either the actor or the subject. From is inclusive, To exclusive.
+type ListEventsRequest struct {
+	Page     int       `form:"page" binding:"omitempty,min=1"`
+	PageSize int       `form:"page_size" binding:"omitempty,min=1,max=200"`
+	UserID   int       `form:"user_id" binding:"omitempty,min=1"`
+	Action   string    `form:"action"`
+	Outcome  string    `form:"outcome" binding:"omitempty,oneof=success failure mfa_required"`
+	From     time.Time `form:"from"`
+	To       time.Time `form:"to"`
+}
+
+type EventResponse struct {
+	ID        int64                  `json:"id"`
+	ActorID   *int                   `json:"actor_id,omitempty"`
+	SubjectID *int                   `json:"subject_id,omitempty"`
+	Action    string                 `json:"action"`
+	Outcome   string                 `json:"outcome"`
+	IPAddress string                 `json:"ip_address"`
+	UserAgent string                 `json:"user_agent"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+type EventListResponse struct {
+	Events   []EventResponse `json:"events"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
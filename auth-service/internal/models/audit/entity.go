@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Outcomes of an audited action.
+const (
+	OutcomeSuccess     = "success"
+	OutcomeFailure     = "failure"
+	OutcomeMFARequired = "mfa_required"
+)
+
+// Audited actions.
+const (
+	ActionLogin              = "login"
+	ActionLoginUnlocked      = "login.unlocked"
+	ActionLogout             = "logout"
+	ActionLogoutAll          = "logout.all"
+	ActionTokenRefresh       = "token.refresh"
+	ActionSessionRevoked     = "session.revoked"
+	ActionPasswordForgot     = "password.forgot"
+	ActionPasswordReset      = "password.reset"
+	ActionPasswordResetForce = "password.reset_forced"
+	ActionUserCreated        = "user.created"
+	ActionUserDeactivated    = "user.deactivated"
+	ActionUserReactivated    = "user.reactivated"
+	ActionUserEmailChanged   = "user.email_changed"
+	ActionUserRolesChanged   = "user.roles_changed"
+	ActionRoleCreated        = "role.created"
+	ActionRoleUpdated        = "role.updated"
+	ActionRoleDeleted        = "role.deleted"
+	ActionRolePermissions    = "role.permissions_changed"
+	ActionPermissionCreated  = "permission.created"
+	ActionPermissionDeleted  = "permission.deleted"
+)
+
+// AuditEvents is one security-relevant event. Rows are only ever inserted;
+// the table has no foreign keys so that events outlive the users they name.
+type AuditEvents struct {
+	ID        int64             `gorm:"column:id;primaryKey"`
+	ActorID   *int              `gorm:"column:actor_id;index"`
+	SubjectID *int              `gorm:"column:subject_id;index"`
+	Action    string            `gorm:"column:action;type:varchar(64);not null;index"`
+	Outcome   string            `gorm:"column:outcome;type:varchar(32);not null"`
+	IPAddress string            `gorm:"column:ip_address"`
+	UserAgent string            `gorm:"column:user_agent"`
+	RequestID string            `gorm:"column:request_id"`
+	Metadata  datatypes.JSONMap `gorm:"column:metadata;type:jsonb"`
+	CreatedAt time.Time         `gorm:"column:created_at;not null;index"`
+}
+
+func (AuditEvents) TableName() string {
+	return "audit_events"
+}
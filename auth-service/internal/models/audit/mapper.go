@@ -0,0 +1,16 @@
+package audit
+
+func EntityToEventResponse(entity *AuditEvents) EventResponse {
+	return EventResponse{
+		ID:        entity.ID,
+		ActorID:   entity.ActorID,
+		SubjectID: entity.SubjectID,
+		Action:    entity.Action,
+		Outcome:   entity.Outcome,
+		IPAddress: entity.IPAddress,
+		UserAgent: entity.UserAgent,
+		RequestID: entity.RequestID,
+		Metadata:  entity.Metadata,
+		CreatedAt: entity.CreatedAt,
+	}
+}
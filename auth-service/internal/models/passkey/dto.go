@@ -0,0 +1,46 @@
+package passkey
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BeginResponse carries the options to pass to navigator.credentials.create()
+// or navigator.credentials.get() and the session to send back when finishing.
+type BeginResponse struct {
+	SessionID string      `json:"session_id"`
+	Options   interface{} `json:"options" swaggertype:"object"`
+}
+
+type BeginRegistrationRequest struct {
+	Name string `json:"name" binding:"max=64"`
+}
+
+type FinishRegistrationRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type FinishLoginRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type BeginSecondFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+}
+
+type FinishSecondFactorRequest struct {
+	ChallengeToken string          `json:"challenge_token" binding:"required"`
+	SessionID      string          `json:"session_id" binding:"required"`
+	Credential     json.RawMessage `json:"credential" binding:"required" swaggertype:"object"`
+}
+
+type PasskeyResponse struct {
+	ID                int        `json:"id"`
+	Name              string     `json:"name"`
+	AttestationFormat string     `json:"attestation_format"`
+	BackupEligible    bool       `json:"backup_eligible"`
+	CreatedAt         *time.Time `json:"created_at"`
+	LastUsedAt        *time.Time `json:"last_used_at"`
+}
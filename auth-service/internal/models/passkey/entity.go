@@ -0,0 +1,30 @@
+package passkey
+
+import (
+	"time"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+)
+
+type Passkeys struct {
+	ID                int        `gorm:"column:id;primaryKey"`
+	UserID            int        `gorm:"column:user_id;not null;index"`
+	Name              string     `gorm:"column:name"`
+	CredentialID      []byte     `gorm:"column:credential_id;not null;uniqueIndex"`
+	PublicKey         []byte     `gorm:"column:public_key;not null"`
+	AttestationFormat string     `gorm:"column:attestation_format"`
+	Transports        string     `gorm:"column:transports"`
+	AAGUID            []byte     `gorm:"column:aaguid"`
+	SignCount         uint32     `gorm:"column:sign_count;not null;default:0"`
+	CloneWarning      bool       `gorm:"column:clone_warning;not null;default:false"`
+	UserVerified      bool       `gorm:"column:user_verified;not null;default:false"`
+	BackupEligible    bool       `gorm:"column:backup_eligible;not null;default:false"`
+	BackupState       bool       `gorm:"column:backup_state;not null;default:false"`
+	CreatedAt         *time.Time `gorm:"column:created_at"`
+	LastUsedAt        *time.Time `gorm:"column:last_used_at"`
+	User              user.Users `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (Passkeys) TableName() string {
+	return "webauthn_credentials"
+}
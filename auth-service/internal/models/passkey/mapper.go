@@ -0,0 +1,69 @@
+package passkey
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+func CredentialToEntity(userID int, name string, credential *webauthn.Credential) *Passkeys {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	now := time.Now()
+	return &Passkeys{
+		UserID:            userID,
+		Name:              name,
+		CredentialID:      credential.ID,
+		PublicKey:         credential.PublicKey,
+		AttestationFormat: credential.AttestationType,
+		Transports:        strings.Join(transports, ","),
+		AAGUID:            credential.Authenticator.AAGUID,
+		SignCount:         credential.Authenticator.SignCount,
+		UserVerified:      credential.Flags.UserVerified,
+		BackupEligible:    credential.Flags.BackupEligible,
+		BackupState:       credential.Flags.BackupState,
+		CreatedAt:         &now,
+	}
+}
+
+func EntityToCredential(entity Passkeys) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	for _, transport := range strings.Split(entity.Transports, ",") {
+		if transport != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              entity.CredentialID,
+		PublicKey:       entity.PublicKey,
+		AttestationType: entity.AttestationFormat,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserVerified:   entity.UserVerified,
+			BackupEligible: entity.BackupEligible,
+			BackupState:    entity.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       entity.AAGUID,
+			SignCount:    entity.SignCount,
+			CloneWarning: entity.CloneWarning,
+		},
+	}
+}
+
+func EntityToResponse(entity Passkeys) PasskeyResponse {
+	return PasskeyResponse{
+		ID:                entity.ID,
+		Name:              entity.Name,
+		AttestationFormat: entity.AttestationFormat,
+		BackupEligible:    entity.BackupEligible,
+		CreatedAt:         entity.CreatedAt,
+		LastUsedAt:        entity.LastUsedAt,
+	}
+}
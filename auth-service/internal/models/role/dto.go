@@ -0,0 +1,43 @@
+package role
+
+import "time"
+
+type CreateRoleRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Description string `json:"description"`
+	// Parent is the name of the role to inherit permissions from.
+	Parent      string   `json:"parent"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest only changes the fields that are set. An empty Parent
+// removes the parent.
+type UpdateRoleRequest struct {
+	Description *string `json:"description"`
+	Parent      *string `json:"parent"`
+}
+
+type SetPermissionsRequest struct {
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type RoleResponse struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Parent      string    `json:"parent,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreatePermissionRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+type PermissionResponse struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package role
+
+import "time"
+
+// Roles is a named set of permissions. A role inherits every permission of
+// its parent, and of the parent's ancestors. Internal roles grant their
+// permissions but are not listed in the role claim of tokens.
+type Roles struct {
+	ID          int           `gorm:"column:id;primaryKey"`
+	Name        string        `gorm:"column:name;type:varchar(50);not null;uniqueIndex"`
+	Description string        `gorm:"column:description;not null;default:''"`
+	Internal    bool          `gorm:"column:internal;not null;default:false"`
+	ParentID    *int          `gorm:"column:parent_id;index"`
+	Parent      *Roles        `gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL"`
+	Permissions []Permissions `gorm:"many2many:role_permissions;joinForeignKey:RoleID;joinReferences:PermissionID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time     `gorm:"column:created_at"`
+}
+
+func (Roles) TableName() string {
+	return "roles"
+}
+
+type Permissions struct {
+	ID          int       `gorm:"column:id;primaryKey"`
+	Name        string    `gorm:"column:name;type:varchar(100);not null;uniqueIndex"`
+	Description string    `gorm:"column:description;not null;default:''"`
+	CreatedAt   time.Time `gorm:"column:created_at"`
+}
+
+func (Permissions) TableName() string {
+	return "permissions"
+}
+
+// RolePermissions grants a permission to a role.
+type RolePermissions struct {
+	RoleID       int `gorm:"column:role_id;primaryKey"`
+	PermissionID int `gorm:"column:permission_id;primaryKey;index"`
+}
+
+func (RolePermissions) TableName() string {
+	return "role_permissions"
+}
+
+// UserRoles assigns a role to a user.
+type UserRoles struct {
+	UserID int `gorm:"column:user_id;primaryKey"`
+	RoleID int `gorm:"column:role_id;primaryKey;index"`
+}
+
+func (UserRoles) TableName() string {
+	return "user_roles"
+}
+
+// Access is what a user is granted through their roles, including inherited
+// ones. It is what goes into the access token.
+type Access struct {
+	Roles       []string
+	Permissions []string
+}
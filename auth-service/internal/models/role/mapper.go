@@ -0,0 +1,27 @@
+package role
+
+func EntityToRoleResponse(entity *Roles) RoleResponse {
+	resp := RoleResponse{
+		ID:          entity.ID,
+		Name:        entity.Name,
+		Description: entity.Description,
+		Permissions: make([]string, 0, len(entity.Permissions)),
+		CreatedAt:   entity.CreatedAt,
+	}
+	if entity.Parent != nil {
+		resp.Parent = entity.Parent.Name
+	}
+	for _, permission := range entity.Permissions {
+		resp.Permissions = append(resp.Permissions, permission.Name)
+	}
+	return resp
+}
+
+func EntityToPermissionResponse(entity *Permissions) PermissionResponse {
+	return PermissionResponse{
+		ID:          entity.ID,
+		Name:        entity.Name,
+		Description: entity.Description,
+		CreatedAt:   entity.CreatedAt,
+	}
+}
@@ -0,0 +1,26 @@
+package session
+
+import (
+	"time"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+)
+
+// Sessions is one signed-in device. Each session owns a single refresh token,
+// stored hashed, which is rotated on every refresh.
+type Sessions struct {
+	ID           string     `gorm:"column:id;primaryKey;type:varchar(36)"`
+	UserID       int        `gorm:"column:user_id;not null;index"`
+	DeviceName   string     `gorm:"column:device_name"`
+	UserAgent    string     `gorm:"column:user_agent"`
+	IPAddress    string     `gorm:"column:ip_address"`
+	RefreshToken string     `gorm:"column:refresh_token;not null"`
+	CreatedAt    time.Time  `gorm:"column:created_at"`
+	LastUsedAt   time.Time  `gorm:"column:last_used_at"`
+	ExpiresAt    time.Time  `gorm:"column:expires_at;index"`
+	User         user.Users `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (Sessions) TableName() string {
+	return "sessions"
+}
@@ -0,0 +1,14 @@
+package session
+
+func EntityToResponse(entity Sessions, currentID string) SessionResponse {
+	return SessionResponse{
+		ID:         entity.ID,
+		DeviceName: entity.DeviceName,
+		UserAgent:  entity.UserAgent,
+		IPAddress:  entity.IPAddress,
+		CreatedAt:  entity.CreatedAt,
+		LastUsedAt: entity.LastUsedAt,
+		ExpiresAt:  entity.ExpiresAt,
+		Current:    entity.ID == currentID,
+	}
+}
@@ -0,0 +1,21 @@
+package token
+
+type IntrospectionRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+// IntrospectionResponse follows RFC 7662. Only Active (and Revoked) are set
+// for tokens that are not active.
+type IntrospectionResponse struct {
+	Active     bool     `json:"active"`
+	Revoked    bool     `json:"revoked,omitempty"`
+	Sub        string   `json:"sub,omitempty"`
+	Role       []string `json:"role,omitempty"`
+	Permission []string `json:"permission,omitempty"`
+	Exp        int64    `json:"exp,omitempty"`
+	Iat        int64    `json:"iat,omitempty"`
+	Jti        string   `json:"jti,omitempty"`
+	Sid        string   `json:"sid,omitempty"`
+	TokenType  string   `json:"token_type,omitempty"`
+}
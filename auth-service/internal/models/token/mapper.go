@@ -0,0 +1,17 @@
+package token
+
+import "github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+
+func ClaimsToIntrospectionResponse(claims *utils.JWTClaim) *IntrospectionResponse {
+	return &IntrospectionResponse{
+		Active:     true,
+		Sub:        claims.Subject,
+		Role:       claims.Role,
+		Permission: claims.Permission,
+		Exp:        claims.ExpiresAt.Unix(),
+		Iat:        claims.IssuedAt.Unix(),
+		Jti:        claims.ID,
+		Sid:        claims.SessionID,
+		TokenType:  claims.Type,
+	}
+}
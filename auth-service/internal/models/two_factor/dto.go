@@ -0,0 +1,25 @@
+package twofactor
+
+type EnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type ConfirmRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// DisableRequest accepts either a current TOTP code or an unused recovery code.
+type DisableRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type VerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code" binding:"required_without=Code"`
+}
@@ -0,0 +1,20 @@
+package twofactor
+
+import (
+	"time"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+)
+
+type RecoveryCodes struct {
+	ID        int        `gorm:"column:id;primaryKey"`
+	UserID    int        `gorm:"column:user_id;not null;index"`
+	CodeHash  string     `gorm:"column:code_hash;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt *time.Time `gorm:"column:created_at"`
+	User      user.Users `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (RecoveryCodes) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,9 @@
+package twofactor
+
+func HashesToEntities(userID int, codeHashes []string) []RecoveryCodes {
+	codes := make([]RecoveryCodes, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, RecoveryCodes{UserID: userID, CodeHash: hash})
+	}
+	return codes
+}
@@ -1,10 +1,19 @@
 package user
 
+import "time"
+
 type AuthRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+// RegisterRequest is AuthRequest for a new account, whose password must be
+// strong. Existing accounts may still log in with older, weaker passwords.
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,password"`
+}
+
 type LoginResponse struct {
 	UserID     int                    `json:"user_id"`
 	Email      string                 `json:"email"`
@@ -12,4 +21,92 @@ type LoginResponse struct {
 	Permission []string               `json:"permission"`
 	AccessToken  string           	  `json:"access_token"`
 	RefreshToken string 			  `json:"refresh_token"`
-}
\ No newline at end of file
+	// MFARequired is set instead of the token pair when a second factor must
+	// be verified with ChallengeToken.
+	MFARequired    bool     `json:"mfa_required,omitempty"`
+	ChallengeToken string   `json:"challenge_token,omitempty"`
+	MFAMethods     []string `json:"mfa_methods,omitempty"`
+}
+
+type VerifyEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	OTP   string `json:"otp" binding:"required,len=6,numeric"`
+}
+
+type RegisterResponse struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	OTP         string `json:"otp" binding:"required,len=6,numeric"`
+	NewPassword string `json:"new_password" binding:"required,password"`
+}
+
+type UnlockAccountRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	IP    string `json:"ip" binding:"omitempty,ip"`
+}
+
+// Account statuses accepted by the admin user list filter.
+const (
+	StatusActive     = "active"
+	StatusDisabled   = "disabled"
+	StatusUnverified = "unverified"
+)
+
+type ListUsersRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Email    string `form:"email"`
+	Role     string `form:"role"`
+	Status   string `form:"status" binding:"omitempty,oneof=active disabled unverified"`
+}
+
+type UserResponse struct {
+	UserID                int        `json:"user_id"`
+	Email                 string     `json:"email"`
+	IsActive              bool       `json:"is_active"`
+	Disabled              bool       `json:"disabled"`
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`
+	PasswordResetRequired bool       `json:"password_reset_required"`
+	TOTPEnabled           bool       `json:"totp_enabled"`
+	WebAuthnEnabled       bool       `json:"webauthn_enabled"`
+	// Role lists the roles assigned directly to the user, without the ones
+	// they inherit.
+	Role      []string   `json:"role"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+type UserListResponse struct {
+	Users    []UserResponse `json:"users"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+type CreateUserRequest struct {
+	Email    string   `json:"email" binding:"required,email"`
+	Password string   `json:"password" binding:"required,password"`
+	Role     []string `json:"role"`
+	// Verified creates the account as already verified instead of emailing a
+	// verification OTP.
+	Verified bool `json:"verified"`
+}
+
+type UpdateUserRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type SetRolesRequest struct {
+	Role []string `json:"role" binding:"required,min=1"`
+}
@@ -3,7 +3,7 @@ package user
 import (
 	"time"
 
-	"gorm.io/datatypes"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
 )
 
 type Users struct {
@@ -12,9 +12,18 @@ type Users struct {
 	Password   string                 `gorm:"column:password_hash"`
 	IsActive   bool                   `gorm:"column:is_active"`
 	CreatedAt  *time.Time             `gorm:"column:create_at"`
-	Role       datatypes.JSON        `gorm:"column:roles;type:jsonb"`
-	Permission datatypes.JSON        `gorm:"column:permissions;type:jsonb"`
-	RefreshToken string               `gorm:"column:refresh_token"`
+	TOTPSecret   string               `gorm:"column:totp_secret"`
+	TOTPEnabled  bool                 `gorm:"column:totp_enabled;not null;default:false"`
+	// DisabledAt is set while an admin has deactivated the account. It is
+	// separate from IsActive, which tracks email verification.
+	DisabledAt            *time.Time `gorm:"column:disabled_at"`
+	PasswordResetRequired bool       `gorm:"column:password_reset_required;not null;default:false"`
+	// WebAuthnEnabled is kept in sync with webauthn_credentials by the passkey
+	// repository so that login can tell whether a second factor is required.
+	WebAuthnEnabled bool              `gorm:"column:webauthn_enabled;not null;default:false"`
+	// Roles are the roles assigned directly to the user. Permissions and
+	// inherited roles are resolved by the role repository.
+	Roles []role.Roles `gorm:"many2many:user_roles;joinForeignKey:UserID;joinReferences:RoleID;constraint:OnDelete:CASCADE"`
 }
 
 func(Users) TableName() string{
@@ -1,10 +1,35 @@
 package user
 
-func UsersToEntity(userDTO AuthRequest) *Users{
+func UsersToEntity(userDTO RegisterRequest) *Users{
 	return &Users{
 		Email:   userDTO.Email,
 		Password: userDTO.Password,
 	}
 }
 
+func UsersToRegisterResponse(userEntity *Users) *RegisterResponse {
+	return &RegisterResponse{
+		UserID: userEntity.UserID,
+		Email:  userEntity.Email,
+	}
+}
+
+func EntityToUserResponse(userEntity *Users) UserResponse {
+	roles := make([]string, 0, len(userEntity.Roles))
+	for _, roleEntity := range userEntity.Roles {
+		roles = append(roles, roleEntity.Name)
+	}
 
+	return UserResponse{
+		UserID:                userEntity.UserID,
+		Email:                 userEntity.Email,
+		IsActive:              userEntity.IsActive,
+		Disabled:              userEntity.DisabledAt != nil,
+		DisabledAt:            userEntity.DisabledAt,
+		PasswordResetRequired: userEntity.PasswordResetRequired,
+		TOTPEnabled:           userEntity.TOTPEnabled,
+		WebAuthnEnabled:       userEntity.WebAuthnEnabled,
+		Role:                  roles,
+		CreatedAt:             userEntity.CreatedAt,
+	}
+}
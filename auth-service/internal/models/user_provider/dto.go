@@ -1 +1,14 @@
-package userprovider
\ No newline at end of file
+package userprovider
+
+type OAuthCallbackRequest struct {
+	Code  string `form:"code"`
+	State string `form:"state" binding:"required"`
+	Error string `form:"error"`
+}
+
+// ProviderIdentity is the identity asserted by an OAuth provider.
+type ProviderIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
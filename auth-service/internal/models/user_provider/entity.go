@@ -1 +1,21 @@
 package userprovider
+
+import (
+	"time"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+)
+
+type UserProviders struct {
+	ID       int        `gorm:"column:id;primaryKey"`
+	UserID   int        `gorm:"column:user_id;not null;index"`
+	Provider string     `gorm:"column:provider;not null;uniqueIndex:idx_user_providers_provider_subject"`
+	Subject  string     `gorm:"column:subject;not null;uniqueIndex:idx_user_providers_provider_subject"`
+	Email    string     `gorm:"column:email"`
+	LinkedAt *time.Time `gorm:"column:linked_at"`
+	User     user.Users `gorm:"foreignKey:UserID;references:UserID;constraint:OnDelete:CASCADE"`
+}
+
+func (UserProviders) TableName() string {
+	return "user_providers"
+}
@@ -1,2 +1,14 @@
 package userprovider
 
+import "time"
+
+func IdentityToEntity(userID int, provider string, identity *ProviderIdentity) *UserProviders {
+	now := time.Now()
+	return &UserProviders{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: &now,
+	}
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"gorm.io/gorm"
+)
+
+// AuditRepository is append-only: events can be written and queried but
+// never changed or removed.
+type AuditRepository interface {
+	Create(ctx context.Context, event *audit.AuditEvents) error
+	List(ctx context.Context, query audit.ListEventsRequest) ([]audit.AuditEvents, int64, error)
+}
+
+type AuditRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &AuditRepoImpl{
+		db: db,
+	}
+}
+
+func (r *AuditRepoImpl) Create(ctx context.Context, event *audit.AuditEvents) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// List returns a page of matching events, newest first, and the total number
+// of matches.
+func (r *AuditRepoImpl) List(ctx context.Context, query audit.ListEventsRequest) ([]audit.AuditEvents, int64, error) {
+	db := r.db.WithContext(ctx).Model(&audit.AuditEvents{})
+	if query.UserID != 0 {
+		db = db.Where("actor_id = ? OR subject_id = ?", query.UserID, query.UserID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.Outcome != "" {
+		db = db.Where("outcome = ?", query.Outcome)
+	}
+	if !query.From.IsZero() {
+		db = db.Where("created_at >= ?", query.From)
+	}
+	if !query.To.IsZero() {
+		db = db.Where("created_at < ?", query.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []audit.AuditEvents
+	if err := db.Order("created_at DESC, id DESC").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
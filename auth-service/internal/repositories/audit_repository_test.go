@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+)
+
+func TestCreateAuditEvent(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAuditRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "audit_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	subjectID := 7
+	event := &audit.AuditEvents{
+		SubjectID: &subjectID,
+		Action:    audit.ActionLogin,
+		Outcome:   audit.OutcomeSuccess,
+		CreatedAt: time.Now(),
+	}
+	err := repo.Create(context.Background(), event)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), event.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAuditEvents_Filters(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAuditRepository(db)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "audit_events" WHERE \(actor_id = .* OR subject_id = .*\) AND action = .* AND created_at >= .* AND created_at < .*`).
+		WithArgs(7, 7, audit.ActionLogin, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT \* FROM "audit_events" WHERE .* ORDER BY created_at DESC, id DESC LIMIT .*`).
+		WithArgs(7, 7, audit.ActionLogin, from, to, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action", "outcome"}).
+			AddRow(3, audit.ActionLogin, audit.OutcomeFailure).
+			AddRow(2, audit.ActionLogin, audit.OutcomeSuccess))
+
+	events, total, err := repo.List(context.Background(), audit.ListEventsRequest{
+		Page: 1, PageSize: 2, UserID: 7, Action: audit.ActionLogin, From: from, To: to,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, events, 2)
+	assert.Equal(t, audit.OutcomeFailure, events[0].Outcome)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/passkey"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"gorm.io/gorm"
+)
+
+type PasskeyRepository interface {
+	Create(ctx context.Context, credential *passkey.Passkeys) error
+	FindByUser(ctx context.Context, userID int) ([]passkey.Passkeys, error)
+	UpdateAfterLogin(ctx context.Context, credential *passkey.Passkeys) error
+	Delete(ctx context.Context, userID int, id int) (bool, error)
+}
+
+type PasskeyRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewPasskeyRepository(db *gorm.DB) PasskeyRepository {
+	return &PasskeyRepoImpl{
+		db: db,
+	}
+}
+
+// Create stores the credential and marks the user as having a passkey.
+func (r *PasskeyRepoImpl) Create(ctx context.Context, credential *passkey.Passkeys) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Create(credential).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user.Users{}).Where("id = ?", credential.UserID).Update("webauthn_enabled", true).Error
+	})
+}
+
+func (r *PasskeyRepoImpl) FindByUser(ctx context.Context, userID int) ([]passkey.Passkeys, error) {
+	var credentials []passkey.Passkeys
+
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateAfterLogin saves the sign counter, clone warning and backup state
+// reported by the last assertion.
+func (r *PasskeyRepoImpl) UpdateAfterLogin(ctx context.Context, credential *passkey.Passkeys) error {
+	return r.db.WithContext(ctx).Model(&passkey.Passkeys{}).
+		Where("id = ?", credential.ID).
+		Updates(map[string]interface{}{
+			"sign_count":    credential.SignCount,
+			"clone_warning": credential.CloneWarning,
+			"backup_state":  credential.BackupState,
+			"last_used_at":  time.Now(),
+		}).Error
+}
+
+// Delete removes a credential of the user and clears webauthn_enabled when it
+// was the last one. It reports false when the user has no such credential.
+func (r *PasskeyRepoImpl) Delete(ctx context.Context, userID int, id int) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&passkey.Passkeys{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected == 1
+
+		var remaining int64
+		if err := tx.Model(&passkey.Passkeys{}).Where("user_id = ?", userID).Count(&remaining).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user.Users{}).Where("id = ?", userID).Update("webauthn_enabled", remaining > 0).Error
+	})
+	return deleted, err
+}
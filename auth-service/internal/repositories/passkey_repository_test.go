@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletePasskey_LastCredential(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPasskeyRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "webauthn_credentials" WHERE id = .* AND user_id = .*`).
+		WithArgs(3, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "webauthn_credentials" WHERE user_id = .*`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`UPDATE "users" SET "webauthn_enabled"=.* WHERE id = .*`).
+		WithArgs(false, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Delete(context.Background(), 1, 3)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository interface {
+	EffectiveAccess(ctx context.Context, userID int) (*role.Access, error)
+	FindUserRoles(ctx context.Context, userID int) ([]role.Roles, error)
+	SetUserRoles(ctx context.Context, userID int, roleIDs []int) error
+	ListRoles(ctx context.Context) ([]role.Roles, error)
+	FindRoleByID(ctx context.Context, id int) (*role.Roles, error)
+	FindRolesByName(ctx context.Context, names []string) ([]role.Roles, error)
+	CreateRole(ctx context.Context, roleEntity *role.Roles) error
+	UpdateRole(ctx context.Context, id int, description string, parentID *int) error
+	DeleteRole(ctx context.Context, id int) (bool, error)
+	SetRolePermissions(ctx context.Context, roleID int, permissionIDs []int) error
+	ListPermissions(ctx context.Context) ([]role.Permissions, error)
+	FindPermissionsByName(ctx context.Context, names []string) ([]role.Permissions, error)
+	CreatePermission(ctx context.Context, permission *role.Permissions) error
+	DeletePermission(ctx context.Context, id int) (bool, error)
+}
+
+type RoleRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &RoleRepoImpl{
+		db: db,
+	}
+}
+
+// effectiveAccessQuery walks from the roles assigned to the user up through
+// their parents, then lists those roles, internal ones aside, and every
+// permission granted to any of them. UNION drops rows already seen, so a
+// cycle in the hierarchy cannot make the recursion run forever.
+const effectiveAccessQuery = `
+WITH RECURSIVE granted AS (
+	SELECT r.id, r.name, r.internal, r.parent_id FROM roles r
+	JOIN user_roles ur ON ur.role_id = r.id
+	WHERE ur.user_id = ?
+	UNION
+	SELECT p.id, p.name, p.internal, p.parent_id FROM roles p
+	JOIN granted g ON p.id = g.parent_id
+)
+SELECT 'role' AS kind, name FROM granted WHERE NOT internal
+UNION
+SELECT 'permission' AS kind, p.name FROM permissions p
+JOIN role_permissions rp ON rp.permission_id = p.id
+WHERE rp.role_id IN (SELECT id FROM granted)
+ORDER BY kind, name`
+
+// EffectiveAccess returns the roles of the user, including inherited ones,
+// and the permissions granted through them.
+func (r *RoleRepoImpl) EffectiveAccess(ctx context.Context, userID int) (*role.Access, error) {
+	var rows []struct {
+		Kind string
+		Name string
+	}
+	if err := r.db.WithContext(ctx).Raw(effectiveAccessQuery, userID).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	access := &role.Access{Roles: []string{}, Permissions: []string{}}
+	for _, row := range rows {
+		if row.Kind == "role" {
+			access.Roles = append(access.Roles, row.Name)
+		} else {
+			access.Permissions = append(access.Permissions, row.Name)
+		}
+	}
+	return access, nil
+}
+
+func (r *RoleRepoImpl) FindUserRoles(ctx context.Context, userID int) ([]role.Roles, error) {
+	var roles []role.Roles
+
+	if err := r.db.WithContext(ctx).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// SetUserRoles replaces the roles assigned to the user. Internal roles, such
+// as the legacy-user-<id> roles holding migrated per-user permissions, are
+// not managed through the role list and stay assigned; naming one of them
+// again is not an error.
+func (r *RoleRepoImpl) SetUserRoles(ctx context.Context, userID int, roleIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND role_id IN (?)", userID,
+			tx.Model(&role.Roles{}).Select("id").Where("internal = ?", false)).
+			Delete(&role.UserRoles{}).Error; err != nil {
+			return err
+		}
+		if len(roleIDs) == 0 {
+			return nil
+		}
+
+		assignments := make([]role.UserRoles, 0, len(roleIDs))
+		for _, roleID := range roleIDs {
+			assignments = append(assignments, role.UserRoles{UserID: userID, RoleID: roleID})
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&assignments).Error
+	})
+}
+
+func (r *RoleRepoImpl) ListRoles(ctx context.Context) ([]role.Roles, error) {
+	var roles []role.Roles
+
+	if err := r.db.WithContext(ctx).
+		Preload("Parent").
+		Preload("Permissions").
+		Order("name").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepoImpl) FindRoleByID(ctx context.Context, id int) (*role.Roles, error) {
+	var roleEntity role.Roles
+
+	if err := r.db.WithContext(ctx).
+		Preload("Parent").
+		Preload("Permissions").
+		Where("id = ?", id).
+		First(&roleEntity).Error; err != nil {
+		return nil, err
+	}
+
+	return &roleEntity, nil
+}
+
+func (r *RoleRepoImpl) FindRolesByName(ctx context.Context, names []string) ([]role.Roles, error) {
+	var roles []role.Roles
+
+	if err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// CreateRole stores the role and grants it its Permissions, which must
+// already exist.
+func (r *RoleRepoImpl) CreateRole(ctx context.Context, roleEntity *role.Roles) error {
+	return r.db.WithContext(ctx).Omit("Parent", "Permissions.*").Create(roleEntity).Error
+}
+
+func (r *RoleRepoImpl) UpdateRole(ctx context.Context, id int, description string, parentID *int) error {
+	return r.db.WithContext(ctx).Model(&role.Roles{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"description": description,
+			"parent_id":   parentID,
+		}).Error
+}
+
+// DeleteRole removes the role. Its grants and assignments are removed by the
+// foreign keys, and roles inheriting from it lose their parent.
+func (r *RoleRepoImpl) DeleteRole(ctx context.Context, id int) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&role.Roles{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// SetRolePermissions replaces the permissions granted directly to the role.
+func (r *RoleRepoImpl) SetRolePermissions(ctx context.Context, roleID int, permissionIDs []int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", roleID).Delete(&role.RolePermissions{}).Error; err != nil {
+			return err
+		}
+		if len(permissionIDs) == 0 {
+			return nil
+		}
+
+		grants := make([]role.RolePermissions, 0, len(permissionIDs))
+		for _, permissionID := range permissionIDs {
+			grants = append(grants, role.RolePermissions{RoleID: roleID, PermissionID: permissionID})
+		}
+		return tx.Create(&grants).Error
+	})
+}
+
+func (r *RoleRepoImpl) ListPermissions(ctx context.Context) ([]role.Permissions, error) {
+	var permissions []role.Permissions
+
+	if err := r.db.WithContext(ctx).Order("name").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleRepoImpl) FindPermissionsByName(ctx context.Context, names []string) ([]role.Permissions, error) {
+	var permissions []role.Permissions
+
+	if err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleRepoImpl) CreatePermission(ctx context.Context, permission *role.Permissions) error {
+	return r.db.WithContext(ctx).Create(permission).Error
+}
+
+// DeletePermission removes the permission and every grant of it.
+func (r *RoleRepoImpl) DeletePermission(ctx context.Context, id int) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&role.Permissions{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
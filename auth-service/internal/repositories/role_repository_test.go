@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveAccess(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewRoleRepository(db)
+
+	mock.ExpectQuery(`WITH RECURSIVE granted AS .* SELECT 'role' AS kind, name FROM granted WHERE NOT internal UNION .*`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"kind", "name"}).
+			AddRow("permission", "user:read").
+			AddRow("permission", "user:write").
+			AddRow("role", "admin").
+			AddRow("role", "user"))
+
+	access, err := repo.EffectiveAccess(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"admin", "user"}, access.Roles)
+	assert.Equal(t, []string{"user:read", "user:write"}, access.Permissions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetUserRoles_ReplacesAssignments(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewRoleRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "user_roles" WHERE user_id = \$1 AND role_id IN \(SELECT "id" FROM "roles" WHERE internal = \$2\)`).
+		WithArgs(7, false).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "user_roles" \("user_id","role_id"\) VALUES .* ON CONFLICT DO NOTHING`).
+		WithArgs(7, 1, 7, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.SetUserRoles(context.Background(), 7, []int{1, 3}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetUserRoles_KeepsInternalRoles(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewRoleRepository(db)
+
+	// The legacy-user-<id> role is internal, so clearing the role list leaves
+	// the migrated permissions of the user in place.
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "user_roles" WHERE user_id = \$1 AND role_id IN \(SELECT "id" FROM "roles" WHERE internal = \$2\)`).
+		WithArgs(7, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.SetUserRoles(context.Background(), 7, nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
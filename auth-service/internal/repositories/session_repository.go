@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, sessionEntity *session.Sessions) error
+	FindByID(ctx context.Context, id string) (*session.Sessions, error)
+	FindActiveByUser(ctx context.Context, userID int) ([]session.Sessions, error)
+	Rotate(ctx context.Context, id string, oldToken string, rotated *session.Sessions) (bool, error)
+	Delete(ctx context.Context, userID int, id string) (bool, error)
+	DeleteByUser(ctx context.Context, userID int) ([]string, error)
+}
+
+type SessionRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &SessionRepoImpl{
+		db: db,
+	}
+}
+
+// Create stores a new session and prunes the expired sessions of the user.
+func (r *SessionRepoImpl) Create(ctx context.Context, sessionEntity *session.Sessions) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND expires_at <= ?", sessionEntity.UserID, time.Now()).
+			Delete(&session.Sessions{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit("User").Create(sessionEntity).Error
+	})
+}
+
+func (r *SessionRepoImpl) FindByID(ctx context.Context, id string) (*session.Sessions, error) {
+	var sessionEntity session.Sessions
+
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&sessionEntity).Error; err != nil {
+		return nil, err
+	}
+
+	return &sessionEntity, nil
+}
+
+func (r *SessionRepoImpl) FindActiveByUser(ctx context.Context, userID int) ([]session.Sessions, error) {
+	var sessions []session.Sessions
+
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Rotate replaces the refresh token of the session only if it still equals
+// oldToken, and records when and from where it was used. It reports false
+// when another request already rotated it.
+func (r *SessionRepoImpl) Rotate(ctx context.Context, id string, oldToken string, rotated *session.Sessions) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&session.Sessions{}).
+		Where("id = ? AND refresh_token = ?", id, oldToken).
+		Updates(map[string]interface{}{
+			"refresh_token": rotated.RefreshToken,
+			"ip_address":    rotated.IPAddress,
+			"user_agent":    rotated.UserAgent,
+			"last_used_at":  rotated.LastUsedAt,
+			"expires_at":    rotated.ExpiresAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Delete removes a session of the user. It reports false when the user has no
+// such session.
+func (r *SessionRepoImpl) Delete(ctx context.Context, userID int, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&session.Sessions{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteByUser removes every session of the user and returns the ids of the
+// removed sessions.
+func (r *SessionRepoImpl) DeleteByUser(ctx context.Context, userID int) ([]string, error) {
+	var deleted []session.Sessions
+	if err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("user_id = ?", userID).
+		Delete(&deleted).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(deleted))
+	for _, s := range deleted {
+		ids = append(ids, s.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
+)
+
+func TestRotateSession_AlreadyRotated(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewSessionRepository(db)
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "sessions" SET .* WHERE id = .* AND refresh_token = .*`).
+		WithArgs(now.Add(time.Hour), "10.0.0.1", now, "new-hash", "curl/8.0", "sid-1", "old-hash").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	rotated, err := repo.Rotate(context.Background(), "sid-1", "old-hash", &session.Sessions{
+		RefreshToken: "new-hash",
+		IPAddress:    "10.0.0.1",
+		UserAgent:    "curl/8.0",
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.False(t, rotated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteSession_OtherUser(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewSessionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "sessions" WHERE id = .* AND user_id = .*`).
+		WithArgs("sid-1", 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Delete(context.Background(), 2, "sid-1")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func TestDeleteSessionsByUser_ReturnsIDs(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewSessionRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM "sessions" WHERE user_id = .* RETURNING "id"`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("sid-1").AddRow("sid-2"))
+	mock.ExpectCommit()
+
+	ids, err := repo.DeleteByUser(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sid-1", "sid-2"}, ids)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
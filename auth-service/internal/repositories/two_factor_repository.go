@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	twofactor "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/two_factor"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"gorm.io/gorm"
+)
+
+type TwoFactorRepository interface {
+	SaveTOTPSecret(ctx context.Context, userID int, encryptedSecret string) error
+	EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID int) error
+	ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+}
+
+type TwoFactorRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &TwoFactorRepoImpl{
+		db: db,
+	}
+}
+
+// SaveTOTPSecret stores a pending secret. 2FA stays disabled until EnableTOTP.
+func (r *TwoFactorRepoImpl) SaveTOTPSecret(ctx context.Context, userID int, encryptedSecret string) error {
+	return r.db.WithContext(ctx).Model(&user.Users{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"totp_secret": encryptedSecret, "totp_enabled": false}).Error
+}
+
+// EnableTOTP turns 2FA on and replaces the recovery codes of the user.
+func (r *TwoFactorRepoImpl) EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user.Users{}).Where("id = ?", userID).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&twofactor.RecoveryCodes{}).Error; err != nil {
+			return err
+		}
+		codes := twofactor.HashesToEntities(userID, recoveryCodeHashes)
+		return tx.Omit("User").Create(&codes).Error
+	})
+}
+
+func (r *TwoFactorRepoImpl) DisableTOTP(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user.Users{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_secret": "", "totp_enabled": false}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&twofactor.RecoveryCodes{}).Error
+	})
+}
+
+// ConsumeRecoveryCode marks an unused recovery code as used. It reports false
+// when the code does not exist or was already used.
+func (r *TwoFactorRepoImpl) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&twofactor.RecoveryCodes{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableTOTP(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewTwoFactorRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "totp_enabled"=.* WHERE id = .*`).
+		WithArgs(true, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "user_recovery_codes" WHERE user_id = .*`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "user_recovery_codes"`).
+		WithArgs(1, "hash-1", nil, sqlmock.AnyArg(), 1, "hash-2", nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	err := repo.EnableTOTP(context.Background(), 1, []string{"hash-1", "hash-2"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumeRecoveryCode_AlreadyUsed(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewTwoFactorRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "user_recovery_codes" SET "used_at"=.* WHERE user_id = .* AND code_hash = .* AND used_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 1, "hash-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ok, err := repo.ConsumeRecoveryCode(context.Background(), 1, "hash-1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	userprovider "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user_provider"
+	"gorm.io/gorm"
+)
+
+type UserProviderRepository interface {
+	FindByProviderSubject(ctx context.Context, provider string, subject string) (*userprovider.UserProviders, error)
+	Create(ctx context.Context, link *userprovider.UserProviders) error
+}
+
+type UserProviderRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewUserProviderRepository(db *gorm.DB) UserProviderRepository {
+	return &UserProviderRepoImpl{
+		db: db,
+	}
+}
+
+func (r *UserProviderRepoImpl) FindByProviderSubject(ctx context.Context, provider string, subject string) (*userprovider.UserProviders, error) {
+	var link userprovider.UserProviders
+
+	if err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&link).Error; err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *UserProviderRepoImpl) Create(ctx context.Context, link *userprovider.UserProviders) error {
+	return r.db.WithContext(ctx).Omit("User").Create(link).Error
+}
@@ -0,0 +1,24 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindByProviderSubject(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserProviderRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "provider", "subject", "email"}).
+		AddRow(1, 3, "google", "google-sub-1", "social@example.com")
+	mock.ExpectQuery(`SELECT .* FROM "user_providers" WHERE provider = .* AND subject = .*`).
+		WithArgs("google", "google-sub-1", 1).
+		WillReturnRows(rows)
+
+	link, err := repo.FindByProviderSubject(context.Background(), "google", "google-sub-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, link.UserID)
+}
@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
 	"gorm.io/gorm"
@@ -9,6 +11,16 @@ import (
 
 type UserRepository interface {
 	Login(ctx context.Context, email string)(*user.Users, error)
+	FindByEmail(ctx context.Context, email string) (*user.Users, error)
+	Create(ctx context.Context, userEntity *user.Users) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	RehashPassword(ctx context.Context, userID int, oldHash string, newHash string) error
+	Activate(ctx context.Context, userID int) error
+	FindByID(ctx context.Context, userID int) (*user.Users, error)
+	List(ctx context.Context, query user.ListUsersRequest) ([]user.Users, int64, error)
+	UpdateEmail(ctx context.Context, userID int, email string) error
+	SetDisabled(ctx context.Context, userID int, disabledAt *time.Time) error
+	RequirePasswordReset(ctx context.Context, userID int) error
 }
 
 type UserRepoImpl struct {
@@ -29,4 +41,118 @@ func(r *UserRepoImpl) Login(ctx context.Context, email string)(*user.Users, erro
 	}
 
 	return &userEntity, nil
-}
\ No newline at end of file
+}
+
+func (r *UserRepoImpl) FindByEmail(ctx context.Context, email string) (*user.Users, error) {
+	var userEntity user.Users
+
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&userEntity).Error; err != nil {
+		return nil, err
+	}
+
+	return &userEntity, nil
+}
+
+// Create stores the user and assigns it its Roles, which must already exist.
+func (r *UserRepoImpl) Create(ctx context.Context, userEntity *user.Users) error {
+	return r.db.WithContext(ctx).Omit("Roles.*").Create(userEntity).Error
+}
+
+// UpdatePassword also clears a pending admin-forced password reset.
+func (r *UserRepoImpl) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	return r.db.WithContext(ctx).Model(&user.Users{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password_hash":           passwordHash,
+			"password_reset_required": false,
+		}).Error
+}
+
+// RehashPassword replaces the password hash only if it still equals oldHash,
+// so that it does nothing when the password was changed in the meantime.
+func (r *UserRepoImpl) RehashPassword(ctx context.Context, userID int, oldHash string, newHash string) error {
+	return r.db.WithContext(ctx).Model(&user.Users{}).
+		Where("id = ? AND password_hash = ?", userID, oldHash).
+		Update("password_hash", newHash).Error
+}
+
+func (r *UserRepoImpl) Activate(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Model(&user.Users{}).
+		Where("id = ?", userID).
+		Update("is_active", true).Error
+}
+
+func (r *UserRepoImpl) FindByID(ctx context.Context, userID int) (*user.Users, error) {
+	var userEntity user.Users
+
+	if err := r.db.WithContext(ctx).Where("id = ?", userID).First(&userEntity).Error; err != nil {
+		return nil, err
+	}
+
+	return &userEntity, nil
+}
+
+// List returns one page of users matching the filters, ordered by id, along
+// with the total number of matches.
+func (r *UserRepoImpl) List(ctx context.Context, query user.ListUsersRequest) ([]user.Users, int64, error) {
+	db := r.db.WithContext(ctx).Model(&user.Users{})
+	if query.Email != "" {
+		db = db.Where(`email ILIKE ? ESCAPE '\'`, "%"+escapeLike(query.Email)+"%")
+	}
+	if query.Role != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id "+
+			"WHERE user_roles.user_id = users.id AND roles.name = ?)", query.Role)
+	}
+	switch query.Status {
+	case user.StatusActive:
+		db = db.Where("is_active = ? AND disabled_at IS NULL", true)
+	case user.StatusDisabled:
+		db = db.Where("disabled_at IS NOT NULL")
+	case user.StatusUnverified:
+		db = db.Where("is_active = ?", false)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []user.Users
+	if err := db.Preload("Roles").Order("id").
+		Offset((query.Page - 1) * query.PageSize).
+		Limit(query.PageSize).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// likeEscaper makes the wildcards of a LIKE pattern match themselves, using
+// the backslash escape character.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// UpdateEmail changes the email of the user and marks the account unverified
+// until the new address is confirmed.
+func (r *UserRepoImpl) UpdateEmail(ctx context.Context, userID int, email string) error {
+	return r.db.WithContext(ctx).Model(&user.Users{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"email": email, "is_active": false}).Error
+}
+
+// SetDisabled deactivates the user, or reactivates them when disabledAt is nil.
+func (r *UserRepoImpl) SetDisabled(ctx context.Context, userID int, disabledAt *time.Time) error {
+	return r.db.WithContext(ctx).Model(&user.Users{}).
+		Where("id = ?", userID).
+		Update("disabled_at", disabledAt).Error
+}
+
+func (r *UserRepoImpl) RequirePasswordReset(ctx context.Context, userID int) error {
+	return r.db.WithContext(ctx).Model(&user.Users{}).
+		Where("id = ?", userID).
+		Update("password_reset_required", true).Error
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -53,3 +54,93 @@ func TestLogin_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, userResult)
 }
+
+func TestActivate(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "is_active"=.* WHERE id = .*`).
+		WithArgs(true, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.Activate(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateEmail_MarksUnverified(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "email"=.*,"is_active"=.* WHERE id = .*`).
+		WithArgs("new@example.com", false, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.UpdateEmail(context.Background(), 1, "new@example.com")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRehashPassword_OnlyIfUnchanged(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "password_hash"=.* WHERE id = .* AND password_hash = .*`).
+		WithArgs("$argon2id$new", 1, "$2a$10$old").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := repo.RehashPassword(context.Background(), 1, "$2a$10$old", "$argon2id$new")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListUsers_Filters(t *testing.T) {
+	db, mock := setupMockDB(t)
+	userRepo := NewUserRepository(db)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE email ILIKE .* ESCAPE .* AND \(EXISTS \(.*roles.name = .*\)\) AND disabled_at IS NOT NULL`).
+		WithArgs("%example%", "doctor").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE .* AND disabled_at IS NOT NULL ORDER BY id LIMIT .* OFFSET .*`).
+		WithArgs("%example%", "doctor", 20, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(21, "doctor@example.com"))
+	mock.ExpectQuery(`SELECT \* FROM "user_roles" WHERE "user_roles"."user_id" = .*`).
+		WithArgs(21).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role_id"}).AddRow(21, 3))
+	mock.ExpectQuery(`SELECT \* FROM "roles" WHERE "roles"."id" = .*`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "doctor"))
+
+	users, total, err := userRepo.List(context.Background(), user.ListUsersRequest{
+		Page: 2, PageSize: 20, Email: "example", Role: "doctor", Status: user.StatusDisabled,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(21), total)
+	assert.Len(t, users, 1)
+	assert.Equal(t, "doctor@example.com", users[0].Email)
+	assert.Equal(t, "doctor", users[0].Roles[0].Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListUsers_EscapesEmailWildcards(t *testing.T) {
+	db, mock := setupMockDB(t)
+	userRepo := NewUserRepository(db)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE email ILIKE .* ESCAPE '\\'`).
+		WithArgs(`%a\_b\%c\\%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE email ILIKE .* ORDER BY id LIMIT .*`).
+		WithArgs(`%a\_b\%c\\%`, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+	_, total, err := userRepo.List(context.Background(), user.ListUsersRequest{Page: 1, PageSize: 20, Email: `a_b%c\`})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	adminRole           = "admin"
+	defaultUserPageSize = 20
+)
+
+var (
+	ErrUserNotFound     = utils.NewAppError("user_not_found", http.StatusNotFound, "User not found")
+	ErrCannotModifySelf = utils.NewAppError("cannot_modify_self", http.StatusConflict, "Admins cannot deactivate themselves or drop their own admin role")
+)
+
+// AdminUserService manages accounts on behalf of an admin. Role changes are
+// picked up the next time the user's tokens are issued or refreshed, so they
+// apply within one access token lifetime.
+type AdminUserService interface {
+	ListUsers(ctx context.Context, req user.ListUsersRequest) (*user.UserListResponse, error)
+	CreateUser(ctx context.Context, req user.CreateUserRequest) (*user.UserResponse, error)
+	GetUser(ctx context.Context, userID int) (*user.UserResponse, error)
+	UpdateUser(ctx context.Context, userID int, req user.UpdateUserRequest) (*user.UserResponse, error)
+	DeactivateUser(ctx context.Context, claims *utils.JWTClaim, userID int) error
+	ReactivateUser(ctx context.Context, userID int) error
+	SetRoles(ctx context.Context, claims *utils.JWTClaim, userID int, req user.SetRolesRequest) (*user.UserResponse, error)
+	ForcePasswordReset(ctx context.Context, userID int) error
+}
+
+type AdminUserServiceImpl struct {
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+	roleRepo    repositories.RoleRepository
+	audit       *auditor
+	log         *logrus.Logger
+}
+
+func NewAdminUserService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, roleRepo repositories.RoleRepository, auditRepo repositories.AuditRepository, log *logrus.Logger) AdminUserService {
+	return &AdminUserServiceImpl{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		roleRepo:    roleRepo,
+		audit:       &auditor{repo: auditRepo, log: log},
+		log:         log,
+	}
+}
+
+func (s *AdminUserServiceImpl) ListUsers(ctx context.Context, req user.ListUsersRequest) (*user.UserListResponse, error) {
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultUserPageSize
+	}
+
+	users, total, err := s.userRepo.List(ctx, req)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to list users: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	resp := &user.UserListResponse{
+		Users:    make([]user.UserResponse, 0, len(users)),
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	for i := range users {
+		resp.Users = append(resp.Users, user.EntityToUserResponse(&users[i]))
+	}
+	return resp, nil
+}
+
+// CreateUser creates an account with the given roles. Unless it is created as
+// verified, the user is emailed a verification OTP like a self-registration.
+func (s *AdminUserServiceImpl) CreateUser(ctx context.Context, req user.CreateUserRequest) (*user.UserResponse, error) {
+	existing, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+	if existing != nil {
+		return nil, ErrEmailAlreadyRegistered
+	}
+
+	if len(req.Role) == 0 {
+		req.Role = []string{defaultRole}
+	}
+	roles, err := findRolesByName(ctx, s.roleRepo, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := hashPassword(ctx, req.Password)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to hash password: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	userEntity := &user.Users{
+		Email:    req.Email,
+		Password: passwordHash,
+		IsActive: req.Verified,
+		Roles:    roles,
+	}
+	if err := s.userRepo.Create(ctx, userEntity); err != nil {
+		requestLog(ctx, s.log).Error("Failed to create user: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	if !req.Verified {
+		if err := sendOTP(ctx, s.log, config.OTPVerifyEmail, userEntity.Email, "HealthMate email verification",
+			"Your HealthMate verification code is %s. It expires in 5 minutes."); err != nil {
+			return nil, err
+		}
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"user_id": userEntity.UserID}).Info("User created by admin")
+	s.audit.success(ctx, audit.ActionUserCreated, userEntity.UserID, map[string]interface{}{"roles": req.Role, "verified": req.Verified})
+	resp := user.EntityToUserResponse(userEntity)
+	return &resp, nil
+}
+
+func (s *AdminUserServiceImpl) GetUser(ctx context.Context, userID int) (*user.UserResponse, error) {
+	userEntity, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := user.EntityToUserResponse(userEntity)
+	return &resp, nil
+}
+
+// UpdateUser changes the email of the user. The account has not proven that it
+// owns the new address, so it becomes unverified, is signed out everywhere and
+// gets a verification OTP at the new address.
+func (s *AdminUserServiceImpl) UpdateUser(ctx context.Context, userID int, req user.UpdateUserRequest) (*user.UserResponse, error) {
+	userEntity, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userEntity.Email == req.Email {
+		resp := user.EntityToUserResponse(userEntity)
+		return &resp, nil
+	}
+
+	existing, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+	if existing != nil {
+		return nil, ErrEmailAlreadyRegistered
+	}
+
+	if err := s.userRepo.UpdateEmail(ctx, userID, req.Email); err != nil {
+		requestLog(ctx, s.log).Error("Failed to update email: ", err)
+		return nil, utils.Internal(err)
+	}
+	if err := s.signOutEverywhere(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := sendOTP(ctx, s.log, config.OTPVerifyEmail, req.Email, "HealthMate email verification",
+		"Your HealthMate verification code is %s. It expires in 5 minutes."); err != nil {
+		return nil, err
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"user_id": userID}).Info("User email changed by admin")
+	s.audit.success(ctx, audit.ActionUserEmailChanged, userID, map[string]interface{}{
+		"previous_email": userEntity.Email,
+		"email":          req.Email,
+	})
+	userEntity.Email = req.Email
+	userEntity.IsActive = false
+	resp := user.EntityToUserResponse(userEntity)
+	return &resp, nil
+}
+
+// DeactivateUser disables the account, ends all of its sessions and revokes
+// every token issued to it. The account and its data are kept.
+func (s *AdminUserServiceImpl) DeactivateUser(ctx context.Context, claims *utils.JWTClaim, userID int) error {
+	if claims.UserID == userID {
+		return ErrCannotModifySelf
+	}
+	userEntity, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if userEntity.DisabledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.userRepo.SetDisabled(ctx, userID, &now); err != nil {
+		requestLog(ctx, s.log).Error("Failed to disable user: ", err)
+		return utils.Internal(err)
+	}
+	if err := s.signOutEverywhere(ctx, userID); err != nil {
+		return err
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"user_id": userID, "admin_id": claims.UserID}).Info("User deactivated by admin")
+	s.audit.success(ctx, audit.ActionUserDeactivated, userID, nil)
+	return nil
+}
+
+func (s *AdminUserServiceImpl) ReactivateUser(ctx context.Context, userID int) error {
+	if _, err := s.findUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.SetDisabled(ctx, userID, nil); err != nil {
+		requestLog(ctx, s.log).Error("Failed to reactivate user: ", err)
+		return utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"user_id": userID}).Info("User reactivated by admin")
+	s.audit.success(ctx, audit.ActionUserReactivated, userID, nil)
+	return nil
+}
+
+// SetRoles replaces the roles assigned to the user. Admins cannot take the
+// admin role, directly or through inheritance, away from themselves so that
+// they cannot lock themselves out.
+func (s *AdminUserServiceImpl) SetRoles(ctx context.Context, claims *utils.JWTClaim, userID int, req user.SetRolesRequest) (*user.UserResponse, error) {
+	userEntity, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := findRolesByName(ctx, s.roleRepo, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.UserID == userID {
+		catalogue, err := s.roleRepo.ListRoles(ctx)
+		if err != nil {
+			requestLog(ctx, s.log).Error("Failed to list roles: ", err)
+			return nil, utils.Internal(err)
+		}
+		if !inheritsRole(catalogue, roles, adminRole) {
+			s.audit.failure(ctx, audit.ActionUserRolesChanged, userID, "cannot_modify_self", map[string]interface{}{"roles": req.Role})
+			return nil, ErrCannotModifySelf
+		}
+	}
+
+	roleIDs := make([]int, 0, len(roles))
+	for _, roleEntity := range roles {
+		roleIDs = append(roleIDs, roleEntity.ID)
+	}
+	if err := s.roleRepo.SetUserRoles(ctx, userID, roleIDs); err != nil {
+		requestLog(ctx, s.log).Error("Failed to set user roles: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"user_id": userID, "admin_id": claims.UserID, "roles": req.Role}).Info("User roles updated by admin")
+	s.audit.success(ctx, audit.ActionUserRolesChanged, userID, map[string]interface{}{
+		"previous_roles": user.EntityToUserResponse(userEntity).Role,
+		"roles":          req.Role,
+	})
+	userEntity.Roles = roles
+	resp := user.EntityToUserResponse(userEntity)
+	return &resp, nil
+}
+
+// ForcePasswordReset blocks password login until the user sets a new password
+// through the reset flow, signs them out everywhere and emails them a reset OTP.
+func (s *AdminUserServiceImpl) ForcePasswordReset(ctx context.Context, userID int) error {
+	userEntity, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.RequirePasswordReset(ctx, userID); err != nil {
+		requestLog(ctx, s.log).Error("Failed to require password reset: ", err)
+		return utils.Internal(err)
+	}
+	if err := s.signOutEverywhere(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit.success(ctx, audit.ActionPasswordResetForce, userID, nil)
+	return sendOTP(ctx, s.log, config.OTPResetPassword, userEntity.Email, "HealthMate password reset",
+		"An administrator has required you to reset your HealthMate password. Your reset code is %s. It expires in 5 minutes.")
+}
+
+func (s *AdminUserServiceImpl) findUser(ctx context.Context, userID int) (*user.Users, error) {
+	userEntity, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	roles, err := s.roleRepo.FindUserRoles(ctx, userID)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to find user roles: ", err)
+		return nil, utils.Internal(err)
+	}
+	userEntity.Roles = roles
+	return userEntity, nil
+}
+
+func (s *AdminUserServiceImpl) signOutEverywhere(ctx context.Context, userID int) error {
+	if err := endAllSessions(ctx, s.sessionRepo, userID); err != nil {
+		requestLog(ctx, s.log).Error("Failed to end sessions: ", err)
+		return utils.Internal(err)
+	}
+	return nil
+}
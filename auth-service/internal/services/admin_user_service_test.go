@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+var adminClaims = &utils.JWTClaim{UserID: 99, Role: []string{"admin"}}
+
+func TestAdminDeactivateUser_SignsOutEverywhere(t *testing.T) {
+	setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+	mockRepo.On("SetDisabled", mock.Anything, 1, mock.AnythingOfType("*time.Time")).Return(nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("DeleteByUser", mock.Anything, 1).Return(nil, nil)
+
+	svc := NewAdminUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), logrus.New())
+	require.NoError(t, svc.DeactivateUser(context.Background(), adminClaims, 1))
+
+	sessionRepo.AssertCalled(t, "DeleteByUser", mock.Anything, 1)
+	revoked, err := config.IsTokenRevoked("jti", "", 1, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestAdminDeactivateUser_Self(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), logrus.New())
+
+	err := svc.DeactivateUser(context.Background(), adminClaims, adminClaims.UserID)
+	assert.ErrorIs(t, err, ErrCannotModifySelf)
+	mockRepo.AssertNotCalled(t, "SetDisabled", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminDeactivateUser_NotFound(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 5).Return(nil, gorm.ErrRecordNotFound)
+
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), logrus.New())
+	err := svc.DeactivateUser(context.Background(), adminClaims, 5)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAdminForcePasswordReset(t *testing.T) {
+	mr := setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+	mockRepo.On("RequirePasswordReset", mock.Anything, 1).Return(nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("DeleteByUser", mock.Anything, 1).Return(nil, nil)
+
+	svc := NewAdminUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), logrus.New())
+	require.NoError(t, svc.ForcePasswordReset(context.Background(), 1))
+
+	mockRepo.AssertCalled(t, "RequirePasswordReset", mock.Anything, 1)
+	sessionRepo.AssertCalled(t, "DeleteByUser", mock.Anything, 1)
+	assert.True(t, mr.Exists("otp:reset_password:test@example.com"))
+}
+
+func TestAdminUpdateUser_EmailChangeRequiresVerification(t *testing.T) {
+	mr := setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+	mockRepo.On("FindByEmail", mock.Anything, "new@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("UpdateEmail", mock.Anything, 1, "new@example.com").Return(nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("DeleteByUser", mock.Anything, 1).Return(nil, nil)
+
+	svc := NewAdminUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), logrus.New())
+	resp, err := svc.UpdateUser(context.Background(), 1, user.UpdateUserRequest{Email: "new@example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "new@example.com", resp.Email)
+	assert.False(t, resp.IsActive)
+	sessionRepo.AssertCalled(t, "DeleteByUser", mock.Anything, 1)
+	assert.True(t, mr.Exists("otp:verify_email:new@example.com"))
+	assert.False(t, mr.Exists("otp:verify_email:test@example.com"))
+}
+
+func TestAdminUpdateUser_SameEmail(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), logrus.New())
+	resp, err := svc.UpdateUser(context.Background(), 1, user.UpdateUserRequest{Email: "test@example.com"})
+	require.NoError(t, err)
+
+	assert.True(t, resp.IsActive)
+	mockRepo.AssertNotCalled(t, "UpdateEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminCreateUser_EmailAlreadyRegistered(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(newActiveUser(), nil)
+
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), logrus.New())
+	_, err := svc.CreateUser(context.Background(), user.CreateUserRequest{Email: "test@example.com", Password: "123456"})
+
+	assert.ErrorIs(t, err, ErrEmailAlreadyRegistered)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAdminCreateUser_Verified(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "staff@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*user.Users")).Return(nil)
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("FindRolesByName", mock.Anything, []string{"doctor"}).Return([]role.Roles{{ID: 3, Name: "doctor"}}, nil)
+
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), roleRepo, newAuditRepo(), logrus.New())
+	resp, err := svc.CreateUser(context.Background(), user.CreateUserRequest{
+		Email:    "staff@example.com",
+		Password: "123456",
+		Role:     []string{"doctor"},
+		Verified: true,
+	})
+
+	require.NoError(t, err)
+	assert.True(t, resp.IsActive)
+	assert.Equal(t, []string{"doctor"}, resp.Role)
+	created := mockRepo.Calls[1].Arguments.Get(1).(*user.Users)
+	assert.Equal(t, 3, created.Roles[0].ID)
+}
+
+func TestAdminCreateUser_UnknownRole(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "staff@example.com").Return(nil, gorm.ErrRecordNotFound)
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("FindRolesByName", mock.Anything, []string{"doctor", "docter"}).Return([]role.Roles{{ID: 3, Name: "doctor"}}, nil)
+
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), roleRepo, newAuditRepo(), logrus.New())
+	_, err := svc.CreateUser(context.Background(), user.CreateUserRequest{
+		Email:    "staff@example.com",
+		Password: "123456",
+		Role:     []string{"doctor", "docter"},
+	})
+
+	assert.ErrorIs(t, err, ErrUnknownRole)
+	assert.Contains(t, err.Error(), "docter")
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAdminSetRoles_CannotDropOwnAdmin(t *testing.T) {
+	userRoleID, adminRoleID := 1, 2
+	catalogue := []role.Roles{
+		{ID: 1, Name: "user"},
+		{ID: 2, Name: "admin", ParentID: &userRoleID},
+		{ID: 3, Name: "superadmin", ParentID: &adminRoleID},
+	}
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, adminClaims.UserID).Return(&user.Users{UserID: adminClaims.UserID}, nil)
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("FindUserRoles", mock.Anything, adminClaims.UserID).Return(catalogue[1:2], nil)
+	roleRepo.On("ListRoles", mock.Anything).Return(catalogue, nil)
+	roleRepo.On("FindRolesByName", mock.Anything, []string{"user"}).Return(catalogue[:1], nil)
+	roleRepo.On("FindRolesByName", mock.Anything, []string{"superadmin"}).Return(catalogue[2:], nil)
+	roleRepo.On("SetUserRoles", mock.Anything, adminClaims.UserID, []int{3}).Return(nil)
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), roleRepo, newAuditRepo(), logrus.New())
+
+	_, err := svc.SetRoles(context.Background(), adminClaims, adminClaims.UserID, user.SetRolesRequest{Role: []string{"user"}})
+	assert.ErrorIs(t, err, ErrCannotModifySelf)
+	roleRepo.AssertNotCalled(t, "SetUserRoles", mock.Anything, mock.Anything, mock.Anything)
+
+	// superadmin inherits admin, so the admin keeps access.
+	resp, err := svc.SetRoles(context.Background(), adminClaims, adminClaims.UserID, user.SetRolesRequest{Role: []string{"superadmin"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"superadmin"}, resp.Role)
+}
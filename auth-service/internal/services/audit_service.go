@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+const defaultAuditPageSize = 50
+
+var ErrInvalidTimeRange = utils.NewAppError("invalid_time_range", http.StatusBadRequest, "From must be before to")
+
+// AuditService queries the audit log. Events are written by the services
+// whose actions they describe.
+type AuditService interface {
+	ListEvents(ctx context.Context, req audit.ListEventsRequest) (*audit.EventListResponse, error)
+}
+
+type AuditServiceImpl struct {
+	auditRepo repositories.AuditRepository
+	log       *logrus.Logger
+}
+
+func NewAuditService(auditRepo repositories.AuditRepository, log *logrus.Logger) AuditService {
+	return &AuditServiceImpl{
+		auditRepo: auditRepo,
+		log:       log,
+	}
+}
+
+func (s *AuditServiceImpl) ListEvents(ctx context.Context, req audit.ListEventsRequest) (*audit.EventListResponse, error) {
+	if !req.From.IsZero() && !req.To.IsZero() && !req.From.Before(req.To) {
+		return nil, ErrInvalidTimeRange
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = defaultAuditPageSize
+	}
+
+	events, total, err := s.auditRepo.List(ctx, req)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to list audit events: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	resp := &audit.EventListResponse{
+		Events:   make([]audit.EventResponse, 0, len(events)),
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	for i := range events {
+		resp.Events = append(resp.Events, audit.EntityToEventResponse(&events[i]))
+	}
+	return resp, nil
+}
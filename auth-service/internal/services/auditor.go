@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+// auditor appends security events to the audit log. The IP, user agent and
+// request ID come from the request context, and the actor defaults to the
+// authenticated caller, or else to the subject. Failing to write an event is
+// logged but does not fail the operation being audited.
+type auditor struct {
+	repo repositories.AuditRepository
+	log  *logrus.Logger
+}
+
+func (a *auditor) record(ctx context.Context, event audit.AuditEvents) {
+	if a == nil || a.repo == nil {
+		return
+	}
+
+	meta := utils.RequestMetaFromContext(ctx)
+	event.IPAddress = meta.IP
+	event.UserAgent = meta.UserAgent
+	event.RequestID = meta.RequestID
+	event.CreatedAt = time.Now()
+	if event.ActorID == nil {
+		if claims, ok := utils.ClaimsFromContext(ctx); ok {
+			event.ActorID = &claims.UserID
+		} else {
+			event.ActorID = event.SubjectID
+		}
+	}
+
+	if err := a.repo.Create(context.WithoutCancel(ctx), &event); err != nil {
+		requestLog(ctx, a.log).WithFields(logrus.Fields{
+			"action":  event.Action,
+			"outcome": event.Outcome,
+		}).Error("Failed to write audit event: ", err)
+	}
+}
+
+// success records that action succeeded on the subject user, or on no user
+// when subjectID is zero.
+func (a *auditor) success(ctx context.Context, action string, subjectID int, metadata map[string]interface{}) {
+	a.record(ctx, audit.AuditEvents{SubjectID: auditSubject(subjectID), Action: action, Outcome: audit.OutcomeSuccess, Metadata: metadata})
+}
+
+// failure records that action failed on the subject user with the given
+// reason.
+func (a *auditor) failure(ctx context.Context, action string, subjectID int, reason string, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["reason"] = reason
+	a.record(ctx, audit.AuditEvents{SubjectID: auditSubject(subjectID), Action: action, Outcome: audit.OutcomeFailure, Metadata: metadata})
+}
+
+func auditSubject(userID int) *int {
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
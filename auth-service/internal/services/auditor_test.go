@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+// captureAuditRepo returns an audit repository that keeps every event it is
+// given.
+func captureAuditRepo() (*MockAuditRepo, *[]audit.AuditEvents) {
+	var events []audit.AuditEvents
+	auditRepo := new(MockAuditRepo)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		events = append(events, *args.Get(1).(*audit.AuditEvents))
+	})
+	return auditRepo, &events
+}
+
+func TestLoginWithEmail_AuditsFailureReason(t *testing.T) {
+	passwordHash, err := utils.HashPassword("123456")
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(&user.Users{
+		UserID: 7, Email: "test@example.com", Password: passwordHash, IsActive: true,
+	}, nil)
+	mockRepo.On("Login", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+	auditRepo, events := captureAuditRepo()
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), auditRepo, config.LoginLockout{}, logrus.New())
+	ctx := utils.WithRequestMeta(context.Background(), utils.RequestMeta{IP: "203.0.113.9", UserAgent: "curl/8.0", RequestID: "req-1"})
+	_, err = svc.LoginWithEmail(ctx, user.AuthRequest{Email: "test@example.com", Password: "wrong"})
+	assert.Error(t, err)
+	_, err = svc.LoginWithEmail(ctx, user.AuthRequest{Email: "nobody@example.com", Password: "wrong"})
+	assert.Error(t, err)
+
+	assert.Len(t, *events, 2)
+	badPassword := (*events)[0]
+	assert.Equal(t, audit.ActionLogin, badPassword.Action)
+	assert.Equal(t, audit.OutcomeFailure, badPassword.Outcome)
+	assert.Equal(t, "bad_password", badPassword.Metadata["reason"])
+	assert.Equal(t, 7, *badPassword.SubjectID)
+	assert.Equal(t, 7, *badPassword.ActorID)
+	assert.Equal(t, "203.0.113.9", badPassword.IPAddress)
+	assert.Equal(t, "curl/8.0", badPassword.UserAgent)
+	assert.Equal(t, "req-1", badPassword.RequestID)
+
+	unknown := (*events)[1]
+	assert.Equal(t, "unknown_email", unknown.Metadata["reason"])
+	assert.Equal(t, "nobody@example.com", unknown.Metadata["email"])
+	assert.Nil(t, unknown.SubjectID)
+	assert.Nil(t, unknown.ActorID)
+}
+
+func TestLoginWithEmail_AuditsInternalErrorsWithoutDetails(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(nil, errors.New("pq: connection refused to 10.0.0.5"))
+	auditRepo, events := captureAuditRepo()
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), auditRepo, config.LoginLockout{}, logrus.New())
+	_, err := svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: "test@example.com", Password: "wrong"})
+	assert.Error(t, err)
+
+	assert.Len(t, *events, 1)
+	assert.Equal(t, "internal", (*events)[0].Metadata["reason"])
+}
+
+func TestAdminSetRoles_AuditsActorAndChange(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 5).Return(&user.Users{UserID: 5, Email: "doctor@example.com"}, nil)
+	roleRepo := newRoleRepo()
+	roleRepo.On("SetUserRoles", mock.Anything, 5, []int{1}).Return(nil)
+	auditRepo, events := captureAuditRepo()
+
+	svc := NewAdminUserService(mockRepo, new(MockSessionRepo), roleRepo, auditRepo, logrus.New())
+	claims := &utils.JWTClaim{UserID: 1}
+	ctx := utils.WithClaims(context.Background(), claims)
+	_, err := svc.SetRoles(ctx, claims, 5, user.SetRolesRequest{Role: []string{"user"}})
+	assert.NoError(t, err)
+
+	assert.Len(t, *events, 1)
+	event := (*events)[0]
+	assert.Equal(t, audit.ActionUserRolesChanged, event.Action)
+	assert.Equal(t, 1, *event.ActorID)
+	assert.Equal(t, 5, *event.SubjectID)
+	assert.Equal(t, []string{"user"}, event.Metadata["roles"])
+}
+
+func TestAuditor_WriteFailureDoesNotFailOperation(t *testing.T) {
+	mr := setupRedis(t)
+	defer mr.Close()
+
+	auditRepo := new(MockAuditRepo)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(assert.AnError)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("DeleteByUser", mock.Anything, 3).Return(nil, nil)
+
+	svc := NewUserService(new(MockUserRepo), sessionRepo, newRoleRepo(), auditRepo, config.LoginLockout{}, logrus.New())
+	err := svc.LogoutAll(context.Background(), &utils.JWTClaim{UserID: 3})
+
+	assert.NoError(t, err)
+	auditRepo.AssertCalled(t, "Create", mock.Anything, mock.Anything)
+}
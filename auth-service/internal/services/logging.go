@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+// requestLog returns the log entry of the request in ctx, which carries the
+// request ID, route, client IP and user ID, or an entry of log when ctx does
+// not come from a request.
+func requestLog(ctx context.Context, log *logrus.Logger) *logrus.Entry {
+	return utils.LoggerFromContext(ctx, log)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+func TestLoginWithEmail_LogsThroughRequestEntry(t *testing.T) {
+	var requestOut, globalOut bytes.Buffer
+	requestLogger := logrus.New()
+	requestLogger.SetOutput(&requestOut)
+	global := logrus.New()
+	global.SetOutput(&globalOut)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, global)
+
+	ctx := utils.WithLogger(context.Background(), requestLogger.WithField("request_id", "req-9"))
+	_, err := svc.LoginWithEmail(ctx, user.AuthRequest{Email: "nobody@example.com", Password: "secret"})
+
+	assert.Error(t, err)
+	assert.Contains(t, requestOut.String(), "request_id=req-9")
+	assert.Contains(t, requestOut.String(), "Failed to login user")
+	assert.Empty(t, globalOut.String())
+}
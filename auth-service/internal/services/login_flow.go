@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/metrics"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+// loginFlow holds the steps shared by every login method (password, OAuth,
+// TOTP and passkeys) once the user is known: checking that the account may
+// log in, starting the session, issuing tokens and auditing the attempt.
+type loginFlow struct {
+	sessionRepo repositories.SessionRepository
+	roleRepo    repositories.RoleRepository
+	audit       *auditor
+	log         *logrus.Logger
+}
+
+// checkAllowed turns away accounts that may not log in whatever the method:
+// unverified ones, disabled ones and those an admin sent to reset their
+// password.
+func (f *loginFlow) checkAllowed(ctx context.Context, userEntity *user.Users) error {
+	switch {
+	case !userEntity.IsActive:
+		requestLog(ctx, f.log).Warn("Login attempt on unverified account: ", userEntity.UserID)
+		return ErrEmailNotVerified
+	case userEntity.DisabledAt != nil:
+		requestLog(ctx, f.log).Warn("Login attempt on disabled account: ", userEntity.UserID)
+		return ErrAccountDisabled
+	case userEntity.PasswordResetRequired:
+		return ErrPasswordResetRequired
+	}
+	return nil
+}
+
+// completeLogin issues the token pair, or a challenge when the account has a
+// second factor (TOTP or passkey) that must be verified first.
+func (f *loginFlow) completeLogin(ctx context.Context, userEntity *user.Users) (*user.LoginResponse, error) {
+	if err := f.checkAllowed(ctx, userEntity); err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	if userEntity.TOTPEnabled {
+		methods = append(methods, mfaMethodTOTP)
+	}
+	if userEntity.WebAuthnEnabled {
+		methods = append(methods, mfaMethodWebAuthn)
+	}
+	if len(methods) == 0 {
+		return f.issueTokens(ctx, userEntity)
+	}
+
+	challengeToken := utils.RandomToken(32)
+	if err := config.SaveMFAChallenge(utils.HashToken(challengeToken), userEntity.UserID, mfaChallengeTTL); err != nil {
+		requestLog(ctx, f.log).Error("Failed to save mfa challenge: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return &user.LoginResponse{
+		UserID:         userEntity.UserID,
+		Email:          userEntity.Email,
+		MFARequired:    true,
+		ChallengeToken: challengeToken,
+		MFAMethods:     methods,
+	}, nil
+}
+
+// recordLogin audits a login attempt made with method. userEntity is nil when
+// the attempt did not get as far as identifying an account.
+func (f *loginFlow) recordLogin(ctx context.Context, method string, email string, userEntity *user.Users, resp *user.LoginResponse, err error) {
+	var subjectID int
+	if userEntity != nil {
+		subjectID = userEntity.UserID
+		email = userEntity.Email
+	}
+	metadata := map[string]interface{}{"method": method}
+	if email != "" {
+		metadata["email"] = email
+	}
+
+	metrics.LoginAttempts.WithLabelValues(method, loginOutcome(resp, err)).Inc()
+	switch {
+	case err != nil:
+		f.audit.failure(ctx, audit.ActionLogin, subjectID, loginFailureReason(err), metadata)
+	case resp.MFARequired:
+		f.audit.record(ctx, audit.AuditEvents{
+			SubjectID: auditSubject(subjectID),
+			Action:    audit.ActionLogin,
+			Outcome:   audit.OutcomeMFARequired,
+			Metadata:  metadata,
+		})
+	default:
+		f.audit.success(ctx, audit.ActionLogin, subjectID, metadata)
+	}
+}
+
+// issueTokens starts a new session for the requesting device and issues a
+// token pair bound to it. Every login method ends here, so it checks again
+// that the account may log in.
+func (f *loginFlow) issueTokens(ctx context.Context, userEntity *user.Users) (*user.LoginResponse, error) {
+	if err := f.checkAllowed(ctx, userEntity); err != nil {
+		return nil, err
+	}
+
+	sessionID := uuid.NewString()
+	resp, err := f.generateTokens(ctx, userEntity, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := utils.RequestMetaFromContext(ctx)
+	now := time.Now()
+	sessionEntity := &session.Sessions{
+		ID:           sessionID,
+		UserID:       userEntity.UserID,
+		DeviceName:   utils.DeviceName(meta.DeviceName, meta.UserAgent),
+		UserAgent:    meta.UserAgent,
+		IPAddress:    meta.IP,
+		RefreshToken: utils.HashToken(resp.RefreshToken),
+		CreatedAt:    now,
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(utils.RefreshTokenTTL),
+	}
+	if err := f.sessionRepo.Create(ctx, sessionEntity); err != nil {
+		requestLog(ctx, f.log).Error("Failed to create session: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return resp, nil
+}
+
+// generateTokens issues a token pair carrying the roles and permissions the
+// user has at this moment, inherited ones included. Changes to roles reach
+// the user on their next refresh.
+func (f *loginFlow) generateTokens(ctx context.Context, userEntity *user.Users, sessionID string) (*user.LoginResponse, error) {
+	access, err := f.roleRepo.EffectiveAccess(ctx, userEntity.UserID)
+	if err != nil {
+		requestLog(ctx, f.log).Error("Failed to resolve roles: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	accessToken, refreshToken, err := utils.GenerateJwtToken(userEntity.UserID, sessionID, access.Permissions, access.Roles)
+	if err != nil {
+		requestLog(ctx, f.log).Error("Failed to generate JWT tokens: ", err)
+		return nil, utils.Internal(err)
+	}
+	metrics.TokensIssued.WithLabelValues(utils.TokenTypeAccess).Inc()
+	metrics.TokensIssued.WithLabelValues(utils.TokenTypeRefresh).Inc()
+
+	return &user.LoginResponse{
+		UserID:       userEntity.UserID,
+		Email:        userEntity.Email,
+		Role:         access.Roles,
+		Permission:   access.Permissions,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
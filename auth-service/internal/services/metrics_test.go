@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/metrics"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+func loginAttempts(outcome string) float64 {
+	return testutil.ToFloat64(metrics.LoginAttempts.WithLabelValues(loginMethodPassword, outcome))
+}
+
+func sampleCount(observer prometheus.Observer) uint64 {
+	var m dto.Metric
+	if err := observer.(prometheus.Metric).Write(&m); err != nil {
+		panic(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestLoginWithEmail_CountsOutcomes(t *testing.T) {
+	setupRedis(t)
+	passwordHash, err := utils.HashPassword("123456")
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(&user.Users{
+		UserID: 1, Email: "test@example.com", Password: passwordHash, IsActive: true,
+	}, nil)
+	mockRepo.On("Login", mock.Anything, "nobody@example.com").Return(nil, gorm.ErrRecordNotFound)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	roleRepo := newRoleRepo()
+	roleRepo.On("EffectiveAccess", mock.Anything, 1).Return(&role.Access{Roles: []string{"user"}}, nil)
+	lockout := config.LoginLockout{MaxAttempts: 2, LockoutDuration: time.Minute, Window: time.Minute}
+	svc := NewUserService(mockRepo, sessionRepo, roleRepo, newAuditRepo(), lockout, logrus.New())
+
+	success, badPassword := loginAttempts(metrics.LoginSuccess), loginAttempts(metrics.LoginBadPassword)
+	notFound, locked := loginAttempts(metrics.LoginNotFound), loginAttempts(metrics.LoginLocked)
+	access := testutil.ToFloat64(metrics.TokensIssued.WithLabelValues(utils.TokenTypeAccess))
+	compares := sampleCount(metrics.PasswordHashDuration.WithLabelValues("compare"))
+
+	ctx := context.Background()
+	_, err = svc.LoginWithEmail(ctx, user.AuthRequest{Email: "test@example.com", Password: "123456"})
+	assert.NoError(t, err)
+	_, err = svc.LoginWithEmail(ctx, user.AuthRequest{Email: "nobody@example.com", Password: "123456"})
+	assert.Error(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = svc.LoginWithEmail(ctx, user.AuthRequest{Email: "test@example.com", Password: "wrong"})
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, success+1, loginAttempts(metrics.LoginSuccess))
+	assert.Equal(t, notFound+1, loginAttempts(metrics.LoginNotFound))
+	assert.Equal(t, badPassword+2, loginAttempts(metrics.LoginBadPassword))
+	assert.Equal(t, locked+1, loginAttempts(metrics.LoginLocked))
+	assert.Equal(t, access+1, testutil.ToFloat64(metrics.TokensIssued.WithLabelValues(utils.TokenTypeAccess)))
+	assert.Equal(t, compares+3, sampleCount(metrics.PasswordHashDuration.WithLabelValues("compare")))
+}
+
+func TestVerifyEmail_CountsOTPResults(t *testing.T) {
+	setupRedis(t)
+	verified := func(result string) float64 {
+		return testutil.ToFloat64(metrics.OTPVerified.WithLabelValues(string(config.OTPVerifyEmail), result))
+	}
+	valid, invalid := verified("valid"), verified("invalid")
+	sent := testutil.ToFloat64(metrics.OTPSent.WithLabelValues(string(config.OTPVerifyEmail)))
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&user.Users{UserID: 1, IsActive: true}, nil)
+	svc := &UserServiceImpl{userRepo: mockRepo, log: logrus.New()}
+	assert.NoError(t, sendOTP(context.Background(), svc.log, config.OTPVerifyEmail, "test@example.com", "subject", "code %s"))
+
+	err := svc.VerifyEmail(context.Background(), user.VerifyEmailRequest{Email: "test@example.com", OTP: "000000x"})
+	assert.ErrorIs(t, err, ErrInvalidOTP)
+
+	assert.Equal(t, sent+1, testutil.ToFloat64(metrics.OTPSent.WithLabelValues(string(config.OTPVerifyEmail))))
+	assert.Equal(t, invalid+1, verified("invalid"))
+	assert.Equal(t, valid, verified("valid"))
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	userprovider "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user_provider"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const oauthStateTTL = 10 * time.Minute
+
+var (
+	ErrUnknownOAuthProvider  = utils.NewAppError("unknown_oauth_provider", http.StatusNotFound, "Unknown OAuth provider")
+	ErrInvalidOAuthState     = utils.NewAppError("invalid_oauth_state", http.StatusBadRequest, "Invalid or expired OAuth state")
+	ErrOAuthExchangeFailed   = utils.NewAppError("oauth_exchange_failed", http.StatusUnauthorized, "OAuth code exchange failed")
+	ErrOAuthEmailNotVerified = utils.NewAppError("oauth_email_not_verified", http.StatusForbidden, "Provider did not return a verified email")
+)
+
+type OAuthService interface {
+	AuthCodeURL(ctx context.Context, provider string) (string, error)
+	HandleCallback(ctx context.Context, provider string, req userprovider.OAuthCallbackRequest) (*user.LoginResponse, error)
+}
+
+type oauthProvider struct {
+	conf     config.OAuthProvider
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type oauthState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+type OAuthServiceImpl struct {
+	providers    map[string]*oauthProvider
+	userRepo     repositories.UserRepository
+	providerRepo repositories.UserProviderRepository
+	roleRepo     repositories.RoleRepository
+	logins       *loginFlow
+	log          *logrus.Logger
+}
+
+func NewOAuthService(
+	providers map[string]config.OAuthProvider,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	roleRepo repositories.RoleRepository,
+	auditRepo repositories.AuditRepository,
+	providerRepo repositories.UserProviderRepository,
+	log *logrus.Logger,
+) OAuthService {
+	configured := make(map[string]*oauthProvider, len(providers))
+	for name, conf := range providers {
+		configured[name] = &oauthProvider{
+			conf: conf,
+			oauth2: &oauth2.Config{
+				ClientID:     conf.ClientID,
+				ClientSecret: conf.ClientSecret,
+				RedirectURL:  conf.RedirectURL,
+				Scopes:       conf.Scopes,
+				Endpoint: oauth2.Endpoint{
+					AuthURL:  conf.AuthURL,
+					TokenURL: conf.TokenURL,
+				},
+			},
+			verifier: oidc.NewVerifier(
+				conf.Issuer,
+				oidc.NewRemoteKeySet(context.Background(), conf.JWKSURL),
+				&oidc.Config{ClientID: conf.ClientID},
+			),
+		}
+	}
+
+	return &OAuthServiceImpl{
+		providers:    configured,
+		userRepo:     userRepo,
+		providerRepo: providerRepo,
+		roleRepo:     roleRepo,
+		logins:       &loginFlow{sessionRepo: sessionRepo, roleRepo: roleRepo, audit: &auditor{repo: auditRepo, log: log}, log: log},
+		log:          log,
+	}
+}
+
+// AuthCodeURL starts the authorization-code flow with PKCE. The state, nonce
+// and code verifier are kept in Redis until the callback.
+func (s *OAuthServiceImpl) AuthCodeURL(ctx context.Context, provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownOAuthProvider
+	}
+
+	state := utils.RandomToken(32)
+	st := oauthState{
+		Provider: provider,
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    utils.RandomToken(32),
+	}
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return "", err
+	}
+	if err := config.SaveOAuthState(state, string(raw), oauthStateTTL); err != nil {
+		requestLog(ctx, s.log).Error("Failed to save oauth state: ", err)
+		return "", utils.Internal(err)
+	}
+
+	opts := []oauth2.AuthCodeOption{
+		oauth2.S256ChallengeOption(st.Verifier),
+		oidc.Nonce(st.Nonce),
+	}
+	if p.conf.ResponseMode != "" {
+		opts = append(opts, oauth2.SetAuthURLParam("response_mode", p.conf.ResponseMode))
+	}
+
+	return p.oauth2.AuthCodeURL(state, opts...), nil
+}
+
+// HandleCallback exchanges the authorization code, verifies the identity and
+// logs in the linked user. Unknown identities are linked to the account with
+// the same verified email, or to a new account.
+func (s *OAuthServiceImpl) HandleCallback(ctx context.Context, provider string, req userprovider.OAuthCallbackRequest) (*user.LoginResponse, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownOAuthProvider
+	}
+
+	raw, err := config.ConsumeOAuthState(req.State)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidOAuthState
+		}
+		requestLog(ctx, s.log).Error("Failed to get oauth state: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	var st oauthState
+	if err := json.Unmarshal([]byte(raw), &st); err != nil || st.Provider != provider {
+		return nil, ErrInvalidOAuthState
+	}
+
+	token, err := p.oauth2.Exchange(ctx, req.Code, oauth2.VerifierOption(st.Verifier))
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to exchange oauth code: ", err)
+		return nil, ErrOAuthExchangeFailed.Wrap(err)
+	}
+
+	identity, err := s.fetchIdentity(ctx, p, token, st.Nonce)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to verify oauth identity: ", err)
+		return nil, ErrOAuthExchangeFailed.Wrap(err)
+	}
+
+	userEntity, err := s.findOrCreateUser(ctx, provider, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.logins.completeLogin(ctx, userEntity)
+	s.logins.recordLogin(ctx, "oauth:"+provider, "", userEntity, resp, err)
+	return resp, err
+}
+
+func (s *OAuthServiceImpl) fetchIdentity(ctx context.Context, p *oauthProvider, token *oauth2.Token, nonce string) (*userprovider.ProviderIdentity, error) {
+	if rawIDToken, ok := token.Extra("id_token").(string); ok && rawIDToken != "" {
+		idToken, err := p.verifier.Verify(ctx, rawIDToken)
+		if err != nil {
+			return nil, err
+		}
+		if idToken.Nonce != nonce {
+			return nil, errors.New("id token nonce mismatch")
+		}
+
+		var claims struct {
+			Email         string      `json:"email"`
+			EmailVerified interface{} `json:"email_verified"`
+		}
+		if err := idToken.Claims(&claims); err != nil {
+			return nil, err
+		}
+
+		return &userprovider.ProviderIdentity{
+			Subject:       idToken.Subject,
+			Email:         claims.Email,
+			EmailVerified: parseEmailVerified(claims.EmailVerified, false),
+		}, nil
+	}
+
+	if p.conf.UserInfoURL == "" {
+		return nil, errors.New("provider returned no id token")
+	}
+
+	resp, err := p.oauth2.Client(ctx, token).Get(p.conf.UserInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("userinfo returned status %d", resp.StatusCode)
+	}
+
+	var info struct {
+		Sub           string      `json:"sub"`
+		ID            string      `json:"id"`
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+
+	subject := info.Sub
+	if subject == "" {
+		subject = info.ID
+	}
+	if subject == "" {
+		return nil, errors.New("userinfo returned no subject")
+	}
+
+	return &userprovider.ProviderIdentity{
+		Subject:       subject,
+		Email:         info.Email,
+		EmailVerified: parseEmailVerified(info.EmailVerified, p.conf.TrustEmail),
+	}, nil
+}
+
+func (s *OAuthServiceImpl) findOrCreateUser(ctx context.Context, provider string, identity *userprovider.ProviderIdentity) (*user.Users, error) {
+	link, err := s.providerRepo.FindByProviderSubject(ctx, provider, identity.Subject)
+	if err == nil {
+		userEntity, err := s.userRepo.FindByID(ctx, link.UserID)
+		if err != nil {
+			requestLog(ctx, s.log).Error("Failed to find linked user: ", err)
+			return nil, utils.Internal(err)
+		}
+		if !userEntity.IsActive {
+			return nil, ErrEmailNotVerified
+		}
+		return userEntity, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		requestLog(ctx, s.log).Error("Failed to find provider link: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrOAuthEmailNotVerified
+	}
+
+	userEntity, err := s.userRepo.FindByEmail(ctx, identity.Email)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		roles, err := defaultRoles(ctx, s.roleRepo, s.log)
+		if err != nil {
+			return nil, err
+		}
+		userEntity = &user.Users{
+			Email:    identity.Email,
+			IsActive: true,
+			Roles:    roles,
+		}
+		if err := s.userRepo.Create(ctx, userEntity); err != nil {
+			requestLog(ctx, s.log).Error("Failed to create user: ", err)
+			return nil, utils.Internal(err)
+		}
+	case err != nil:
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	case !userEntity.IsActive:
+		// Nobody proved ownership of this unverified account, so its password
+		// may belong to someone else. Drop it before trusting the provider.
+		if err := s.userRepo.UpdatePassword(ctx, userEntity.UserID, ""); err != nil {
+			requestLog(ctx, s.log).Error("Failed to clear password: ", err)
+			return nil, utils.Internal(err)
+		}
+		if err := s.userRepo.Activate(ctx, userEntity.UserID); err != nil {
+			requestLog(ctx, s.log).Error("Failed to activate user: ", err)
+			return nil, utils.Internal(err)
+		}
+		userEntity.IsActive = true
+	}
+
+	if err := s.providerRepo.Create(ctx, userprovider.IdentityToEntity(userEntity.UserID, provider, identity)); err != nil {
+		requestLog(ctx, s.log).Error("Failed to link provider: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return userEntity, nil
+}
+
+// parseEmailVerified accepts both booleans and the "true"/"false" strings Apple sends.
+func parseEmailVerified(value interface{}, fallback bool) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		verified, err := strconv.ParseBool(v)
+		return err == nil && verified
+	default:
+		return fallback
+	}
+}
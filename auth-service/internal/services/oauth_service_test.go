@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	userprovider "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user_provider"
+	"gorm.io/gorm"
+)
+
+type MockUserProviderRepo struct {
+	mock.Mock
+}
+
+func (m *MockUserProviderRepo) FindByProviderSubject(ctx context.Context, provider string, subject string) (*userprovider.UserProviders, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userprovider.UserProviders), args.Error(1)
+}
+
+func (m *MockUserProviderRepo) Create(ctx context.Context, link *userprovider.UserProviders) error {
+	args := m.Called(ctx, link)
+	return args.Error(0)
+}
+
+// fakeOIDCProvider is a minimal OIDC provider: it issues an ID token for any
+// authorization code and publishes its signing key as a JWKS.
+type fakeOIDCProvider struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	subject string
+	email   string
+	nonce   string
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	p := &fakeOIDCProvider{key: key, subject: "google-sub-1", email: "social@example.com"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/jwks", p.jwks)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *fakeOIDCProvider) config() config.OAuthProvider {
+	return config.OAuthProvider{
+		Name:        "google",
+		ClientID:    "client-id",
+		RedirectURL: "http://localhost/callback",
+		Issuer:      p.server.URL,
+		AuthURL:     p.server.URL + "/authorize",
+		TokenURL:    p.server.URL + "/token",
+		JWKSURL:     p.server.URL + "/jwks",
+		Scopes:      []string{"openid", "email"},
+	}
+}
+
+func (p *fakeOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("code_verifier") == "" {
+		http.Error(w, "missing code_verifier", http.StatusBadRequest)
+		return
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            "client-id",
+		"sub":            p.subject,
+		"email":          p.email,
+		"email_verified": true,
+		"nonce":          p.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	})
+	idToken.Header["kid"] = "test-key"
+	signed, _ := idToken.SignedString(p.key)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func (p *fakeOIDCProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+// startFlow runs AuthCodeURL and returns the state, remembering the nonce the
+// fake provider must echo in its ID token.
+func startFlow(t *testing.T, svc OAuthService, provider *fakeOIDCProvider) string {
+	authURL, err := svc.AuthCodeURL(context.Background(), "google")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	provider.nonce = query.Get("nonce")
+	return query.Get("state")
+}
+
+func TestOAuthCallback_CreatesUser(t *testing.T) {
+	setupRedis(t)
+	provider := newFakeOIDCProvider(t)
+
+	userRepo := new(MockUserRepo)
+	providerRepo := new(MockUserProviderRepo)
+	providerRepo.On("FindByProviderSubject", mock.Anything, "google", "google-sub-1").Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("FindByEmail", mock.Anything, "social@example.com").Return(nil, gorm.ErrRecordNotFound)
+	userRepo.On("Create", mock.Anything, mock.AnythingOfType("*user.Users")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*user.Users).UserID = 5
+		}).
+		Return(nil)
+	providerRepo.On("Create", mock.Anything, mock.AnythingOfType("*userprovider.UserProviders")).Return(nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	svc := NewOAuthService(map[string]config.OAuthProvider{"google": provider.config()}, userRepo, sessionRepo, newRoleRepo(), newAuditRepo(), providerRepo, logrus.New())
+	state := startFlow(t, svc, provider)
+
+	resp, err := svc.HandleCallback(context.Background(), "google", userprovider.OAuthCallbackRequest{Code: "code", State: state})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, resp.UserID)
+	assert.NotEmpty(t, resp.AccessToken)
+
+	link := providerRepo.Calls[1].Arguments.Get(1).(*userprovider.UserProviders)
+	assert.Equal(t, 5, link.UserID)
+	assert.Equal(t, "google-sub-1", link.Subject)
+}
+
+func TestOAuthCallback_LinkedUser(t *testing.T) {
+	setupRedis(t)
+	provider := newFakeOIDCProvider(t)
+
+	userRepo := new(MockUserRepo)
+	providerRepo := new(MockUserProviderRepo)
+	providerRepo.On("FindByProviderSubject", mock.Anything, "google", "google-sub-1").Return(&userprovider.UserProviders{UserID: 3}, nil)
+	userRepo.On("FindByID", mock.Anything, 3).Return(newActiveUser(), nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	svc := NewOAuthService(map[string]config.OAuthProvider{"google": provider.config()}, userRepo, sessionRepo, newRoleRepo(), newAuditRepo(), providerRepo, logrus.New())
+	state := startFlow(t, svc, provider)
+
+	resp, err := svc.HandleCallback(context.Background(), "google", userprovider.OAuthCallbackRequest{Code: "code", State: state})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	providerRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestOAuthCallback_LinkedUserRequiresPasswordReset(t *testing.T) {
+	setupRedis(t)
+	provider := newFakeOIDCProvider(t)
+
+	resetRequired := newActiveUser()
+	resetRequired.PasswordResetRequired = true
+	userRepo := new(MockUserRepo)
+	providerRepo := new(MockUserProviderRepo)
+	providerRepo.On("FindByProviderSubject", mock.Anything, "google", "google-sub-1").Return(&userprovider.UserProviders{UserID: 3}, nil)
+	userRepo.On("FindByID", mock.Anything, 3).Return(resetRequired, nil)
+	sessionRepo := new(MockSessionRepo)
+
+	svc := NewOAuthService(map[string]config.OAuthProvider{"google": provider.config()}, userRepo, sessionRepo, newRoleRepo(), newAuditRepo(), providerRepo, logrus.New())
+	state := startFlow(t, svc, provider)
+
+	_, err := svc.HandleCallback(context.Background(), "google", userprovider.OAuthCallbackRequest{Code: "code", State: state})
+
+	assert.ErrorIs(t, err, ErrPasswordResetRequired)
+	sessionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestOAuthCallback_StateIsSingleUse(t *testing.T) {
+	setupRedis(t)
+	provider := newFakeOIDCProvider(t)
+
+	userRepo := new(MockUserRepo)
+	providerRepo := new(MockUserProviderRepo)
+	providerRepo.On("FindByProviderSubject", mock.Anything, "google", "google-sub-1").Return(&userprovider.UserProviders{UserID: 3}, nil)
+	userRepo.On("FindByID", mock.Anything, 3).Return(newActiveUser(), nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	svc := NewOAuthService(map[string]config.OAuthProvider{"google": provider.config()}, userRepo, sessionRepo, newRoleRepo(), newAuditRepo(), providerRepo, logrus.New())
+	state := startFlow(t, svc, provider)
+
+	_, err := svc.HandleCallback(context.Background(), "google", userprovider.OAuthCallbackRequest{Code: "code", State: state})
+	assert.NoError(t, err)
+
+	_, err = svc.HandleCallback(context.Background(), "google", userprovider.OAuthCallbackRequest{Code: "code", State: state})
+	assert.ErrorIs(t, err, ErrInvalidOAuthState)
+}
+
+func TestOAuthCallback_NonceMismatch(t *testing.T) {
+	setupRedis(t)
+	provider := newFakeOIDCProvider(t)
+
+	svc := NewOAuthService(map[string]config.OAuthProvider{"google": provider.config()}, new(MockUserRepo), new(MockSessionRepo), newRoleRepo(), newAuditRepo(), new(MockUserProviderRepo), logrus.New())
+	state := startFlow(t, svc, provider)
+	provider.nonce = "another-nonce"
+
+	_, err := svc.HandleCallback(context.Background(), "google", userprovider.OAuthCallbackRequest{Code: "code", State: state})
+	assert.ErrorIs(t, err, ErrOAuthExchangeFailed)
+}
+
+func TestOAuthAuthCodeURL_UnknownProvider(t *testing.T) {
+	svc := NewOAuthService(map[string]config.OAuthProvider{}, new(MockUserRepo), new(MockSessionRepo), newRoleRepo(), newAuditRepo(), new(MockUserProviderRepo), logrus.New())
+
+	_, err := svc.AuthCodeURL(context.Background(), "github")
+	assert.ErrorIs(t, err, ErrUnknownOAuthProvider)
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/metrics"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sendOTP stores a new OTP for email and purpose, replacing any earlier one,
+// and emails it using bodyFormat.
+func sendOTP(ctx context.Context, log *logrus.Logger, purpose config.OTPPurpose, email string, subject string, bodyFormat string) (err error) {
+	ctx, span := tracer.Start(ctx, "otp.send", trace.WithAttributes(attribute.String("otp.purpose", string(purpose))))
+	defer func() { endSpan(span, err) }()
+
+	otp := utils.RandomOTP()
+	if err := config.SaveOTP(ctx, purpose, otp, email); err != nil {
+		requestLog(ctx, log).Error("Failed to save OTP: ", err)
+		return utils.Internal(err)
+	}
+
+	if err := config.SendMail(email, subject, fmt.Sprintf(bodyFormat, otp)); err != nil {
+		requestLog(ctx, log).Error("Failed to send OTP email: ", err)
+		return utils.Internal(err)
+	}
+
+	metrics.OTPSent.WithLabelValues(string(purpose)).Inc()
+	return nil
+}
+
+// verifyOTP checks otp against the one stored for email and purpose, which
+// is consumed on a match.
+func verifyOTP(ctx context.Context, purpose config.OTPPurpose, email string, otp string) (bool, error) {
+	ctx, span := tracer.Start(ctx, "otp.verify", trace.WithAttributes(attribute.String("otp.purpose", string(purpose))))
+	valid, err := config.VerifyOTP(ctx, purpose, email, otp)
+	endSpan(span, err)
+	if err != nil {
+		return false, err
+	}
+
+	result := "valid"
+	if !valid {
+		result = "invalid"
+	}
+	metrics.OTPVerified.WithLabelValues(string(purpose), result).Inc()
+	return valid, nil
+}
@@ -0,0 +1,492 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/passkey"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaMethodWebAuthn  = "webauthn"
+	webauthnSessionTTL = 5 * time.Minute
+)
+
+// Purposes of a stored ceremony session, so that a challenge issued for one
+// ceremony cannot be answered in another.
+const (
+	webauthnPurposeRegister     = "register"
+	webauthnPurposeLogin        = "login"
+	webauthnPurposeSecondFactor = "second_factor"
+)
+
+var (
+	ErrPasskeysUnavailable       = utils.NewAppError("passkeys_unavailable", http.StatusServiceUnavailable, "Passkeys are not configured")
+	ErrInvalidWebAuthnSession    = utils.NewAppError("invalid_webauthn_session", http.StatusBadRequest, "Invalid or expired WebAuthn session")
+	ErrPasskeyVerificationFailed = utils.NewAppError("passkey_verification_failed", http.StatusUnauthorized, "Passkey verification failed")
+	ErrPasskeyCloned             = utils.NewAppError("passkey_cloned", http.StatusUnauthorized, "Passkey sign counter did not increase, the authenticator may be cloned")
+	ErrPasskeyNotFound           = utils.NewAppError("passkey_not_found", http.StatusNotFound, "Passkey not found")
+)
+
+type PasskeyService interface {
+	BeginRegistration(ctx context.Context, claims *utils.JWTClaim, req passkey.BeginRegistrationRequest) (*passkey.BeginResponse, error)
+	FinishRegistration(ctx context.Context, claims *utils.JWTClaim, req passkey.FinishRegistrationRequest) (*passkey.PasskeyResponse, error)
+	BeginLogin(ctx context.Context) (*passkey.BeginResponse, error)
+	FinishLogin(ctx context.Context, req passkey.FinishLoginRequest) (*user.LoginResponse, error)
+	BeginSecondFactor(ctx context.Context, req passkey.BeginSecondFactorRequest) (*passkey.BeginResponse, error)
+	FinishSecondFactor(ctx context.Context, req passkey.FinishSecondFactorRequest) (*user.LoginResponse, error)
+	List(ctx context.Context, claims *utils.JWTClaim) ([]passkey.PasskeyResponse, error)
+	Delete(ctx context.Context, claims *utils.JWTClaim, id int) error
+}
+
+type webauthnSession struct {
+	Purpose string               `json:"purpose"`
+	UserID  int                  `json:"user_id,omitempty"`
+	Name    string               `json:"name,omitempty"`
+	Data    webauthn.SessionData `json:"data"`
+}
+
+// webauthnUser adapts a user and its stored credentials to webauthn.User. The
+// user handle is the user id, which carries no personal data.
+type webauthnUser struct {
+	entity   *user.Users
+	passkeys []passkey.Passkeys
+}
+
+func (u *webauthnUser) WebAuthnID() []byte {
+	return []byte(strconv.Itoa(u.entity.UserID))
+}
+
+func (u *webauthnUser) WebAuthnName() string {
+	return u.entity.Email
+}
+
+func (u *webauthnUser) WebAuthnDisplayName() string {
+	return u.entity.Email
+}
+
+func (u *webauthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.passkeys))
+	for _, p := range u.passkeys {
+		credentials = append(credentials, passkey.EntityToCredential(p))
+	}
+	return credentials
+}
+
+func (u *webauthnUser) findPasskey(credentialID []byte) *passkey.Passkeys {
+	for i := range u.passkeys {
+		if bytes.Equal(u.passkeys[i].CredentialID, credentialID) {
+			return &u.passkeys[i]
+		}
+	}
+	return nil
+}
+
+type PasskeyServiceImpl struct {
+	webauthn           *webauthn.WebAuthn
+	attestationFormats []protocol.AttestationFormat
+	userRepo           repositories.UserRepository
+	passkeyRepo        repositories.PasskeyRepository
+	logins             *loginFlow
+	log                *logrus.Logger
+}
+
+// NewPasskeyService returns a service whose every method fails with
+// ErrPasskeysUnavailable when conf has no relying party id.
+func NewPasskeyService(
+	conf config.WebAuthn,
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	roleRepo repositories.RoleRepository,
+	auditRepo repositories.AuditRepository,
+	passkeyRepo repositories.PasskeyRepository,
+	log *logrus.Logger,
+) (PasskeyService, error) {
+	s := &PasskeyServiceImpl{
+		userRepo:    userRepo,
+		passkeyRepo: passkeyRepo,
+		logins:      &loginFlow{sessionRepo: sessionRepo, roleRepo: roleRepo, audit: &auditor{repo: auditRepo, log: log}, log: log},
+		log:         log,
+	}
+	if conf.RPID == "" {
+		return s, nil
+	}
+
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:                  conf.RPID,
+		RPDisplayName:         conf.RPDisplayName,
+		RPOrigins:             conf.RPOrigins,
+		AttestationPreference: protocol.ConveyancePreference(conf.Attestation),
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:      protocol.ResidentKeyRequirementPreferred,
+			UserVerification: protocol.VerificationPreferred,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.webauthn = w
+	for _, format := range conf.AttestationFormats {
+		s.attestationFormats = append(s.attestationFormats, protocol.AttestationFormat(format))
+	}
+	return s, nil
+}
+
+// BeginRegistration starts adding a passkey to the current user. Passkeys
+// already registered are excluded so the same authenticator is not added twice.
+func (s *PasskeyServiceImpl) BeginRegistration(ctx context.Context, claims *utils.JWTClaim, req passkey.BeginRegistrationRequest) (*passkey.BeginResponse, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	wUser, err := s.loadUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []webauthn.RegistrationOption{
+		webauthn.WithExclusions(webauthn.Credentials(wUser.WebAuthnCredentials()).CredentialDescriptors()),
+	}
+	if len(s.attestationFormats) > 0 {
+		opts = append(opts, webauthn.WithAttestationFormats(s.attestationFormats))
+	}
+
+	creation, session, err := s.webauthn.BeginRegistration(wUser, opts...)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to begin passkey registration: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return s.saveSession(ctx, webauthnSession{Purpose: webauthnPurposeRegister, UserID: claims.UserID, Name: req.Name, Data: *session}, creation)
+}
+
+// FinishRegistration verifies the attestation and stores the new credential.
+func (s *PasskeyServiceImpl) FinishRegistration(ctx context.Context, claims *utils.JWTClaim, req passkey.FinishRegistrationRequest) (*passkey.PasskeyResponse, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	session, err := s.consumeSession(ctx, req.SessionID, webauthnPurposeRegister)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != claims.UserID {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	wUser, err := s.loadUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to parse passkey attestation: ", err)
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	credential, err := s.webauthn.CreateCredential(wUser, session.Data, parsed)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to verify passkey attestation: ", err)
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	entity := passkey.CredentialToEntity(claims.UserID, session.Name, credential)
+	if err := s.passkeyRepo.Create(ctx, entity); err != nil {
+		requestLog(ctx, s.log).Error("Failed to save passkey: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{
+		"user_id":     claims.UserID,
+		"attestation": entity.AttestationFormat,
+	}).Info("Passkey registered")
+	resp := passkey.EntityToResponse(*entity)
+	return &resp, nil
+}
+
+// BeginLogin starts a passwordless login with a discoverable credential. It
+// takes no email, so the options say nothing about which accounts exist or
+// which credentials they have.
+func (s *PasskeyServiceImpl) BeginLogin(ctx context.Context) (*passkey.BeginResponse, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	assertion, session, err := s.webauthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to begin passkey login: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return s.saveSession(ctx, webauthnSession{Purpose: webauthnPurposeLogin, Data: *session}, assertion)
+}
+
+// FinishLogin verifies the assertion and logs the owner of the credential in.
+// A passkey with user verification replaces both the password and the second
+// factor, so the token pair is issued directly.
+func (s *PasskeyServiceImpl) FinishLogin(ctx context.Context, req passkey.FinishLoginRequest) (*user.LoginResponse, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	session, err := s.consumeSession(ctx, req.SessionID, webauthnPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to parse passkey assertion: ", err)
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	var wUser *webauthnUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := strconv.Atoi(string(userHandle))
+		if err != nil {
+			return nil, err
+		}
+		wUser, err = s.loadUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		return wUser, nil
+	}
+
+	_, credential, err := s.webauthn.ValidatePasskeyLogin(handler, session.Data, parsed)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to verify passkey assertion: ", err)
+		var userEntity *user.Users
+		if wUser != nil {
+			userEntity = wUser.entity
+		}
+		s.logins.recordLogin(ctx, mfaMethodWebAuthn, "", userEntity, nil, ErrPasskeyVerificationFailed)
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	if err := s.recordAssertion(ctx, wUser, credential); err != nil {
+		s.logins.recordLogin(ctx, mfaMethodWebAuthn, "", wUser.entity, nil, err)
+		return nil, err
+	}
+	resp, err := s.logins.issueTokens(ctx, wUser.entity)
+	s.logins.recordLogin(ctx, mfaMethodWebAuthn, "", wUser.entity, resp, err)
+	return resp, err
+}
+
+// BeginSecondFactor starts a passkey assertion for a login that returned a
+// challenge token.
+func (s *PasskeyServiceImpl) BeginSecondFactor(ctx context.Context, req passkey.BeginSecondFactorRequest) (*passkey.BeginResponse, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	wUser, err := s.loadChallengeUser(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(wUser.passkeys) == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+
+	assertion, session, err := s.webauthn.BeginLogin(wUser)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to begin passkey assertion: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return s.saveSession(ctx, webauthnSession{Purpose: webauthnPurposeSecondFactor, UserID: wUser.entity.UserID, Data: *session}, assertion)
+}
+
+// FinishSecondFactor verifies the assertion and finishes the login.
+func (s *PasskeyServiceImpl) FinishSecondFactor(ctx context.Context, req passkey.FinishSecondFactorRequest) (*user.LoginResponse, error) {
+	if s.webauthn == nil {
+		return nil, ErrPasskeysUnavailable
+	}
+
+	session, err := s.consumeSession(ctx, req.SessionID, webauthnPurposeSecondFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	wUser, err := s.loadChallengeUser(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != wUser.entity.UserID {
+		return nil, ErrInvalidWebAuthnSession
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to parse passkey assertion: ", err)
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	credential, err := s.webauthn.ValidateLogin(wUser, session.Data, parsed)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to verify passkey assertion: ", err)
+		s.logins.recordLogin(ctx, mfaMethodWebAuthn, "", wUser.entity, nil, ErrPasskeyVerificationFailed)
+		return nil, ErrPasskeyVerificationFailed
+	}
+
+	if err := s.recordAssertion(ctx, wUser, credential); err != nil {
+		s.logins.recordLogin(ctx, mfaMethodWebAuthn, "", wUser.entity, nil, err)
+		return nil, err
+	}
+
+	consumed, err := config.ConsumeMFAChallenge(utils.HashToken(req.ChallengeToken))
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to consume mfa challenge: ", err)
+		return nil, utils.Internal(err)
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	resp, err := s.logins.issueTokens(ctx, wUser.entity)
+	s.logins.recordLogin(ctx, mfaMethodWebAuthn, "", wUser.entity, resp, err)
+	return resp, err
+}
+
+func (s *PasskeyServiceImpl) List(ctx context.Context, claims *utils.JWTClaim) ([]passkey.PasskeyResponse, error) {
+	passkeys, err := s.passkeyRepo.FindByUser(ctx, claims.UserID)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to list passkeys: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	resp := make([]passkey.PasskeyResponse, 0, len(passkeys))
+	for _, p := range passkeys {
+		resp = append(resp, passkey.EntityToResponse(p))
+	}
+	return resp, nil
+}
+
+func (s *PasskeyServiceImpl) Delete(ctx context.Context, claims *utils.JWTClaim, id int) error {
+	deleted, err := s.passkeyRepo.Delete(ctx, claims.UserID, id)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to delete passkey: ", err)
+		return utils.Internal(err)
+	}
+	if !deleted {
+		return ErrPasskeyNotFound
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"user_id": claims.UserID, "passkey_id": id}).Info("Passkey removed")
+	return nil
+}
+
+// recordAssertion rejects an assertion whose sign counter did not increase and
+// stores the new counter otherwise.
+func (s *PasskeyServiceImpl) recordAssertion(ctx context.Context, wUser *webauthnUser, credential *webauthn.Credential) error {
+	stored := wUser.findPasskey(credential.ID)
+	if stored == nil {
+		return ErrPasskeyVerificationFailed
+	}
+
+	stored.SignCount = credential.Authenticator.SignCount
+	stored.CloneWarning = credential.Authenticator.CloneWarning
+	stored.BackupState = credential.Flags.BackupState
+	if err := s.passkeyRepo.UpdateAfterLogin(ctx, stored); err != nil {
+		requestLog(ctx, s.log).Error("Failed to update passkey: ", err)
+		return utils.Internal(err)
+	}
+
+	if credential.Authenticator.CloneWarning {
+		requestLog(ctx, s.log).WithFields(logrus.Fields{
+			"user_id":    wUser.entity.UserID,
+			"passkey_id": stored.ID,
+		}).Warn("Passkey sign counter did not increase")
+		return ErrPasskeyCloned
+	}
+	return nil
+}
+
+func (s *PasskeyServiceImpl) loadUser(ctx context.Context, userID int) (*webauthnUser, error) {
+	userEntity, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPasskeyVerificationFailed
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	passkeys, err := s.passkeyRepo.FindByUser(ctx, userID)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to find passkeys: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return &webauthnUser{entity: userEntity, passkeys: passkeys}, nil
+}
+
+func (s *PasskeyServiceImpl) loadChallengeUser(ctx context.Context, challengeToken string) (*webauthnUser, error) {
+	userID, err := config.GetMFAChallenge(utils.HashToken(challengeToken))
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		requestLog(ctx, s.log).Error("Failed to get mfa challenge: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	wUser, err := s.loadUser(ctx, userID)
+	if errors.Is(err, ErrPasskeyVerificationFailed) {
+		return nil, ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !wUser.entity.IsActive {
+		return nil, ErrInvalidMFAChallenge
+	}
+	return wUser, nil
+}
+
+func (s *PasskeyServiceImpl) saveSession(ctx context.Context, session webauthnSession, options interface{}) (*passkey.BeginResponse, error) {
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID := utils.RandomToken(32)
+	if err := config.SaveWebAuthnSession(sessionID, string(raw), webauthnSessionTTL); err != nil {
+		requestLog(ctx, s.log).Error("Failed to save webauthn session: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return &passkey.BeginResponse{SessionID: sessionID, Options: options}, nil
+}
+
+func (s *PasskeyServiceImpl) consumeSession(ctx context.Context, sessionID string, purpose string) (*webauthnSession, error) {
+	raw, err := config.ConsumeWebAuthnSession(sessionID)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidWebAuthnSession
+		}
+		requestLog(ctx, s.log).Error("Failed to get webauthn session: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	var session webauthnSession
+	if err := json.Unmarshal([]byte(raw), &session); err != nil || session.Purpose != purpose {
+		return nil, ErrInvalidWebAuthnSession
+	}
+	return &session, nil
+}
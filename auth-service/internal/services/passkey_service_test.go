@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/passkey"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+const (
+	testRPID   = "healthmate.test"
+	testOrigin = "https://healthmate.test"
+)
+
+type MockPasskeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyRepo) Create(ctx context.Context, credential *passkey.Passkeys) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyRepo) FindByUser(ctx context.Context, userID int) ([]passkey.Passkeys, error) {
+	args := m.Called(ctx, userID)
+	if fn, ok := args.Get(0).(func(context.Context, int) []passkey.Passkeys); ok {
+		return fn(ctx, userID), args.Error(1)
+	}
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]passkey.Passkeys), args.Error(1)
+}
+
+func (m *MockPasskeyRepo) UpdateAfterLogin(ctx context.Context, credential *passkey.Passkeys) error {
+	args := m.Called(ctx, credential)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyRepo) Delete(ctx context.Context, userID int, id int) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// softAuthenticator is a software WebAuthn authenticator with one ES256
+// credential. It answers ceremonies the way a browser and a platform
+// authenticator would, with "none" attestation.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	credentialID := make([]byte, 16)
+	_, err = rand.Read(credentialID)
+	require.NoError(t, err)
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func clientData(t *testing.T, ceremony string, challenge string) []byte {
+	raw, err := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": testOrigin})
+	require.NoError(t, err)
+	return raw
+}
+
+func (a *softAuthenticator) authData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softAuthenticator) register(t *testing.T, options interface{}) json.RawMessage {
+	creation := options.(*protocol.CredentialCreation)
+	userID, ok := creation.Response.User.ID.(protocol.URLEncodedBase64)
+	require.True(t, ok)
+	a.userHandle = userID
+
+	coseKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1,
+		XCoord: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	require.NoError(t, err)
+
+	// Flags: user present, user verified, attested credential data included.
+	authData := a.authData(0x45)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(a.credentialID)))
+	authData = append(authData, a.credentialID...)
+	authData = append(authData, coseKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	require.NoError(t, err)
+
+	return a.marshalCredential(t, map[string]string{
+		"clientDataJSON":    b64(clientData(t, "webauthn.create", creation.Response.Challenge.String())),
+		"attestationObject": b64(attestationObject),
+	})
+}
+
+func (a *softAuthenticator) assert(t *testing.T, options interface{}) json.RawMessage {
+	assertion := options.(*protocol.CredentialAssertion)
+	a.signCount++
+
+	// Flags: user present, user verified.
+	authData := a.authData(0x05)
+	clientDataJSON := clientData(t, "webauthn.get", assertion.Response.Challenge.String())
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+
+	return a.marshalCredential(t, map[string]string{
+		"clientDataJSON":    b64(clientDataJSON),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) marshalCredential(t *testing.T, response map[string]string) json.RawMessage {
+	raw, err := json.Marshal(map[string]interface{}{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	require.NoError(t, err)
+	return raw
+}
+
+// passkeyFixture wires the service to mocks that keep registered passkeys in
+// memory like the real repository would.
+type passkeyFixture struct {
+	svc         PasskeyService
+	userRepo    *MockUserRepo
+	sessionRepo *MockSessionRepo
+	passkeyRepo *MockPasskeyRepo
+	user        *user.Users
+	passkeys    []passkey.Passkeys
+}
+
+func newPasskeyFixture(t *testing.T) *passkeyFixture {
+	setupRedis(t)
+	f := &passkeyFixture{
+		userRepo:    new(MockUserRepo),
+		sessionRepo: new(MockSessionRepo),
+		passkeyRepo: new(MockPasskeyRepo),
+		user:        newActiveUser(),
+	}
+
+	f.userRepo.On("FindByID", mock.Anything, 1).Return(f.user, nil)
+	f.sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	f.passkeyRepo.On("FindByUser", mock.Anything, 1).Return(func(ctx context.Context, userID int) []passkey.Passkeys {
+		return append([]passkey.Passkeys{}, f.passkeys...)
+	}, nil)
+	f.passkeyRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		entity := args.Get(1).(*passkey.Passkeys)
+		entity.ID = len(f.passkeys) + 1
+		f.passkeys = append(f.passkeys, *entity)
+		f.user.WebAuthnEnabled = true
+	})
+	f.passkeyRepo.On("UpdateAfterLogin", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		entity := args.Get(1).(*passkey.Passkeys)
+		f.passkeys[entity.ID-1] = *entity
+	})
+
+	svc, err := NewPasskeyService(config.WebAuthn{
+		RPID:          testRPID,
+		RPDisplayName: "HealthMate",
+		RPOrigins:     []string{testOrigin},
+		Attestation:   "none",
+	}, f.userRepo, f.sessionRepo, newRoleRepo(), newAuditRepo(), f.passkeyRepo, logrus.New())
+	require.NoError(t, err)
+	f.svc = svc
+	return f
+}
+
+func (f *passkeyFixture) register(t *testing.T, authenticator *softAuthenticator) {
+	claims := &utils.JWTClaim{UserID: 1}
+	begin, err := f.svc.BeginRegistration(context.Background(), claims, passkey.BeginRegistrationRequest{Name: "Phone"})
+	require.NoError(t, err)
+
+	credential := authenticator.register(t, begin.Options)
+	resp, err := f.svc.FinishRegistration(context.Background(), claims, passkey.FinishRegistrationRequest{SessionID: begin.SessionID, Credential: credential})
+	require.NoError(t, err)
+	assert.Equal(t, "Phone", resp.Name)
+	assert.Equal(t, "none", resp.AttestationFormat)
+}
+
+func TestPasskey_RegisterAndLogin(t *testing.T) {
+	f := newPasskeyFixture(t)
+	authenticator := newSoftAuthenticator(t)
+	f.register(t, authenticator)
+	require.Len(t, f.passkeys, 1)
+	assert.Equal(t, authenticator.credentialID, f.passkeys[0].CredentialID)
+	assert.Equal(t, []byte(strconv.Itoa(1)), authenticator.userHandle)
+
+	begin, err := f.svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	resp, err := f.svc.FinishLogin(context.Background(), passkey.FinishLoginRequest{
+		SessionID:  begin.SessionID,
+		Credential: authenticator.assert(t, begin.Options),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	assert.Equal(t, uint32(1), f.passkeys[0].SignCount)
+
+	// The ceremony session is single-use.
+	_, err = f.svc.FinishLogin(context.Background(), passkey.FinishLoginRequest{
+		SessionID:  begin.SessionID,
+		Credential: authenticator.assert(t, begin.Options),
+	})
+	assert.ErrorIs(t, err, ErrInvalidWebAuthnSession)
+}
+
+func TestPasskey_LoginRequiresPasswordReset(t *testing.T) {
+	f := newPasskeyFixture(t)
+	authenticator := newSoftAuthenticator(t)
+	f.register(t, authenticator)
+	f.user.PasswordResetRequired = true
+
+	begin, err := f.svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	_, err = f.svc.FinishLogin(context.Background(), passkey.FinishLoginRequest{
+		SessionID:  begin.SessionID,
+		Credential: authenticator.assert(t, begin.Options),
+	})
+	assert.ErrorIs(t, err, ErrPasswordResetRequired)
+	f.sessionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestPasskey_RejectsClonedAuthenticator(t *testing.T) {
+	f := newPasskeyFixture(t)
+	authenticator := newSoftAuthenticator(t)
+	f.register(t, authenticator)
+	f.passkeys[0].SignCount = 10
+
+	begin, err := f.svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	_, err = f.svc.FinishLogin(context.Background(), passkey.FinishLoginRequest{
+		SessionID:  begin.SessionID,
+		Credential: authenticator.assert(t, begin.Options),
+	})
+	assert.ErrorIs(t, err, ErrPasskeyCloned)
+	assert.True(t, f.passkeys[0].CloneWarning)
+}
+
+func TestPasskey_RejectsWrongKey(t *testing.T) {
+	f := newPasskeyFixture(t)
+	authenticator := newSoftAuthenticator(t)
+	f.register(t, authenticator)
+
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialID = authenticator.credentialID
+	impostor.userHandle = authenticator.userHandle
+
+	begin, err := f.svc.BeginLogin(context.Background())
+	require.NoError(t, err)
+	_, err = f.svc.FinishLogin(context.Background(), passkey.FinishLoginRequest{
+		SessionID:  begin.SessionID,
+		Credential: impostor.assert(t, begin.Options),
+	})
+	assert.ErrorIs(t, err, ErrPasskeyVerificationFailed)
+}
+
+func TestPasskey_SecondFactor(t *testing.T) {
+	f := newPasskeyFixture(t)
+	authenticator := newSoftAuthenticator(t)
+	f.register(t, authenticator)
+
+	passwordHash, err := utils.HashPassword("123456")
+	require.NoError(t, err)
+	f.user.Password = passwordHash
+	f.userRepo.On("Login", mock.Anything, f.user.Email).Return(f.user, nil)
+
+	users := NewUserService(f.userRepo, f.sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	login, err := users.LoginWithEmail(context.Background(), user.AuthRequest{Email: f.user.Email, Password: "123456"})
+	require.NoError(t, err)
+	assert.True(t, login.MFARequired)
+	assert.Equal(t, []string{mfaMethodWebAuthn}, login.MFAMethods)
+
+	begin, err := f.svc.BeginSecondFactor(context.Background(), passkey.BeginSecondFactorRequest{ChallengeToken: login.ChallengeToken})
+	require.NoError(t, err)
+	resp, err := f.svc.FinishSecondFactor(context.Background(), passkey.FinishSecondFactorRequest{
+		ChallengeToken: login.ChallengeToken,
+		SessionID:      begin.SessionID,
+		Credential:     authenticator.assert(t, begin.Options),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+
+	// The login challenge cannot be used again.
+	_, err = f.svc.BeginSecondFactor(context.Background(), passkey.BeginSecondFactorRequest{ChallengeToken: login.ChallengeToken})
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+func TestPasskey_Unavailable(t *testing.T) {
+	svc, err := NewPasskeyService(config.WebAuthn{}, new(MockUserRepo), new(MockSessionRepo), newRoleRepo(), newAuditRepo(), new(MockPasskeyRepo), logrus.New())
+	require.NoError(t, err)
+
+	_, err = svc.BeginLogin(context.Background())
+	assert.ErrorIs(t, err, ErrPasskeysUnavailable)
+}
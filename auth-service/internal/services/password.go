@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/metrics"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+// hashPassword hashes a new password, recording how long it took.
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracer.Start(ctx, "password.hash")
+	defer observePasswordHash("hash", time.Now())
+
+	hash, err := utils.HashPassword(password)
+	endSpan(span, err)
+	return hash, err
+}
+
+// comparePassword returns utils.ErrPasswordMismatch when password does not
+// match hash. On a match, rehash reports whether the hash is outdated and
+// should be replaced.
+func comparePassword(ctx context.Context, hash string, password string) (rehash bool, err error) {
+	_, span := tracer.Start(ctx, "password.compare")
+	defer observePasswordHash("compare", time.Now())
+
+	rehash, err = utils.VerifyPassword(hash, password)
+	// A mismatch is an expected outcome, not a failure of the operation.
+	if err != nil && !errors.Is(err, utils.ErrPasswordMismatch) {
+		endSpan(span, err)
+		return false, err
+	}
+	span.End()
+	return rehash, err
+}
+
+func observePasswordHash(operation string, start time.Time) {
+	metrics.PasswordHashDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleNotFound       = utils.NewAppError("role_not_found", http.StatusNotFound, "Role not found")
+	ErrPermissionNotFound = utils.NewAppError("permission_not_found", http.StatusNotFound, "Permission not found")
+	ErrUnknownRole        = utils.NewAppError("unknown_role", http.StatusBadRequest, "Unknown role")
+	ErrUnknownPermission  = utils.NewAppError("unknown_permission", http.StatusBadRequest, "Unknown permission")
+	ErrRoleExists         = utils.NewAppError("role_exists", http.StatusConflict, "Role already exists")
+	ErrPermissionExists   = utils.NewAppError("permission_exists", http.StatusConflict, "Permission already exists")
+	ErrRoleCycle          = utils.NewAppError("role_cycle", http.StatusBadRequest, "Role cannot inherit from itself or its descendants")
+	ErrBuiltInRole        = utils.NewAppError("built_in_role", http.StatusConflict, "Built-in roles cannot be deleted")
+)
+
+// RoleService manages the catalogue of roles and permissions. Changes reach
+// users the next time their tokens are issued or refreshed.
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]role.RoleResponse, error)
+	GetRole(ctx context.Context, id int) (*role.RoleResponse, error)
+	CreateRole(ctx context.Context, req role.CreateRoleRequest) (*role.RoleResponse, error)
+	UpdateRole(ctx context.Context, id int, req role.UpdateRoleRequest) (*role.RoleResponse, error)
+	DeleteRole(ctx context.Context, id int) error
+	SetRolePermissions(ctx context.Context, id int, req role.SetPermissionsRequest) (*role.RoleResponse, error)
+	ListPermissions(ctx context.Context) ([]role.PermissionResponse, error)
+	CreatePermission(ctx context.Context, req role.CreatePermissionRequest) (*role.PermissionResponse, error)
+	DeletePermission(ctx context.Context, id int) error
+}
+
+type RoleServiceImpl struct {
+	roleRepo repositories.RoleRepository
+	audit    *auditor
+	log      *logrus.Logger
+}
+
+func NewRoleService(roleRepo repositories.RoleRepository, auditRepo repositories.AuditRepository, log *logrus.Logger) RoleService {
+	return &RoleServiceImpl{
+		roleRepo: roleRepo,
+		audit:    &auditor{repo: auditRepo, log: log},
+		log:      log,
+	}
+}
+
+func (s *RoleServiceImpl) ListRoles(ctx context.Context) ([]role.RoleResponse, error) {
+	roles, err := s.roleRepo.ListRoles(ctx)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to list roles: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	resp := make([]role.RoleResponse, 0, len(roles))
+	for i := range roles {
+		resp = append(resp, role.EntityToRoleResponse(&roles[i]))
+	}
+	return resp, nil
+}
+
+func (s *RoleServiceImpl) GetRole(ctx context.Context, id int) (*role.RoleResponse, error) {
+	roleEntity, err := s.findRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := role.EntityToRoleResponse(roleEntity)
+	return &resp, nil
+}
+
+func (s *RoleServiceImpl) CreateRole(ctx context.Context, req role.CreateRoleRequest) (*role.RoleResponse, error) {
+	existing, err := s.roleRepo.FindRolesByName(ctx, []string{req.Name})
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to find role: ", err)
+		return nil, utils.Internal(err)
+	}
+	if len(existing) > 0 {
+		return nil, ErrRoleExists
+	}
+
+	roleEntity := &role.Roles{Name: req.Name, Description: req.Description}
+	if req.Parent != "" {
+		parents, err := findRolesByName(ctx, s.roleRepo, []string{req.Parent})
+		if err != nil {
+			return nil, err
+		}
+		roleEntity.ParentID = &parents[0].ID
+	}
+	if len(req.Permissions) > 0 {
+		permissions, err := findPermissionsByName(ctx, s.roleRepo, req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		roleEntity.Permissions = permissions
+	}
+
+	if err := s.roleRepo.CreateRole(ctx, roleEntity); err != nil {
+		requestLog(ctx, s.log).Error("Failed to create role: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"role": roleEntity.Name}).Info("Role created")
+	s.audit.success(ctx, audit.ActionRoleCreated, 0, map[string]interface{}{
+		"role":        roleEntity.Name,
+		"parent":      req.Parent,
+		"permissions": req.Permissions,
+	})
+	return s.GetRole(ctx, roleEntity.ID)
+}
+
+func (s *RoleServiceImpl) UpdateRole(ctx context.Context, id int, req role.UpdateRoleRequest) (*role.RoleResponse, error) {
+	roleEntity, err := s.findRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	description := roleEntity.Description
+	if req.Description != nil {
+		description = *req.Description
+	}
+	parentID := roleEntity.ParentID
+	if req.Parent != nil {
+		parentID = nil
+		if *req.Parent != "" {
+			parents, err := findRolesByName(ctx, s.roleRepo, []string{*req.Parent})
+			if err != nil {
+				return nil, err
+			}
+			catalogue, err := s.roleRepo.ListRoles(ctx)
+			if err != nil {
+				requestLog(ctx, s.log).Error("Failed to list roles: ", err)
+				return nil, utils.Internal(err)
+			}
+			if inheritsRole(catalogue, parents, roleEntity.Name) {
+				return nil, ErrRoleCycle
+			}
+			parentID = &parents[0].ID
+		}
+	}
+
+	if err := s.roleRepo.UpdateRole(ctx, id, description, parentID); err != nil {
+		requestLog(ctx, s.log).Error("Failed to update role: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	metadata := map[string]interface{}{"role": roleEntity.Name}
+	if req.Parent != nil {
+		metadata["parent"] = *req.Parent
+	}
+	if req.Description != nil {
+		metadata["description"] = *req.Description
+	}
+	s.audit.success(ctx, audit.ActionRoleUpdated, 0, metadata)
+
+	return s.GetRole(ctx, id)
+}
+
+// DeleteRole removes a role from the catalogue and from every user it was
+// assigned to. The default and admin roles cannot be deleted.
+func (s *RoleServiceImpl) DeleteRole(ctx context.Context, id int) error {
+	roleEntity, err := s.findRole(ctx, id)
+	if err != nil {
+		return err
+	}
+	if roleEntity.Name == defaultRole || roleEntity.Name == adminRole {
+		return ErrBuiltInRole
+	}
+
+	deleted, err := s.roleRepo.DeleteRole(ctx, id)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to delete role: ", err)
+		return utils.Internal(err)
+	}
+	if !deleted {
+		return ErrRoleNotFound
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"role": roleEntity.Name}).Info("Role deleted")
+	s.audit.success(ctx, audit.ActionRoleDeleted, 0, map[string]interface{}{"role": roleEntity.Name})
+	return nil
+}
+
+// SetRolePermissions replaces the permissions granted directly to the role.
+func (s *RoleServiceImpl) SetRolePermissions(ctx context.Context, id int, req role.SetPermissionsRequest) (*role.RoleResponse, error) {
+	roleEntity, err := s.findRole(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var permissionIDs []int
+	if len(req.Permissions) > 0 {
+		permissions, err := findPermissionsByName(ctx, s.roleRepo, req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range permissions {
+			permissionIDs = append(permissionIDs, permission.ID)
+		}
+	}
+
+	if err := s.roleRepo.SetRolePermissions(ctx, id, permissionIDs); err != nil {
+		requestLog(ctx, s.log).Error("Failed to set role permissions: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	s.audit.success(ctx, audit.ActionRolePermissions, 0, map[string]interface{}{
+		"role":                 roleEntity.Name,
+		"previous_permissions": role.EntityToRoleResponse(roleEntity).Permissions,
+		"permissions":          req.Permissions,
+	})
+
+	return s.GetRole(ctx, id)
+}
+
+func (s *RoleServiceImpl) ListPermissions(ctx context.Context) ([]role.PermissionResponse, error) {
+	permissions, err := s.roleRepo.ListPermissions(ctx)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to list permissions: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	resp := make([]role.PermissionResponse, 0, len(permissions))
+	for i := range permissions {
+		resp = append(resp, role.EntityToPermissionResponse(&permissions[i]))
+	}
+	return resp, nil
+}
+
+func (s *RoleServiceImpl) CreatePermission(ctx context.Context, req role.CreatePermissionRequest) (*role.PermissionResponse, error) {
+	existing, err := s.roleRepo.FindPermissionsByName(ctx, []string{req.Name})
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to find permission: ", err)
+		return nil, utils.Internal(err)
+	}
+	if len(existing) > 0 {
+		return nil, ErrPermissionExists
+	}
+
+	permission := &role.Permissions{Name: req.Name, Description: req.Description}
+	if err := s.roleRepo.CreatePermission(ctx, permission); err != nil {
+		requestLog(ctx, s.log).Error("Failed to create permission: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"permission": permission.Name}).Info("Permission created")
+	s.audit.success(ctx, audit.ActionPermissionCreated, 0, map[string]interface{}{"permission": permission.Name})
+	resp := role.EntityToPermissionResponse(permission)
+	return &resp, nil
+}
+
+// DeletePermission removes a permission from the catalogue and from every
+// role it was granted to.
+func (s *RoleServiceImpl) DeletePermission(ctx context.Context, id int) error {
+	deleted, err := s.roleRepo.DeletePermission(ctx, id)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to delete permission: ", err)
+		return utils.Internal(err)
+	}
+	if !deleted {
+		return ErrPermissionNotFound
+	}
+
+	s.audit.success(ctx, audit.ActionPermissionDeleted, 0, map[string]interface{}{"permission_id": id})
+	return nil
+}
+
+func (s *RoleServiceImpl) findRole(ctx context.Context, id int) (*role.Roles, error) {
+	roleEntity, err := s.roleRepo.FindRoleByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		requestLog(ctx, s.log).Error("Failed to find role: ", err)
+		return nil, utils.Internal(err)
+	}
+	return roleEntity, nil
+}
+
+// findRolesByName looks up every named role and fails with ErrUnknownRole,
+// naming the missing ones, unless all of them exist.
+func findRolesByName(ctx context.Context, roleRepo repositories.RoleRepository, names []string) ([]role.Roles, error) {
+	roles, err := roleRepo.FindRolesByName(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(roles))
+	for _, roleEntity := range roles {
+		found[roleEntity.Name] = true
+	}
+	if missing := missingNames(names, found); len(missing) > 0 {
+		return nil, ErrUnknownRole.WithDetail(strings.Join(missing, ", "))
+	}
+	return roles, nil
+}
+
+// findPermissionsByName is findRolesByName for permissions.
+func findPermissionsByName(ctx context.Context, roleRepo repositories.RoleRepository, names []string) ([]role.Permissions, error) {
+	permissions, err := roleRepo.FindPermissionsByName(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		found[permission.Name] = true
+	}
+	if missing := missingNames(names, found); len(missing) > 0 {
+		return nil, ErrUnknownPermission.WithDetail(strings.Join(missing, ", "))
+	}
+	return permissions, nil
+}
+
+func missingNames(names []string, found map[string]bool) []string {
+	var missing []string
+	for _, name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// inheritsRole reports whether any of the roles is the named role or has it
+// as an ancestor in the catalogue.
+func inheritsRole(catalogue []role.Roles, roles []role.Roles, name string) bool {
+	byID := make(map[int]role.Roles, len(catalogue))
+	for _, roleEntity := range catalogue {
+		byID[roleEntity.ID] = roleEntity
+	}
+
+	for _, current := range roles {
+		seen := make(map[int]bool)
+		for {
+			if current.Name == name {
+				return true
+			}
+			if current.ParentID == nil || seen[current.ID] {
+				break
+			}
+			seen[current.ID] = true
+			parent, ok := byID[*current.ParentID]
+			if !ok {
+				break
+			}
+			current = parent
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+)
+
+func TestCreateRole_UnknownPermission(t *testing.T) {
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("FindRolesByName", mock.Anything, []string{"doctor"}).Return([]role.Roles{}, nil)
+	roleRepo.On("FindPermissionsByName", mock.Anything, []string{"record:read", "recrod:write"}).
+		Return([]role.Permissions{{ID: 1, Name: "record:read"}}, nil)
+
+	svc := NewRoleService(roleRepo, newAuditRepo(), logrus.New())
+	_, err := svc.CreateRole(context.Background(), role.CreateRoleRequest{
+		Name:        "doctor",
+		Permissions: []string{"record:read", "recrod:write"},
+	})
+
+	assert.ErrorIs(t, err, ErrUnknownPermission)
+	assert.Contains(t, err.Error(), "recrod:write")
+	roleRepo.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+}
+
+func TestUpdateRole_RejectsCycle(t *testing.T) {
+	userRoleID := 1
+	catalogue := []role.Roles{
+		{ID: 1, Name: "user"},
+		{ID: 2, Name: "admin", ParentID: &userRoleID},
+	}
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("FindRoleByID", mock.Anything, 1).Return(&catalogue[0], nil)
+	roleRepo.On("FindRolesByName", mock.Anything, []string{"admin"}).Return(catalogue[1:], nil)
+	roleRepo.On("ListRoles", mock.Anything).Return(catalogue, nil)
+
+	svc := NewRoleService(roleRepo, newAuditRepo(), logrus.New())
+	parent := "admin"
+	_, err := svc.UpdateRole(context.Background(), 1, role.UpdateRoleRequest{Parent: &parent})
+
+	assert.ErrorIs(t, err, ErrRoleCycle)
+	roleRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDeleteRole_BuiltIn(t *testing.T) {
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("FindRoleByID", mock.Anything, 2).Return(&role.Roles{ID: 2, Name: "admin"}, nil)
+
+	svc := NewRoleService(roleRepo, newAuditRepo(), logrus.New())
+	err := svc.DeleteRole(context.Background(), 2)
+
+	assert.ErrorIs(t, err, ErrBuiltInRole)
+	roleRepo.AssertNotCalled(t, "DeleteRole", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+var ErrSessionNotFound = utils.NewAppError("session_not_found", http.StatusNotFound, "Session not found")
+
+type SessionService interface {
+	List(ctx context.Context, claims *utils.JWTClaim) ([]session.SessionResponse, error)
+	Revoke(ctx context.Context, claims *utils.JWTClaim, id string) error
+}
+
+type SessionServiceImpl struct {
+	sessionRepo repositories.SessionRepository
+	audit       *auditor
+	log         *logrus.Logger
+}
+
+func NewSessionService(sessionRepo repositories.SessionRepository, auditRepo repositories.AuditRepository, log *logrus.Logger) SessionService {
+	return &SessionServiceImpl{
+		sessionRepo: sessionRepo,
+		audit:       &auditor{repo: auditRepo, log: log},
+		log:         log,
+	}
+}
+
+// List returns the signed-in devices of the user, most recently used first.
+func (s *SessionServiceImpl) List(ctx context.Context, claims *utils.JWTClaim) ([]session.SessionResponse, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(ctx, claims.UserID)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to list sessions: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	resp := make([]session.SessionResponse, 0, len(sessions))
+	for _, sessionEntity := range sessions {
+		resp = append(resp, session.EntityToResponse(sessionEntity, claims.SessionID))
+	}
+	return resp, nil
+}
+
+// Revoke signs a device out: its refresh token stops working and its access
+// tokens are denied until they expire.
+func (s *SessionServiceImpl) Revoke(ctx context.Context, claims *utils.JWTClaim, id string) error {
+	deleted, err := endSession(ctx, s.sessionRepo, claims.UserID, id)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to end session: ", err)
+		return utils.Internal(err)
+	}
+	if !deleted {
+		return ErrSessionNotFound
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"user_id": claims.UserID, "session_id": id}).Info("Session revoked")
+	s.audit.success(ctx, audit.ActionSessionRevoked, claims.UserID, map[string]interface{}{"session_id": id})
+	return nil
+}
+
+// endSession deletes a session of the user and denies the access tokens that
+// were issued to it. It reports false when the user has no such session.
+func endSession(ctx context.Context, sessionRepo repositories.SessionRepository, userID int, sessionID string) (bool, error) {
+	deleted, err := sessionRepo.Delete(ctx, userID, sessionID)
+	if err != nil || !deleted {
+		return deleted, err
+	}
+
+	return true, config.RevokeSession(sessionID, utils.AccessTokenTTL)
+}
+
+// endAllSessions deletes every session of the user and denies the tokens
+// issued to them so far, by issue time and by the sid of each deleted session.
+func endAllSessions(ctx context.Context, sessionRepo repositories.SessionRepository, userID int) error {
+	if err := config.RevokeUserTokens(userID, time.Now(), utils.RefreshTokenTTL); err != nil {
+		return err
+	}
+
+	sessionIDs, err := sessionRepo.DeleteByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, sessionID := range sessionIDs {
+		if err := config.RevokeSession(sessionID, utils.AccessTokenTTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
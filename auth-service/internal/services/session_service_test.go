@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+func TestSessionList_MarksCurrent(t *testing.T) {
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("FindActiveByUser", mock.Anything, 1).Return([]session.Sessions{
+		{ID: "sid-1", UserID: 1, DeviceName: "Chrome on Windows"},
+		{ID: "sid-2", UserID: 1, DeviceName: "Android app"},
+	}, nil)
+
+	svc := NewSessionService(sessionRepo, newAuditRepo(), logrus.New())
+	resp, err := svc.List(context.Background(), &utils.JWTClaim{UserID: 1, SessionID: "sid-2"})
+
+	require.NoError(t, err)
+	require.Len(t, resp, 2)
+	assert.False(t, resp[0].Current)
+	assert.True(t, resp[1].Current)
+	assert.Equal(t, "Android app", resp[1].DeviceName)
+}
+
+func TestSessionRevoke_DeniesAccessTokens(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "sid-2", nil, []string{"user"})
+	require.NoError(t, err)
+	claims, err := utils.ValidateJwtToken(accessToken)
+	require.NoError(t, err)
+
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Delete", mock.Anything, 1, "sid-2").Return(true, nil)
+	sessionRepo.On("Delete", mock.Anything, 1, "sid-3").Return(false, nil)
+
+	svc := NewSessionService(sessionRepo, newAuditRepo(), logrus.New())
+	require.NoError(t, svc.Revoke(context.Background(), &utils.JWTClaim{UserID: 1, SessionID: "sid-1"}, "sid-2"))
+
+	revoked, err := config.IsTokenRevoked(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	err = svc.Revoke(context.Background(), &utils.JWTClaim{UserID: 1, SessionID: "sid-1"}, "sid-3")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/token"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+type TokenService interface {
+	Introspect(ctx context.Context, req token.IntrospectionRequest) (*token.IntrospectionResponse, error)
+}
+
+type TokenServiceImpl struct {
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+	log         *logrus.Logger
+}
+
+func NewTokenService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, log *logrus.Logger) TokenService {
+	return &TokenServiceImpl{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		log:         log,
+	}
+}
+
+// Introspect reports whether a token is currently active (RFC 7662). Besides
+// the signature and expiry it checks the revocation denylist, that the user
+// is still active and, for refresh tokens, that the token is still the
+// current one of a live session.
+func (s *TokenServiceImpl) Introspect(ctx context.Context, req token.IntrospectionRequest) (*token.IntrospectionResponse, error) {
+	inactive := &token.IntrospectionResponse{Active: false}
+
+	claims, err := parseAnyToken(req.Token, req.TokenTypeHint)
+	if err != nil {
+		return inactive, nil
+	}
+
+	revoked, err := config.IsTokenRevoked(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to check token revocation: ", err)
+		return nil, utils.Internal(err)
+	}
+	if revoked {
+		return &token.IntrospectionResponse{Active: false, Revoked: true}, nil
+	}
+
+	userEntity, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return inactive, nil
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+	if !userEntity.IsActive || userEntity.DisabledAt != nil {
+		return inactive, nil
+	}
+
+	if claims.Type == utils.TokenTypeRefresh {
+		current, err := s.isCurrentRefreshToken(ctx, claims, req.Token)
+		if err != nil {
+			return nil, err
+		}
+		if !current {
+			return &token.IntrospectionResponse{Active: false, Revoked: true}, nil
+		}
+	}
+
+	return token.ClaimsToIntrospectionResponse(claims), nil
+}
+
+func (s *TokenServiceImpl) isCurrentRefreshToken(ctx context.Context, claims *utils.JWTClaim, tokenString string) (bool, error) {
+	if claims.SessionID == "" {
+		return false, nil
+	}
+
+	sessionEntity, err := s.sessionRepo.FindByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		requestLog(ctx, s.log).Error("Failed to find session: ", err)
+		return false, utils.Internal(err)
+	}
+
+	return sessionEntity.UserID == claims.UserID &&
+		subtle.ConstantTimeCompare([]byte(sessionEntity.RefreshToken), []byte(utils.HashToken(tokenString))) == 1, nil
+}
+
+// parseAnyToken validates the token as an access or refresh token, trying the
+// type given by the RFC 7662 token_type_hint first.
+func parseAnyToken(tokenString string, hint string) (*utils.JWTClaim, error) {
+	validators := []func(string) (*utils.JWTClaim, error){utils.ValidateJwtToken, utils.ValidateRefreshToken}
+	if hint == "refresh_token" {
+		validators[0], validators[1] = validators[1], validators[0]
+	}
+
+	var err error
+	for _, validate := range validators {
+		var claims *utils.JWTClaim
+		if claims, err = validate(tokenString); err == nil {
+			return claims, nil
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/token"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+func TestIntrospect_ActiveAccessToken(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "", []string{"user:read"}, []string{"user"})
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+
+	svc := NewTokenService(mockRepo, new(MockSessionRepo), logrus.New())
+	resp, err := svc.Introspect(context.Background(), token.IntrospectionRequest{Token: accessToken})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Active)
+	assert.Equal(t, "1", resp.Sub)
+	assert.Equal(t, []string{"user"}, resp.Role)
+	assert.Equal(t, []string{"user:read"}, resp.Permission)
+	assert.Equal(t, utils.TokenTypeAccess, resp.TokenType)
+	assert.NotZero(t, resp.Exp)
+}
+
+func TestIntrospect_RevokedToken(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+	claims, err := utils.ValidateJwtToken(accessToken)
+	assert.NoError(t, err)
+	assert.NoError(t, config.RevokeToken(claims.ID, utils.AccessTokenTTL))
+
+	mockRepo := new(MockUserRepo)
+	svc := NewTokenService(mockRepo, new(MockSessionRepo), logrus.New())
+	resp, err := svc.Introspect(context.Background(), token.IntrospectionRequest{Token: accessToken})
+
+	assert.NoError(t, err)
+	assert.False(t, resp.Active)
+	assert.True(t, resp.Revoked)
+	assert.Empty(t, resp.Sub)
+}
+
+func TestIntrospect_RotatedRefreshToken(t *testing.T) {
+	setupRedis(t)
+	_, oldToken, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+	_, currentToken, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("FindByID", mock.Anything, "sid-1").Return(newSession("sid-1", currentToken), nil)
+	svc := NewTokenService(mockRepo, sessionRepo, logrus.New())
+
+	resp, err := svc.Introspect(context.Background(), token.IntrospectionRequest{Token: oldToken, TokenTypeHint: "refresh_token"})
+	assert.NoError(t, err)
+	assert.False(t, resp.Active)
+
+	resp, err = svc.Introspect(context.Background(), token.IntrospectionRequest{Token: currentToken})
+	assert.NoError(t, err)
+	assert.True(t, resp.Active)
+	assert.Equal(t, utils.TokenTypeRefresh, resp.TokenType)
+	assert.Equal(t, "sid-1", resp.Sid)
+}
+
+func TestIntrospect_RevokedSession(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+	assert.NoError(t, config.RevokeSession("sid-1", utils.AccessTokenTTL))
+
+	svc := NewTokenService(new(MockUserRepo), new(MockSessionRepo), logrus.New())
+	resp, err := svc.Introspect(context.Background(), token.IntrospectionRequest{Token: accessToken})
+
+	assert.NoError(t, err)
+	assert.False(t, resp.Active)
+	assert.True(t, resp.Revoked)
+}
+
+func TestIntrospect_InvalidToken(t *testing.T) {
+	setupRedis(t)
+	svc := NewTokenService(new(MockUserRepo), new(MockSessionRepo), logrus.New())
+
+	resp, err := svc.Introspect(context.Background(), token.IntrospectionRequest{Token: "not-a-jwt"})
+	assert.NoError(t, err)
+	assert.False(t, resp.Active)
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanRecorder    = tracetest.NewSpanRecorder()
+	installRecorder sync.Once
+)
+
+// recordSpans installs the span recorder as the global provider. The package
+// tracer only binds to the first provider set, so it is installed once.
+func recordSpans() *tracetest.SpanRecorder {
+	installRecorder.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	return spanRecorder
+}
+
+func TestLoginWithEmail_TracesPasswordCheck(t *testing.T) {
+	recorder := recordSpans()
+	passwordHash, err := utils.HashPassword("123456")
+	require.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "trace@example.com").Return(&user.Users{
+		UserID: 3, Email: "trace@example.com", Password: passwordHash, IsActive: true,
+	}, nil)
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+
+	_, err = svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: "trace@example.com", Password: "wrong"})
+	require.Error(t, err)
+
+	var login, compare sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "UserService.LoginWithEmail":
+			login = span
+		case "password.compare":
+			compare = span
+		}
+	}
+	require.NotNil(t, login)
+	require.NotNil(t, compare)
+	assert.Equal(t, login.SpanContext().SpanID(), compare.Parent().SpanID())
+	assert.Contains(t, login.Attributes(), attribute.String("login.outcome", "bad_password"))
+	assert.Equal(t, "Error", login.Status().Code.String())
+	assert.Equal(t, "Unset", compare.Status().Code.String())
+}
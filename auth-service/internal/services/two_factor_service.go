@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	twofactor "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/two_factor"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	mfaMethodTOTP     = "totp"
+	mfaChallengeTTL   = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorUnavailable    = utils.NewAppError("two_factor_unavailable", http.StatusServiceUnavailable, "Two-factor authentication is not configured")
+	ErrTwoFactorAlreadyEnabled = utils.NewAppError("two_factor_already_enabled", http.StatusConflict, "Two-factor authentication already enabled")
+	ErrTwoFactorNotEnrolled    = utils.NewAppError("two_factor_not_enrolled", http.StatusBadRequest, "Two-factor authentication not enrolled")
+	ErrTwoFactorNotEnabled     = utils.NewAppError("two_factor_not_enabled", http.StatusBadRequest, "Two-factor authentication not enabled")
+	ErrInvalidTwoFactorCode    = utils.NewAppError("invalid_two_factor_code", http.StatusUnauthorized, "Invalid two-factor code")
+	ErrInvalidMFAChallenge     = utils.NewAppError("invalid_mfa_challenge", http.StatusUnauthorized, "Invalid or expired MFA challenge")
+)
+
+type TwoFactorService interface {
+	Enroll(ctx context.Context, claims *utils.JWTClaim) (*twofactor.EnrollResponse, error)
+	Confirm(ctx context.Context, claims *utils.JWTClaim, req twofactor.ConfirmRequest) (*twofactor.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, claims *utils.JWTClaim, req twofactor.DisableRequest) error
+	Verify(ctx context.Context, req twofactor.VerifyRequest) (*user.LoginResponse, error)
+}
+
+type TwoFactorServiceImpl struct {
+	userRepo      repositories.UserRepository
+	twoFactorRepo repositories.TwoFactorRepository
+	encryptionKey []byte
+	issuer        string
+	logins        *loginFlow
+	log           *logrus.Logger
+}
+
+// NewTwoFactorService encrypts TOTP secrets with encryptionKey (AES-256). A nil
+// key leaves 2FA unavailable.
+func NewTwoFactorService(
+	userRepo repositories.UserRepository,
+	sessionRepo repositories.SessionRepository,
+	roleRepo repositories.RoleRepository,
+	auditRepo repositories.AuditRepository,
+	twoFactorRepo repositories.TwoFactorRepository,
+	encryptionKey []byte,
+	issuer string,
+	log *logrus.Logger,
+) TwoFactorService {
+	return &TwoFactorServiceImpl{
+		userRepo:      userRepo,
+		twoFactorRepo: twoFactorRepo,
+		encryptionKey: encryptionKey,
+		issuer:        issuer,
+		logins:        &loginFlow{sessionRepo: sessionRepo, roleRepo: roleRepo, audit: &auditor{repo: auditRepo, log: log}, log: log},
+		log:           log,
+	}
+}
+
+// Enroll generates a new secret for the user. It is only used for login after
+// Confirm proves that the authenticator app was set up.
+func (s *TwoFactorServiceImpl) Enroll(ctx context.Context, claims *utils.JWTClaim) (*twofactor.EnrollResponse, error) {
+	if s.encryptionKey == nil {
+		return nil, ErrTwoFactorUnavailable
+	}
+
+	userEntity, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+	if userEntity.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret := utils.GenerateTOTPSecret()
+	encrypted, err := utils.EncryptSecret(s.encryptionKey, secret)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to encrypt totp secret: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	if err := s.twoFactorRepo.SaveTOTPSecret(ctx, userEntity.UserID, encrypted); err != nil {
+		requestLog(ctx, s.log).Error("Failed to save totp secret: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	return &twofactor.EnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.issuer, userEntity.Email, secret),
+	}, nil
+}
+
+// Confirm enables 2FA once the first code from the authenticator app is valid
+// and returns the recovery codes. They are shown only this once.
+func (s *TwoFactorServiceImpl) Confirm(ctx context.Context, claims *utils.JWTClaim, req twofactor.ConfirmRequest) (*twofactor.RecoveryCodesResponse, error) {
+	userEntity, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+	if userEntity.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if userEntity.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	ok, err := s.checkTOTP(ctx, userEntity, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code := generateRecoveryCode()
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashToken(code))
+	}
+
+	if err := s.twoFactorRepo.EnableTOTP(ctx, userEntity.UserID, hashes); err != nil {
+		requestLog(ctx, s.log).Error("Failed to enable totp: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithField("user_id", userEntity.UserID).Info("Two-factor authentication enabled")
+	return &twofactor.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns 2FA off after checking a current TOTP code or a recovery code.
+func (s *TwoFactorServiceImpl) Disable(ctx context.Context, claims *utils.JWTClaim, req twofactor.DisableRequest) error {
+	userEntity, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return utils.Internal(err)
+	}
+	if !userEntity.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := s.checkSecondFactor(ctx, userEntity, req.Code, req.Code); err != nil {
+		return err
+	}
+
+	if err := s.twoFactorRepo.DisableTOTP(ctx, userEntity.UserID); err != nil {
+		requestLog(ctx, s.log).Error("Failed to disable totp: ", err)
+		return utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithField("user_id", userEntity.UserID).Info("Two-factor authentication disabled")
+	return nil
+}
+
+// Verify finishes a login that returned a challenge token. The challenge is
+// single-use and is dropped after too many wrong codes.
+func (s *TwoFactorServiceImpl) Verify(ctx context.Context, req twofactor.VerifyRequest) (*user.LoginResponse, error) {
+	challengeHash := utils.HashToken(req.ChallengeToken)
+	userID, err := config.GetMFAChallenge(challengeHash)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		requestLog(ctx, s.log).Error("Failed to get mfa challenge: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	userEntity, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+	if !userEntity.IsActive || !userEntity.TOTPEnabled {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	if err := s.checkSecondFactor(ctx, userEntity, req.Code, req.RecoveryCode); err != nil {
+		s.logins.recordLogin(ctx, mfaMethodTOTP, "", userEntity, nil, err)
+		return nil, err
+	}
+
+	consumed, err := config.ConsumeMFAChallenge(challengeHash)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to consume mfa challenge: ", err)
+		return nil, utils.Internal(err)
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	resp, err := s.logins.issueTokens(ctx, userEntity)
+	s.logins.recordLogin(ctx, mfaMethodTOTP, "", userEntity, resp, err)
+	return resp, err
+}
+
+// checkSecondFactor accepts a valid TOTP code, or else an unused recovery code.
+func (s *TwoFactorServiceImpl) checkSecondFactor(ctx context.Context, userEntity *user.Users, code string, recoveryCode string) error {
+	if code != "" {
+		ok, err := s.checkTOTP(ctx, userEntity, code)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+
+	if recoveryCode != "" {
+		ok, err := s.twoFactorRepo.ConsumeRecoveryCode(ctx, userEntity.UserID, utils.HashToken(normalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			requestLog(ctx, s.log).Error("Failed to consume recovery code: ", err)
+			return utils.Internal(err)
+		}
+		if ok {
+			requestLog(ctx, s.log).WithField("user_id", userEntity.UserID).Warn("Recovery code used")
+			return nil
+		}
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+// checkTOTP validates code against the stored secret. A code is accepted only
+// once, even though it stays valid for the whole skew window.
+func (s *TwoFactorServiceImpl) checkTOTP(ctx context.Context, userEntity *user.Users, code string) (bool, error) {
+	if s.encryptionKey == nil {
+		return false, ErrTwoFactorUnavailable
+	}
+
+	secret, err := utils.DecryptSecret(s.encryptionKey, userEntity.TOTPSecret)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to decrypt totp secret: ", err)
+		return false, utils.Internal(err)
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	fresh, err := config.MarkTOTPStepUsed(userEntity.UserID, step, (2*utils.TOTPSkew+1)*utils.TOTPPeriod)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to mark totp code used: ", err)
+		return false, utils.Internal(err)
+	}
+	return fresh, nil
+}
+
+// generateRecoveryCode returns a code like "k3f9q-7zt2m".
+func generateRecoveryCode() string {
+	raw := strings.ToLower(utils.GenerateTOTPSecret()[:10])
+	return raw[:5] + "-" + raw[5:]
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	twofactor "github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/two_factor"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+type MockTwoFactorRepo struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorRepo) SaveTOTPSecret(ctx context.Context, userID int, encryptedSecret string) error {
+	args := m.Called(ctx, userID, encryptedSecret)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) DisableTOTP(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorRepo) ConsumeRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+var testEncryptionKey = make([]byte, 32)
+
+func TestTwoFactor_EnrollAndConfirm(t *testing.T) {
+	setupRedis(t)
+	userEntity := newActiveUser()
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(userEntity, nil)
+	mockTwoFactorRepo := new(MockTwoFactorRepo)
+	mockTwoFactorRepo.On("SaveTOTPSecret", mock.Anything, 1, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		userEntity.TOTPSecret = args.String(2)
+	})
+	mockTwoFactorRepo.On("EnableTOTP", mock.Anything, 1, mock.Anything).Return(nil)
+
+	svc := NewTwoFactorService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), mockTwoFactorRepo, testEncryptionKey, "HealthMate", logrus.New())
+	claims := &utils.JWTClaim{UserID: 1}
+
+	enrollment, err := svc.Enroll(context.Background(), claims)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.OTPAuthURI, "otpauth://totp/HealthMate:")
+	// Only the encrypted secret is stored.
+	assert.NotContains(t, userEntity.TOTPSecret, enrollment.Secret)
+
+	_, err = svc.Confirm(context.Background(), claims, twofactor.ConfirmRequest{Code: "000000"})
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	code, err := utils.TOTPCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+	resp, err := svc.Confirm(context.Background(), claims, twofactor.ConfirmRequest{Code: code})
+	require.NoError(t, err)
+	assert.Len(t, resp.RecoveryCodes, recoveryCodeCount)
+
+	hashes := mockTwoFactorRepo.Calls[1].Arguments.Get(2).([]string)
+	assert.Equal(t, utils.HashToken(resp.RecoveryCodes[0]), hashes[0])
+}
+
+func TestTwoFactor_EnrollUnavailableWithoutKey(t *testing.T) {
+	svc := NewTwoFactorService(new(MockUserRepo), new(MockSessionRepo), newRoleRepo(), newAuditRepo(), new(MockTwoFactorRepo), nil, "HealthMate", logrus.New())
+
+	_, err := svc.Enroll(context.Background(), &utils.JWTClaim{UserID: 1})
+	assert.ErrorIs(t, err, ErrTwoFactorUnavailable)
+}
+
+// newTwoFactorUser returns an active user with 2FA enabled and its plain secret.
+func newTwoFactorUser(t *testing.T) (*user.Users, string) {
+	passwordHash, err := utils.HashPassword("123456")
+	require.NoError(t, err)
+	secret := utils.GenerateTOTPSecret()
+	encrypted, err := utils.EncryptSecret(testEncryptionKey, secret)
+	require.NoError(t, err)
+
+	userEntity := newActiveUser()
+	userEntity.Password = passwordHash
+	userEntity.TOTPSecret = encrypted
+	userEntity.TOTPEnabled = true
+	return userEntity, secret
+}
+
+func TestTwoFactor_LoginRequiresChallenge(t *testing.T) {
+	setupRedis(t)
+	userEntity, secret := newTwoFactorUser(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, userEntity.Email).Return(userEntity, nil)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(userEntity, nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	users := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	login, err := users.LoginWithEmail(context.Background(), user.AuthRequest{Email: userEntity.Email, Password: "123456"})
+	require.NoError(t, err)
+	assert.True(t, login.MFARequired)
+	assert.Empty(t, login.AccessToken)
+	assert.NotEmpty(t, login.ChallengeToken)
+	sessionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	svc := NewTwoFactorService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), new(MockTwoFactorRepo), testEncryptionKey, "HealthMate", logrus.New())
+	code, err := utils.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+
+	resp, err := svc.Verify(context.Background(), twofactor.VerifyRequest{ChallengeToken: login.ChallengeToken, Code: code})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	assert.NotEmpty(t, resp.RefreshToken)
+
+	// The challenge is single-use.
+	_, err = svc.Verify(context.Background(), twofactor.VerifyRequest{ChallengeToken: login.ChallengeToken, Code: code})
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+func TestTwoFactor_VerifyRejectsReusedCode(t *testing.T) {
+	setupRedis(t)
+	userEntity, secret := newTwoFactorUser(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(userEntity, nil)
+	mockTwoFactorRepo := new(MockTwoFactorRepo)
+	mockTwoFactorRepo.On("ConsumeRecoveryCode", mock.Anything, 1, mock.Anything).Return(false, nil)
+
+	svc := NewTwoFactorService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), mockTwoFactorRepo, testEncryptionKey, "HealthMate", logrus.New())
+	code, err := utils.TOTPCode(secret, time.Now())
+	require.NoError(t, err)
+	step := utils.TOTPStep(time.Now())
+	_, err = config.MarkTOTPStepUsed(1, step, time.Minute)
+	require.NoError(t, err)
+
+	challenge := "challenge"
+	require.NoError(t, config.SaveMFAChallenge(utils.HashToken(challenge), 1, time.Minute))
+	_, err = svc.Verify(context.Background(), twofactor.VerifyRequest{ChallengeToken: challenge, Code: code})
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+}
+
+func TestTwoFactor_VerifyWithRecoveryCode(t *testing.T) {
+	setupRedis(t)
+	userEntity, _ := newTwoFactorUser(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(userEntity, nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	mockTwoFactorRepo := new(MockTwoFactorRepo)
+	mockTwoFactorRepo.On("ConsumeRecoveryCode", mock.Anything, 1, utils.HashToken("abcde-fghij")).Return(true, nil)
+
+	svc := NewTwoFactorService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), mockTwoFactorRepo, testEncryptionKey, "HealthMate", logrus.New())
+	challenge := "challenge"
+	require.NoError(t, config.SaveMFAChallenge(utils.HashToken(challenge), 1, time.Minute))
+
+	resp, err := svc.Verify(context.Background(), twofactor.VerifyRequest{ChallengeToken: challenge, RecoveryCode: " ABCDE-FGHIJ "})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+}
+
+func TestTwoFactor_ChallengeDroppedAfterTooManyAttempts(t *testing.T) {
+	setupRedis(t)
+	userEntity, _ := newTwoFactorUser(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(userEntity, nil)
+
+	svc := NewTwoFactorService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), new(MockTwoFactorRepo), testEncryptionKey, "HealthMate", logrus.New())
+	challenge := "challenge"
+	require.NoError(t, config.SaveMFAChallenge(utils.HashToken(challenge), 1, time.Minute))
+
+	for i := 0; i < 5; i++ {
+		_, err := svc.Verify(context.Background(), twofactor.VerifyRequest{ChallengeToken: challenge, Code: "000000"})
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	}
+	_, err := svc.Verify(context.Background(), twofactor.VerifyRequest{ChallengeToken: challenge, Code: "000000"})
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
@@ -2,68 +2,483 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/subtle"
 	"errors"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/metrics"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRole         = "user"
+	loginMethodPassword = "password"
+)
+
+var (
+	ErrEmailAlreadyRegistered = utils.NewAppError("email_already_registered", http.StatusConflict, "Email already registered")
+	ErrEmailNotVerified       = utils.NewAppError("email_not_verified", http.StatusForbidden, "Email not verified")
+	ErrInvalidOTP             = utils.NewAppError("invalid_otp", http.StatusBadRequest, "Invalid or expired OTP")
+	ErrInvalidRefreshToken    = utils.NewAppError("invalid_refresh_token", http.StatusUnauthorized, "Invalid refresh token")
+	ErrRefreshTokenReused     = utils.NewAppError("refresh_token_reused", http.StatusUnauthorized, "Refresh token reuse detected")
+	ErrAccountDisabled        = utils.NewAppError("account_disabled", http.StatusForbidden, "Account disabled")
+	ErrPasswordResetRequired  = utils.NewAppError("password_reset_required", http.StatusForbidden, "Password reset required")
+	// ErrInvalidCredentials covers both an unknown email and a wrong password
+	// so that the response does not reveal which accounts exist.
+	ErrInvalidCredentials = utils.NewAppError("invalid_credentials", http.StatusUnauthorized, "Invalid email or password")
 )
 
 type UserService interface {
 	LoginWithEmail(ctx context.Context, req user.AuthRequest) (*user.LoginResponse, error)
+	Register(ctx context.Context, req user.RegisterRequest) (*user.RegisterResponse, error)
+	VerifyEmail(ctx context.Context, req user.VerifyEmailRequest) error
+	RefreshToken(ctx context.Context, req user.RefreshTokenRequest) (*user.LoginResponse, error)
+	Logout(ctx context.Context, claims *utils.JWTClaim) error
+	LogoutAll(ctx context.Context, claims *utils.JWTClaim) error
+	ForgotPassword(ctx context.Context, req user.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req user.ResetPasswordRequest) error
+	UnlockAccount(ctx context.Context, req user.UnlockAccountRequest) error
 }
 
 type UserServiceImpl struct {
-	userRepo repositories.UserRepository
-	log      *logrus.Logger
+	userRepo    repositories.UserRepository
+	sessionRepo repositories.SessionRepository
+	roleRepo    repositories.RoleRepository
+	guard       *loginGuard
+	logins      *loginFlow
+	audit       *auditor
+	log         *logrus.Logger
 }
 
-func NewUserService(userRepo repositories.UserRepository, log *logrus.Logger) UserService {
+func NewUserService(userRepo repositories.UserRepository, sessionRepo repositories.SessionRepository, roleRepo repositories.RoleRepository, auditRepo repositories.AuditRepository, lockout config.LoginLockout, log *logrus.Logger) UserService {
+	audit := &auditor{repo: auditRepo, log: log}
 	return &UserServiceImpl{
 		log: 	log,
 		userRepo: userRepo,
+		sessionRepo: sessionRepo,
+		roleRepo: roleRepo,
+		guard:    &loginGuard{policy: lockout, log: log},
+		logins:   &loginFlow{sessionRepo: sessionRepo, roleRepo: roleRepo, audit: audit, log: log},
+		audit:    audit,
 	}
 }
 
 func(s *UserServiceImpl) LoginWithEmail(ctx context.Context, req user.AuthRequest) (*user.LoginResponse, error){
-	userEntity, err := s.userRepo.Login(ctx, req.Email)
+	ctx, span := tracer.Start(ctx, "UserService.LoginWithEmail")
+	userEntity, err := s.checkPassword(ctx, req)
 	if err != nil {
-		s.log.Error("Failed to login user: ", err)
+		s.logins.recordLogin(ctx, loginMethodPassword, req.Email, userEntity, nil, err)
+		span.SetAttributes(attribute.String("login.outcome", loginOutcome(nil, err)))
+		endSpan(span, err)
 		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(userEntity.Password), []byte(req.Password)); err != nil {
-		s.log.Error("Password mismatch: ", err)
+	resp, err := s.logins.completeLogin(ctx, userEntity)
+	s.logins.recordLogin(ctx, loginMethodPassword, req.Email, userEntity, resp, err)
+	span.SetAttributes(attribute.String("login.outcome", loginOutcome(resp, err)))
+	endSpan(span, err)
+	return resp, err
+}
+
+// checkPassword returns the user once the password matches and the account
+// may log in. When the account exists but may not log in, the user is
+// returned along with the error so that the attempt can be audited.
+func (s *UserServiceImpl) checkPassword(ctx context.Context, req user.AuthRequest) (*user.Users, error) {
+	ip := utils.RequestMetaFromContext(ctx).IP
+	if err := s.guard.check(ctx, req.Email, ip); err != nil {
+		requestLog(ctx, s.log).Warn("Login blocked: ", err)
 		return nil, err
 	}
 
-	var roles []string
-	if err := json.Unmarshal(userEntity.Role, &roles); err != nil {
-		s.log.Errorf("Failed to convert roles: %v", err)
-		return nil, errors.New("invalid roles format")
+	userEntity, err := s.userRepo.Login(ctx, req.Email)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to login user: ", err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.guard.recordFailure(ctx, req.Email, ip)
+			return nil, ErrInvalidCredentials.Wrap(err)
+		}
+		return nil, utils.Internal(err)
+	}
+
+	rehash, err := comparePassword(ctx, userEntity.Password, req.Password)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Password mismatch: ", err)
+		if !errors.Is(err, utils.ErrPasswordMismatch) {
+			return userEntity, utils.Internal(err)
+		}
+		s.guard.recordFailure(ctx, req.Email, ip)
+		return userEntity, ErrInvalidCredentials.Wrap(err)
+	}
+	s.guard.reset(ctx, req.Email, ip)
+
+	if err := s.logins.checkAllowed(ctx, userEntity); err != nil {
+		return userEntity, err
+	}
+	if rehash {
+		s.rehashPassword(ctx, userEntity, req.Password)
+	}
+
+	return userEntity, nil
+}
+
+// rehashPassword upgrades a hash made with an outdated algorithm or outdated
+// parameters, now that the password is known. Failing to do so does not
+// fail the login; it is tried again on the next one.
+func (s *UserServiceImpl) rehashPassword(ctx context.Context, userEntity *user.Users, password string) {
+	passwordHash, err := hashPassword(ctx, password)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Failed to rehash password: ", err)
+		return
+	}
+	if err := s.userRepo.RehashPassword(ctx, userEntity.UserID, userEntity.Password, passwordHash); err != nil {
+		requestLog(ctx, s.log).Warn("Failed to rehash password: ", err)
+		return
 	}
+	userEntity.Password = passwordHash
+}
+
+// loginFailureReason names the reason a login failed in the audit log.
+// Unexpected errors are reported as "internal" so that database or driver
+// messages never end up in the audit metadata.
+func loginFailureReason(err error) string {
+	var locked *LoginLockedError
+	switch {
+	case errors.As(err, &locked):
+		return "locked"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "unknown_email"
+	case errors.Is(err, utils.ErrPasswordMismatch):
+		return "bad_password"
+	case errors.Is(err, ErrEmailNotVerified):
+		return "unverified"
+	case errors.Is(err, ErrAccountDisabled):
+		return "disabled"
+	case errors.Is(err, ErrPasswordResetRequired):
+		return "password_reset_required"
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		return "bad_second_factor"
+	case errors.Is(err, ErrPasskeyVerificationFailed), errors.Is(err, ErrPasskeyCloned):
+		return "bad_passkey"
+	default:
+		return "internal"
+	}
+}
+
+// loginOutcome names the outcome of a login attempt in metrics.
+func loginOutcome(resp *user.LoginResponse, err error) string {
+	switch {
+	case err == nil && resp.MFARequired:
+		return metrics.LoginMFARequired
+	case err == nil:
+		return metrics.LoginSuccess
+	}
+
+	switch reason := loginFailureReason(err); reason {
+	case "unknown_email":
+		return metrics.LoginNotFound
+	case metrics.LoginLocked, metrics.LoginBadPassword, metrics.LoginUnverified, metrics.LoginDisabled,
+		metrics.LoginPasswordResetRequired, metrics.LoginBadSecondFactor, metrics.LoginBadPasskey:
+		return reason
+	default:
+		return metrics.LoginError
+	}
+}
 
-	var permissions []string
-	if err := json.Unmarshal(userEntity.Permission, &permissions); err != nil {
-		s.log.Error("Failed to convert permissions: ", err)
+// RefreshToken exchanges a refresh token for a new token pair and rotates the
+// refresh token of its session. Presenting a token that was already rotated
+// ends the session, so both the attacker and the victim must log in again on
+// that device.
+func (s *UserServiceImpl) RefreshToken(ctx context.Context, req user.RefreshTokenRequest) (*user.LoginResponse, error) {
+	claims, err := utils.ValidateRefreshToken(req.RefreshToken)
+	if err != nil {
+		requestLog(ctx, s.log).Warn("Invalid refresh token: ", err)
+		return nil, ErrInvalidRefreshToken
+	}
+	if claims.SessionID == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := config.IsTokenRevoked(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to check token revocation: ", err)
+		return nil, utils.Internal(err)
+	}
+	if revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	sessionEntity, err := s.sessionRepo.FindByID(ctx, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		requestLog(ctx, s.log).Error("Failed to find session: ", err)
+		return nil, utils.Internal(err)
+	}
+	if sessionEntity.UserID != claims.UserID || !sessionEntity.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	userEntity, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+
+	if !userEntity.IsActive || userEntity.DisabledAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	presentedHash := utils.HashToken(req.RefreshToken)
+	if subtle.ConstantTimeCompare([]byte(sessionEntity.RefreshToken), []byte(presentedHash)) != 1 {
+		s.revokeReusedSession(ctx, sessionEntity)
+		return nil, ErrRefreshTokenReused
+	}
+
+	resp, err := s.logins.generateTokens(ctx, userEntity, sessionEntity.ID)
+	if err != nil {
 		return nil, err
 	}
 
-	accessToken, refreshToken, err := utils.GenerateJwtToken(userEntity.UserID, roles, permissions)
+	meta := utils.RequestMetaFromContext(ctx)
+	now := time.Now()
+	rotated, err := s.sessionRepo.Rotate(ctx, sessionEntity.ID, presentedHash, &session.Sessions{
+		RefreshToken: utils.HashToken(resp.RefreshToken),
+		IPAddress:    meta.IP,
+		UserAgent:    meta.UserAgent,
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(utils.RefreshTokenTTL),
+	})
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to rotate refresh token: ", err)
+		return nil, utils.Internal(err)
+	}
+	if !rotated {
+		s.revokeReusedSession(ctx, sessionEntity)
+		return nil, ErrRefreshTokenReused
+	}
+
+	return resp, nil
+}
+
+// Logout revokes the presented access token and ends its session.
+func (s *UserServiceImpl) Logout(ctx context.Context, claims *utils.JWTClaim) error {
+	if err := config.RevokeToken(claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+		requestLog(ctx, s.log).Error("Failed to revoke access token: ", err)
+		return utils.Internal(err)
+	}
+
+	if claims.SessionID != "" {
+		if _, err := endSession(ctx, s.sessionRepo, claims.UserID, claims.SessionID); err != nil {
+			requestLog(ctx, s.log).Error("Failed to end session: ", err)
+			return utils.Internal(err)
+		}
+	}
+
+	s.audit.success(ctx, audit.ActionLogout, claims.UserID, map[string]interface{}{"session_id": claims.SessionID})
+	return nil
+}
+
+// LogoutAll revokes every token issued to the user so far and ends all of
+// their sessions.
+func (s *UserServiceImpl) LogoutAll(ctx context.Context, claims *utils.JWTClaim) error {
+	if err := endAllSessions(ctx, s.sessionRepo, claims.UserID); err != nil {
+		requestLog(ctx, s.log).Error("Failed to end sessions: ", err)
+		return utils.Internal(err)
+	}
+
+	s.audit.success(ctx, audit.ActionLogoutAll, claims.UserID, nil)
+	return nil
+}
+
+func (s *UserServiceImpl) revokeReusedSession(ctx context.Context, sessionEntity *session.Sessions) {
+	requestLog(ctx, s.log).WithFields(logrus.Fields{
+		"user_id":    sessionEntity.UserID,
+		"session_id": sessionEntity.ID,
+	}).Warn("Refresh token reuse detected, ending session")
+	s.audit.failure(ctx, audit.ActionTokenRefresh, sessionEntity.UserID, "reuse_detected",
+		map[string]interface{}{"session_id": sessionEntity.ID})
+	if _, err := endSession(ctx, s.sessionRepo, sessionEntity.UserID, sessionEntity.ID); err != nil {
+		requestLog(ctx, s.log).Error("Failed to end session: ", err)
+	}
+}
+
+// defaultRoles returns the roles every new account starts with.
+func defaultRoles(ctx context.Context, roleRepo repositories.RoleRepository, log *logrus.Logger) ([]role.Roles, error) {
+	roles, err := roleRepo.FindRolesByName(ctx, []string{defaultRole})
 	if err != nil {
-		s.log.Error("Failed to generate JWT tokens: ", err)
+		requestLog(ctx, log).Error("Failed to find default role: ", err)
+		return nil, utils.Internal(err)
+	}
+	if len(roles) == 0 {
+		requestLog(ctx, log).Errorf("Default role %q is missing from the role catalogue", defaultRole)
+		return nil, fmt.Errorf("default role %q not found", defaultRole)
+	}
+	return roles, nil
+}
+
+// Register creates an inactive account and emails it a verification OTP.
+// Registering again with an unverified email only resends the code: nothing
+// proves the caller owns the address, so the stored password is kept.
+func (s *UserServiceImpl) Register(ctx context.Context, req user.RegisterRequest) (*user.RegisterResponse, error) {
+	existing, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return nil, utils.Internal(err)
+	}
+	if existing != nil && existing.IsActive {
+		return nil, ErrEmailAlreadyRegistered
+	}
+
+	userEntity := existing
+	if userEntity == nil {
+		passwordHash, err := hashPassword(ctx, req.Password)
+		if err != nil {
+			requestLog(ctx, s.log).Error("Failed to hash password: ", err)
+			return nil, utils.Internal(err)
+		}
+
+		roles, err := defaultRoles(ctx, s.roleRepo, s.log)
+		if err != nil {
+			return nil, err
+		}
+
+		userEntity = user.UsersToEntity(req)
+		userEntity.Password = passwordHash
+		userEntity.IsActive = false
+		userEntity.Roles = roles
+		if err := s.userRepo.Create(ctx, userEntity); err != nil {
+			requestLog(ctx, s.log).Error("Failed to create user: ", err)
+			return nil, utils.Internal(err)
+		}
+	}
+
+	if err := sendOTP(ctx, s.log, config.OTPVerifyEmail, userEntity.Email, "HealthMate email verification",
+		"Your HealthMate verification code is %s. It expires in 5 minutes."); err != nil {
 		return nil, err
 	}
 
-	return &user.LoginResponse{
-		UserID: 	userEntity.UserID,
-		Email:   	userEntity.Email,
-		Role:   	roles,
-		Permission: permissions,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
-}
\ No newline at end of file
+	return user.UsersToRegisterResponse(userEntity), nil
+}
+
+func (s *UserServiceImpl) VerifyEmail(ctx context.Context, req user.VerifyEmailRequest) error {
+	valid, err := verifyOTP(ctx, config.OTPVerifyEmail, req.Email, req.OTP)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to verify OTP: ", err)
+		return utils.Internal(err)
+	}
+	if !valid {
+		return ErrInvalidOTP
+	}
+
+	userEntity, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidOTP
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return utils.Internal(err)
+	}
+
+	if !userEntity.IsActive {
+		if err := s.userRepo.Activate(ctx, userEntity.UserID); err != nil {
+			requestLog(ctx, s.log).Error("Failed to activate user: ", err)
+			return utils.Internal(err)
+		}
+	}
+
+	return nil
+}
+
+// ForgotPassword emails a password reset OTP. It behaves the same whether or
+// not the email is registered so that accounts cannot be enumerated.
+func (s *UserServiceImpl) ForgotPassword(ctx context.Context, req user.ForgotPasswordRequest) error {
+	userEntity, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.audit.failure(ctx, audit.ActionPasswordForgot, 0, "unknown_email", map[string]interface{}{"email": req.Email})
+			return nil
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return utils.Internal(err)
+	}
+
+	s.audit.success(ctx, audit.ActionPasswordForgot, userEntity.UserID, nil)
+	go func(ctx context.Context, email string) {
+		if err := sendOTP(ctx, s.log, config.OTPResetPassword, email, "HealthMate password reset",
+			"Your HealthMate password reset code is %s. It expires in 5 minutes."); err != nil {
+			requestLog(ctx, s.log).Error("Failed to send password reset OTP: ", err)
+		}
+	}(context.WithoutCancel(ctx), userEntity.Email)
+
+	return nil
+}
+
+// ResetPassword sets a new password after checking the reset OTP, then ends
+// every session and revokes every token issued to the user.
+func (s *UserServiceImpl) ResetPassword(ctx context.Context, req user.ResetPasswordRequest) error {
+	valid, err := verifyOTP(ctx, config.OTPResetPassword, req.Email, req.OTP)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to verify OTP: ", err)
+		return utils.Internal(err)
+	}
+	if !valid {
+		s.audit.failure(ctx, audit.ActionPasswordReset, 0, "invalid_otp", map[string]interface{}{"email": req.Email})
+		return ErrInvalidOTP
+	}
+
+	userEntity, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidOTP
+		}
+		requestLog(ctx, s.log).Error("Failed to find user: ", err)
+		return utils.Internal(err)
+	}
+
+	passwordHash, err := hashPassword(ctx, req.NewPassword)
+	if err != nil {
+		requestLog(ctx, s.log).Error("Failed to hash password: ", err)
+		return utils.Internal(err)
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, userEntity.UserID, passwordHash); err != nil {
+		requestLog(ctx, s.log).Error("Failed to update password: ", err)
+		return utils.Internal(err)
+	}
+
+	if err := endAllSessions(ctx, s.sessionRepo, userEntity.UserID); err != nil {
+		requestLog(ctx, s.log).Error("Failed to end sessions: ", err)
+		return utils.Internal(err)
+	}
+
+	s.audit.success(ctx, audit.ActionPasswordReset, userEntity.UserID, nil)
+	return nil
+}
+
+// UnlockAccount clears the lockout and failure counters of an email, and of
+// an IP when one is given.
+func (s *UserServiceImpl) UnlockAccount(ctx context.Context, req user.UnlockAccountRequest) error {
+	if err := s.guard.unlock(ctx, req.Email, req.IP); err != nil {
+		requestLog(ctx, s.log).Error("Failed to unlock account: ", err)
+		return utils.Internal(err)
+	}
+
+	requestLog(ctx, s.log).WithFields(logrus.Fields{"email": req.Email, "ip": req.IP}).Info("Login lockout cleared by admin")
+	s.audit.success(ctx, audit.ActionLoginUnlocked, 0, map[string]interface{}{"email": req.Email, "ip": req.IP})
+	return nil
+}
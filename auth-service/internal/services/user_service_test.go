@@ -2,14 +2,21 @@ package services
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/audit"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/session"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
 	"golang.org/x/crypto/bcrypt"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -25,6 +32,245 @@ func(m *MockUserRepo) Login(ctx context.Context, email string) (*user.Users, err
 	return args.Get(0).(*user.Users), args.Error(1)
 }
 
+func (m *MockUserRepo) FindByEmail(ctx context.Context, email string) (*user.Users, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Users), args.Error(1)
+}
+
+func (m *MockUserRepo) Create(ctx context.Context, userEntity *user.Users) error {
+	args := m.Called(ctx, userEntity)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) RehashPassword(ctx context.Context, userID int, oldHash string, newHash string) error {
+	args := m.Called(ctx, userID, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) Activate(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) FindByID(ctx context.Context, userID int) (*user.Users, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*user.Users), args.Error(1)
+}
+
+func (m *MockUserRepo) List(ctx context.Context, query user.ListUsersRequest) ([]user.Users, int64, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]user.Users), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepo) UpdateEmail(ctx context.Context, userID int, email string) error {
+	args := m.Called(ctx, userID, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) SetDisabled(ctx context.Context, userID int, disabledAt *time.Time) error {
+	args := m.Called(ctx, userID, disabledAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) RequirePasswordReset(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type MockSessionRepo struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepo) Create(ctx context.Context, sessionEntity *session.Sessions) error {
+	args := m.Called(ctx, sessionEntity)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) FindByID(ctx context.Context, id string) (*session.Sessions, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*session.Sessions), args.Error(1)
+}
+
+func (m *MockSessionRepo) FindActiveByUser(ctx context.Context, userID int) ([]session.Sessions, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]session.Sessions), args.Error(1)
+}
+
+func (m *MockSessionRepo) Rotate(ctx context.Context, id string, oldToken string, rotated *session.Sessions) (bool, error) {
+	args := m.Called(ctx, id, oldToken, rotated)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepo) Delete(ctx context.Context, userID int, id string) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepo) DeleteByUser(ctx context.Context, userID int) ([]string, error) {
+	args := m.Called(ctx, userID)
+	sessionIDs, _ := args.Get(0).([]string)
+	return sessionIDs, args.Error(1)
+}
+
+type MockRoleRepo struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepo) EffectiveAccess(ctx context.Context, userID int) (*role.Access, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*role.Access), args.Error(1)
+}
+
+func (m *MockRoleRepo) FindUserRoles(ctx context.Context, userID int) ([]role.Roles, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]role.Roles), args.Error(1)
+}
+
+func (m *MockRoleRepo) SetUserRoles(ctx context.Context, userID int, roleIDs []int) error {
+	args := m.Called(ctx, userID, roleIDs)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) ListRoles(ctx context.Context) ([]role.Roles, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]role.Roles), args.Error(1)
+}
+
+func (m *MockRoleRepo) FindRoleByID(ctx context.Context, id int) (*role.Roles, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*role.Roles), args.Error(1)
+}
+
+func (m *MockRoleRepo) FindRolesByName(ctx context.Context, names []string) ([]role.Roles, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]role.Roles), args.Error(1)
+}
+
+func (m *MockRoleRepo) CreateRole(ctx context.Context, roleEntity *role.Roles) error {
+	args := m.Called(ctx, roleEntity)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) UpdateRole(ctx context.Context, id int, description string, parentID *int) error {
+	args := m.Called(ctx, id, description, parentID)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) DeleteRole(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRoleRepo) SetRolePermissions(ctx context.Context, roleID int, permissionIDs []int) error {
+	args := m.Called(ctx, roleID, permissionIDs)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) ListPermissions(ctx context.Context) ([]role.Permissions, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]role.Permissions), args.Error(1)
+}
+
+func (m *MockRoleRepo) FindPermissionsByName(ctx context.Context, names []string) ([]role.Permissions, error) {
+	args := m.Called(ctx, names)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]role.Permissions), args.Error(1)
+}
+
+func (m *MockRoleRepo) CreatePermission(ctx context.Context, permission *role.Permissions) error {
+	args := m.Called(ctx, permission)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepo) DeletePermission(ctx context.Context, id int) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// newRoleRepo returns a role repository in which the default role exists and
+// every user has just that role.
+func newRoleRepo() *MockRoleRepo {
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("EffectiveAccess", mock.Anything, mock.Anything).
+		Return(&role.Access{Roles: []string{"user"}, Permissions: []string{}}, nil).Maybe()
+	roleRepo.On("FindRolesByName", mock.Anything, []string{"user"}).
+		Return([]role.Roles{{ID: 1, Name: "user"}}, nil).Maybe()
+	roleRepo.On("FindUserRoles", mock.Anything, mock.Anything).
+		Return([]role.Roles{{ID: 1, Name: "user"}}, nil).Maybe()
+	return roleRepo
+}
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) Create(ctx context.Context, event *audit.AuditEvents) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepo) List(ctx context.Context, query audit.ListEventsRequest) ([]audit.AuditEvents, int64, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]audit.AuditEvents), args.Get(1).(int64), args.Error(2)
+}
+
+// newAuditRepo returns an audit repository that accepts every event.
+func newAuditRepo() *MockAuditRepo {
+	auditRepo := new(MockAuditRepo)
+	auditRepo.On("Create", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return auditRepo
+}
+
+func setupRedis(t *testing.T) *miniredis.Miniredis {
+	mr := miniredis.RunT(t)
+	config.InitRedisServer(&config.Config{RedisHost: mr.Host(), RedisPort: mr.Port()})
+	config.InitMailer(&config.Config{}, logrus.New())
+	return mr
+}
+
 func TestLoginWithEmailSuccess(t *testing.T) {
 	// Setup mock repository and logger
 	mockRepo := new(MockUserRepo)
@@ -36,23 +282,31 @@ func TestLoginWithEmailSuccess(t *testing.T) {
 		Password: "password",
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	passwordHash, err := utils.HashPassword(req.Password)
 	assert.NoError(t, err)
 
 	mockUser := &user.Users{
 		UserID:   1,
 		Email:    req.Email,
-		Password: string(passwordHash),
-		Role:     datatypes.JSON([]byte(`["admin"]`)),
-		Permission: datatypes.JSON([]byte(`["read"]`)),
+		Password: passwordHash,
+		IsActive: true,
 	}
 
 	// Setup mock behavior
 	mockRepo.On("Login", mock.Anything, req.Email).Return(mockUser, nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	roleRepo := new(MockRoleRepo)
+	roleRepo.On("EffectiveAccess", mock.Anything, 1).
+		Return(&role.Access{Roles: []string{"admin", "user"}, Permissions: []string{"read"}}, nil)
 
 	// Create service and call method
-	svc := NewUserService(mockRepo, log)
-	resp, err := svc.LoginWithEmail(context.Background(), req)
+	svc := NewUserService(mockRepo, sessionRepo, roleRepo, newAuditRepo(), config.LoginLockout{}, log)
+	ctx := utils.WithRequestMeta(context.Background(), utils.RequestMeta{
+		IP:        "10.0.0.1",
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0 Safari/537.36",
+	})
+	resp, err := svc.LoginWithEmail(ctx, req)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -60,16 +314,70 @@ func TestLoginWithEmailSuccess(t *testing.T) {
 	if resp != nil {
 		assert.Equal(t, req.Email, resp.Email)
 		assert.NotEmpty(t, resp.AccessToken)
+		assert.Equal(t, []string{"admin", "user"}, resp.Role)
+		assert.Equal(t, []string{"read"}, resp.Permission)
+
+		created := sessionRepo.Calls[0].Arguments.Get(1).(*session.Sessions)
+		assert.Equal(t, 1, created.UserID)
+		assert.Equal(t, utils.HashToken(resp.RefreshToken), created.RefreshToken)
+		assert.Equal(t, "Chrome on Windows", created.DeviceName)
+		assert.Equal(t, "10.0.0.1", created.IPAddress)
+
+		claims, err := utils.ValidateJwtToken(resp.AccessToken)
+		assert.NoError(t, err)
+		assert.Equal(t, created.ID, claims.SessionID)
 	}
 }
 
+func TestLoginWithEmail_RehashesLegacyHash(t *testing.T) {
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(&user.Users{
+		UserID: 1, Email: "test@example.com", Password: string(legacyHash), IsActive: true,
+	}, nil)
+	mockRepo.On("RehashPassword", mock.Anything, 1, string(legacyHash), mock.Anything).Return(nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	svc := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	_, err = svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: "test@example.com", Password: "123456"})
+	assert.NoError(t, err)
+
+	newHash := mockRepo.Calls[1].Arguments.String(3)
+	assert.True(t, strings.HasPrefix(newHash, "$argon2id$"))
+	rehash, err := utils.VerifyPassword(newHash, "123456")
+	assert.NoError(t, err)
+	assert.False(t, rehash)
+}
+
+func TestLoginWithEmail_RehashFailureDoesNotFailLogin(t *testing.T) {
+	legacyHash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(&user.Users{
+		UserID: 1, Email: "test@example.com", Password: string(legacyHash), IsActive: true,
+	}, nil)
+	mockRepo.On("RehashPassword", mock.Anything, 1, string(legacyHash), mock.Anything).Return(assert.AnError)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	svc := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: "test@example.com", Password: "123456"})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+}
+
 func TestLoginWithEmail_WrongPassword(t *testing.T){
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte("wrongpassword"), bcrypt.DefaultCost)
+	passwordHash, err := utils.HashPassword("wrongpassword")
 	assert.NoError(t, err)
 	mockUser := &user.Users{
 		UserID: 1,
 		Email: "test@example.com",
-		Password: string(passwordHash),
+		Password: passwordHash,
 	}
 
 	
@@ -78,13 +386,14 @@ func TestLoginWithEmail_WrongPassword(t *testing.T){
 	mockRepo.On("Login", mock.Anything, mockUser.Email).Return(mockUser, nil)
 
 	// Create service and call method
-	svc := NewUserService(mockRepo, log)
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, log)
 
 	req := user.AuthRequest{Email: "test@example.com", Password: "wrongpass"}
 	resp, err := svc.LoginWithEmail(context.Background(), req)
 
 	// Assertions
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.ErrorIs(t, err, utils.ErrPasswordMismatch)
 	assert.Nil(t, resp)
 }
 
@@ -92,23 +401,458 @@ func TestLoginWithEmail_UserNotFound(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockRepo.On("Login", mock.Anything, "notfound@example.com").Return(nil, gorm.ErrRecordNotFound)
 
-	svc := NewUserService(mockRepo, logrus.New())
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
 	req := user.AuthRequest{Email: "notfound@example.com", Password: "123456"}
 	resp, err := svc.LoginWithEmail(context.Background(), req)
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
 	assert.Nil(t, resp)
 }
 
+func TestLoginWithEmail_RepositoryErrorIsInternal(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(nil, assert.AnError)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	_, err := svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: "test@example.com", Password: "123456"})
+
+	assert.ErrorIs(t, err, utils.ErrInternal)
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
 func TestLoginWithEmail_GenerateJwtError(t *testing.T) {
 	mockRepo := new(MockUserRepo)
 	mockRepo.On("Login", mock.Anything, "test@example.com").Return(&user.Users{}, nil)
 	log := logrus.New()
-	svc := NewUserService(mockRepo, log)
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, log)
 
 	req := user.AuthRequest{Email: "test@example.com", Password: "123456"}
 	resp, err := svc.LoginWithEmail(context.Background(), req)
 
 	assert.Error(t, err)
 	assert.Nil(t, resp)
-}
\ No newline at end of file
+}
+
+func TestLoginWithEmail_NotVerified(t *testing.T) {
+	passwordHash, err := utils.HashPassword("123456")
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(&user.Users{
+		UserID:   1,
+		Email:    "test@example.com",
+		Password: passwordHash,
+		IsActive: false,
+	}, nil)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: "test@example.com", Password: "123456"})
+
+	assert.ErrorIs(t, err, ErrEmailNotVerified)
+	assert.Nil(t, resp)
+}
+
+func TestLoginWithEmail_DisabledOrResetRequired(t *testing.T) {
+	passwordHash, err := utils.HashPassword("123456")
+	assert.NoError(t, err)
+	disabledAt := time.Now()
+
+	disabled := newActiveUser()
+	disabled.Password = passwordHash
+	disabled.DisabledAt = &disabledAt
+	resetRequired := newActiveUser()
+	resetRequired.Email = "reset@example.com"
+	resetRequired.Password = passwordHash
+	resetRequired.PasswordResetRequired = true
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, disabled.Email).Return(disabled, nil)
+	mockRepo.On("Login", mock.Anything, resetRequired.Email).Return(resetRequired, nil)
+	sessionRepo := new(MockSessionRepo)
+
+	svc := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	_, err = svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: disabled.Email, Password: "123456"})
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+
+	_, err = svc.LoginWithEmail(context.Background(), user.AuthRequest{Email: resetRequired.Email, Password: "123456"})
+	assert.ErrorIs(t, err, ErrPasswordResetRequired)
+	sessionRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRegister_Success(t *testing.T) {
+	mr := setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "new@example.com").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*user.Users")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*user.Users).UserID = 7
+		}).
+		Return(nil)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.Register(context.Background(), user.RegisterRequest{Email: "new@example.com", Password: "Passw0rd123"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, resp.UserID)
+
+	created := mockRepo.Calls[1].Arguments.Get(1).(*user.Users)
+	assert.False(t, created.IsActive)
+	_, err = utils.VerifyPassword(created.Password, "Passw0rd123")
+	assert.NoError(t, err)
+
+	otp, err := mr.Get("otp:verify_email:new@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, otp, 6)
+}
+
+func TestRegister_EmailAlreadyRegistered(t *testing.T) {
+	setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&user.Users{UserID: 1, IsActive: true}, nil)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.Register(context.Background(), user.RegisterRequest{Email: "test@example.com", Password: "Passw0rd123"})
+
+	assert.ErrorIs(t, err, ErrEmailAlreadyRegistered)
+	assert.Nil(t, resp)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRegister_UnverifiedEmailKeepsPassword(t *testing.T) {
+	mr := setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "pending@example.com").
+		Return(&user.Users{UserID: 3, Email: "pending@example.com", Password: "stored-hash", IsActive: false}, nil)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.Register(context.Background(), user.RegisterRequest{Email: "pending@example.com", Password: "Passw0rd123"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resp.UserID)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.True(t, mr.Exists("otp:verify_email:pending@example.com"))
+}
+
+func TestVerifyEmail_Success(t *testing.T) {
+	mr := setupRedis(t)
+	assert.NoError(t, config.SaveOTP(context.Background(), config.OTPVerifyEmail, "123456", "test@example.com"))
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&user.Users{UserID: 1, IsActive: false}, nil)
+	mockRepo.On("Activate", mock.Anything, 1).Return(nil)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	err := svc.VerifyEmail(context.Background(), user.VerifyEmailRequest{Email: "test@example.com", OTP: "123456"})
+
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "Activate", mock.Anything, 1)
+	assert.False(t, mr.Exists("otp:verify_email:test@example.com"))
+}
+
+func TestVerifyEmail_WrongOTP(t *testing.T) {
+	setupRedis(t)
+	assert.NoError(t, config.SaveOTP(context.Background(), config.OTPVerifyEmail, "123456", "test@example.com"))
+
+	mockRepo := new(MockUserRepo)
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	err := svc.VerifyEmail(context.Background(), user.VerifyEmailRequest{Email: "test@example.com", OTP: "654321"})
+
+	assert.ErrorIs(t, err, ErrInvalidOTP)
+	mockRepo.AssertNotCalled(t, "Activate", mock.Anything, mock.Anything)
+}
+
+func newActiveUser() *user.Users {
+	return &user.Users{
+		UserID:   1,
+		Email:    "test@example.com",
+		IsActive: true,
+	}
+}
+
+func newSession(id string, refreshToken string) *session.Sessions {
+	return &session.Sessions{
+		ID:           id,
+		UserID:       1,
+		RefreshToken: utils.HashToken(refreshToken),
+		ExpiresAt:    time.Now().Add(utils.RefreshTokenTTL),
+	}
+}
+
+func TestRefreshToken_Rotates(t *testing.T) {
+	setupRedis(t)
+	_, refreshToken, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("FindByID", mock.Anything, "sid-1").Return(newSession("sid-1", refreshToken), nil)
+	sessionRepo.On("Rotate", mock.Anything, "sid-1", utils.HashToken(refreshToken), mock.Anything).Return(true, nil)
+
+	svc := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.RefreshToken(context.Background(), user.RefreshTokenRequest{RefreshToken: refreshToken})
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, refreshToken, resp.RefreshToken)
+	rotated := sessionRepo.Calls[1].Arguments.Get(3).(*session.Sessions)
+	assert.Equal(t, utils.HashToken(resp.RefreshToken), rotated.RefreshToken)
+
+	claims, err := utils.ValidateRefreshToken(resp.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "sid-1", claims.SessionID)
+}
+
+func TestRefreshToken_ReuseEndsSession(t *testing.T) {
+	setupRedis(t)
+	_, oldToken, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+	accessToken, currentToken, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(newActiveUser(), nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("FindByID", mock.Anything, "sid-1").Return(newSession("sid-1", currentToken), nil)
+	sessionRepo.On("Delete", mock.Anything, 1, "sid-1").Return(true, nil)
+
+	svc := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.RefreshToken(context.Background(), user.RefreshTokenRequest{RefreshToken: oldToken})
+
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	assert.Nil(t, resp)
+	sessionRepo.AssertCalled(t, "Delete", mock.Anything, 1, "sid-1")
+
+	// Access tokens of the session are denied as well.
+	claims, err := utils.ValidateJwtToken(accessToken)
+	assert.NoError(t, err)
+	revoked, err := config.IsTokenRevoked(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestRefreshToken_RevokedSession(t *testing.T) {
+	setupRedis(t)
+	_, refreshToken, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("FindByID", mock.Anything, "sid-1").Return(nil, gorm.ErrRecordNotFound)
+
+	svc := NewUserService(new(MockUserRepo), sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.RefreshToken(context.Background(), user.RefreshTokenRequest{RefreshToken: refreshToken})
+
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.Nil(t, resp)
+}
+
+func TestRefreshToken_RejectsAccessToken(t *testing.T) {
+	accessToken, _, err := utils.GenerateJwtToken(1, "", nil, []string{"user"})
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	resp, err := svc.RefreshToken(context.Background(), user.RefreshTokenRequest{RefreshToken: accessToken})
+
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.Nil(t, resp)
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+func TestLogout_RevokesAccessToken(t *testing.T) {
+	setupRedis(t)
+	accessToken, _, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+	claims, err := utils.ValidateJwtToken(accessToken)
+	assert.NoError(t, err)
+
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("Delete", mock.Anything, 1, "sid-1").Return(true, nil)
+
+	svc := NewUserService(new(MockUserRepo), sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	assert.NoError(t, svc.Logout(context.Background(), claims))
+
+	revoked, err := config.IsTokenRevoked(claims.ID, "", claims.UserID, claims.IssuedAt.Time)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	sessionRepo.AssertCalled(t, "Delete", mock.Anything, 1, "sid-1")
+}
+
+func TestLogoutAll_RevokesEarlierTokens(t *testing.T) {
+	setupRedis(t)
+	accessToken, refreshToken, err := utils.GenerateJwtToken(1, "sid-1", nil, []string{"user"})
+	assert.NoError(t, err)
+	claims, err := utils.ValidateJwtToken(accessToken)
+	assert.NoError(t, err)
+
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("DeleteByUser", mock.Anything, 1).Return([]string{"sid-1"}, nil)
+
+	svc := NewUserService(new(MockUserRepo), sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	before := time.Now()
+	assert.NoError(t, svc.LogoutAll(context.Background(), claims))
+	sessionRepo.AssertCalled(t, "DeleteByUser", mock.Anything, 1)
+
+	// Tokens of the deleted sessions are denied whenever they were issued.
+	revoked, err := config.IsTokenRevoked(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	refreshClaims, err := utils.ValidateRefreshToken(refreshToken)
+	assert.NoError(t, err)
+	revoked, err = config.IsTokenRevoked(refreshClaims.ID, refreshClaims.SessionID, refreshClaims.UserID, refreshClaims.IssuedAt.Time)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Other tokens of the user are denied by issue time, to the millisecond.
+	revoked, err = config.IsTokenRevoked("", "", 1, before.Add(-time.Millisecond))
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = config.IsTokenRevoked("", "", 2, before.Add(-time.Millisecond))
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestLogoutAll_KeepsTokensIssuedAfterwards(t *testing.T) {
+	setupRedis(t)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("DeleteByUser", mock.Anything, 1).Return(nil, nil)
+
+	svc := NewUserService(new(MockUserRepo), sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	assert.NoError(t, svc.LogoutAll(context.Background(), &utils.JWTClaim{UserID: 1}))
+
+	// A login right after logging out everywhere must not be caught by the
+	// revocation. iat can be read back a millisecond early, hence the pause.
+	time.Sleep(2 * time.Millisecond)
+	accessToken, _, err := utils.GenerateJwtToken(1, "sid-2", nil, []string{"user"})
+	assert.NoError(t, err)
+	claims, err := utils.ValidateJwtToken(accessToken)
+	assert.NoError(t, err)
+	revoked, err := config.IsTokenRevoked(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAt.Time)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestVerifyEmail_LockedAfterTooManyAttempts(t *testing.T) {
+	mr := setupRedis(t)
+	assert.NoError(t, config.SaveOTP(context.Background(), config.OTPVerifyEmail, "123456", "test@example.com"))
+
+	svc := NewUserService(new(MockUserRepo), new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	for i := 0; i < 5; i++ {
+		err := svc.VerifyEmail(context.Background(), user.VerifyEmailRequest{Email: "test@example.com", OTP: "000000"})
+		assert.ErrorIs(t, err, ErrInvalidOTP)
+	}
+
+	assert.False(t, mr.Exists("otp:verify_email:test@example.com"))
+}
+
+func TestForgotPassword_UnknownEmail(t *testing.T) {
+	mr := setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "unknown@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	err := svc.ForgotPassword(context.Background(), user.ForgotPasswordRequest{Email: "unknown@example.com"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, mr.Keys())
+}
+
+func TestForgotPassword_SendsOTP(t *testing.T) {
+	mr := setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&user.Users{UserID: 1, Email: "test@example.com"}, nil)
+
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	err := svc.ForgotPassword(context.Background(), user.ForgotPasswordRequest{Email: "test@example.com"})
+
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return mr.Exists("otp:reset_password:test@example.com")
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	setupRedis(t)
+	assert.NoError(t, config.SaveOTP(context.Background(), config.OTPResetPassword, "123456", "test@example.com"))
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("FindByEmail", mock.Anything, "test@example.com").Return(&user.Users{UserID: 1, Email: "test@example.com"}, nil)
+	mockRepo.On("UpdatePassword", mock.Anything, 1, mock.Anything).Return(nil)
+	sessionRepo := new(MockSessionRepo)
+	sessionRepo.On("DeleteByUser", mock.Anything, 1).Return(nil, nil)
+
+	svc := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), config.LoginLockout{}, logrus.New())
+	req := user.ResetPasswordRequest{Email: "test@example.com", OTP: "123456", NewPassword: "newpass"}
+	assert.NoError(t, svc.ResetPassword(context.Background(), req))
+
+	passwordHash := mockRepo.Calls[1].Arguments.String(2)
+	_, err := utils.VerifyPassword(passwordHash, "newpass")
+	assert.NoError(t, err)
+	sessionRepo.AssertCalled(t, "DeleteByUser", mock.Anything, 1)
+
+	// The code is single-use.
+	err = svc.ResetPassword(context.Background(), req)
+	assert.ErrorIs(t, err, ErrInvalidOTP)
+}
+
+func TestLoginWithEmail_LocksAfterMaxAttempts(t *testing.T) {
+	setupRedis(t)
+	passwordHash, err := utils.HashPassword("123456")
+	assert.NoError(t, err)
+
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "test@example.com").Return(&user.Users{UserID: 1, Email: "test@example.com", Password: passwordHash, IsActive: true}, nil)
+
+	policy := config.LoginLockout{MaxAttempts: 3, MaxAttemptsPerIP: 10, LockoutDuration: time.Minute, Window: time.Minute}
+	sessionRepo := new(MockSessionRepo)
+	svc := NewUserService(mockRepo, sessionRepo, newRoleRepo(), newAuditRepo(), policy, logrus.New())
+	ctx := utils.WithRequestMeta(context.Background(), utils.RequestMeta{IP: "10.0.0.1"})
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.LoginWithEmail(ctx, user.AuthRequest{Email: "test@example.com", Password: "wrongpass"})
+		assert.Error(t, err)
+	}
+
+	// Even the right password is refused while the account is locked.
+	_, err = svc.LoginWithEmail(ctx, user.AuthRequest{Email: "Test@Example.com", Password: "123456"})
+	var lockedErr *LoginLockedError
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.True(t, lockedErr.Locked)
+	assert.Greater(t, lockedErr.RetryAfter, time.Duration(0))
+
+	assert.NoError(t, svc.UnlockAccount(context.Background(), user.UnlockAccountRequest{Email: "test@example.com"}))
+	sessionRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	_, err = svc.LoginWithEmail(ctx, user.AuthRequest{Email: "test@example.com", Password: "123456"})
+	assert.NoError(t, err)
+}
+
+func TestLoginWithEmail_ProgressiveDelay(t *testing.T) {
+	mr := setupRedis(t)
+	mockRepo := new(MockUserRepo)
+	mockRepo.On("Login", mock.Anything, "notfound@example.com").Return(nil, gorm.ErrRecordNotFound)
+
+	policy := config.LoginLockout{MaxAttempts: 10, DelayAfter: 2, BaseDelay: time.Second, LockoutDuration: time.Minute, Window: time.Minute}
+	svc := NewUserService(mockRepo, new(MockSessionRepo), newRoleRepo(), newAuditRepo(), policy, logrus.New())
+	req := user.AuthRequest{Email: "notfound@example.com", Password: "123456"}
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.LoginWithEmail(context.Background(), req)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	}
+
+	_, err := svc.LoginWithEmail(context.Background(), req)
+	var lockedErr *LoginLockedError
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.False(t, lockedErr.Locked)
+	assert.Equal(t, time.Second, lockedErr.RetryAfter)
+
+	// The next failure after the delay doubles it.
+	mr.FastForward(time.Second)
+	_, err = svc.LoginWithEmail(context.Background(), req)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = svc.LoginWithEmail(context.Background(), req)
+	assert.ErrorAs(t, err, &lockedErr)
+	assert.Equal(t, 2*time.Second, lockedErr.RetryAfter)
+}
@@ -0,0 +1,128 @@
+// Package validation registers the custom binding rules of the API and turns
+// binding failures into field errors.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/i18n"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+const (
+	// RulePassword requires at least minPasswordLength characters with an
+	// upper case letter, a lower case letter and a digit.
+	RulePassword = "password"
+	// RuleVietnamesePhone accepts Vietnamese mobile numbers written as
+	// 0xxxxxxxxx, 84xxxxxxxxx or +84xxxxxxxxx.
+	RuleVietnamesePhone = "vnphone"
+	// RuleType reports a JSON value of the wrong type.
+	RuleType = "type"
+
+	minPasswordLength = 8
+)
+
+var vietnamesePhone = regexp.MustCompile(`^(?:0|\+?84)(?:3|5|7|8|9)[0-9]{8}$`)
+
+// The rules are registered when the package is loaded: DTOs use them in
+// their binding tags and binding panics on an unknown rule.
+func init() {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	engine.RegisterTagNameFunc(fieldName)
+	_ = engine.RegisterValidation(RulePassword, func(fl validator.FieldLevel) bool {
+		return StrongPassword(fl.Field().String())
+	})
+	_ = engine.RegisterValidation(RuleVietnamesePhone, func(fl validator.FieldLevel) bool {
+		return VietnamesePhone(fl.Field().String())
+	})
+}
+
+// fieldName reports fields by the name clients send, from the json or form
+// tag, rather than the Go field name.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// StrongPassword reports whether password satisfies RulePassword.
+func StrongPassword(password string) bool {
+	if len([]rune(password)) < minPasswordLength {
+		return false
+	}
+	var upper, lower, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
+
+// VietnamesePhone reports whether phone satisfies RuleVietnamesePhone.
+// Spaces, dots and dashes between digits are ignored.
+func VietnamesePhone(phone string) bool {
+	phone = strings.NewReplacer(" ", "", ".", "", "-", "").Replace(phone)
+	return vietnamesePhone.MatchString(phone)
+}
+
+// FieldErrors lists the fields that failed binding, with messages in lang.
+// It returns nil when err is not about particular fields, such as malformed
+// JSON.
+func FieldErrors(err error, lang i18n.Language) []utils.FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]utils.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, utils.FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Message: i18n.FieldMessage(lang, fe.Field(), fe.Tag(), fe.Param(), kind(fe.Kind())),
+			})
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []utils.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    RuleType,
+			Message: i18n.FieldMessage(lang, typeErr.Field, RuleType, "", ""),
+		}}
+	}
+	return nil
+}
+
+func kind(k reflect.Kind) string {
+	switch k {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	default:
+		return "number"
+	}
+}
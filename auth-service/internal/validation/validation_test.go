@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/i18n"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/utils"
+)
+
+func TestStrongPassword(t *testing.T) {
+	assert.True(t, StrongPassword("Passw0rd"))
+	assert.True(t, StrongPassword("Mật khẩu 2024"))
+	assert.False(t, StrongPassword("Pass0rd"))
+	assert.False(t, StrongPassword("password123"))
+	assert.False(t, StrongPassword("PASSWORD123"))
+	assert.False(t, StrongPassword("Password"))
+}
+
+func TestVietnamesePhone(t *testing.T) {
+	for _, phone := range []string{"0912345678", "+84912345678", "84912345678", "0381 234 567", "090.123.4567", "070-123-4567"} {
+		assert.True(t, VietnamesePhone(phone), phone)
+	}
+	for _, phone := range []string{"0212345678", "091234567", "09123456789", "+1912345678", "phone"} {
+		assert.False(t, VietnamesePhone(phone), phone)
+	}
+}
+
+type signupRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,password"`
+	Phone    string `json:"phone" binding:"omitempty,vnphone"`
+	Age      int    `json:"age" binding:"omitempty,min=13"`
+}
+
+func bind(body string) error {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	var req signupRequest
+	return c.ShouldBindJSON(&req)
+}
+
+func TestFieldErrors(t *testing.T) {
+	err := bind(`{"email":"not-an-email","password":"weak","phone":"0212345678","age":5}`)
+
+	assert.Equal(t, []utils.FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "password", Rule: RulePassword, Message: "password must be at least 8 characters long and contain an upper case letter, a lower case letter and a digit"},
+		{Field: "phone", Rule: RuleVietnamesePhone, Message: "phone must be a valid Vietnamese phone number"},
+		{Field: "age", Rule: "min", Message: "age must be at least 13"},
+	}, FieldErrors(err, i18n.English))
+}
+
+func TestFieldErrors_Vietnamese(t *testing.T) {
+	err := bind(`{}`)
+
+	assert.Equal(t, []utils.FieldError{
+		{Field: "email", Rule: "required", Message: "email là bắt buộc"},
+		{Field: "password", Rule: "required", Message: "password là bắt buộc"},
+	}, FieldErrors(err, i18n.Vietnamese))
+}
+
+func TestFieldErrors_WrongType(t *testing.T) {
+	err := bind(`{"email":"a@example.com","password":"Passw0rd","age":"old"}`)
+
+	assert.Equal(t, []utils.FieldError{
+		{Field: "age", Rule: RuleType, Message: "age has the wrong type"},
+	}, FieldErrors(err, i18n.English))
+}
+
+func TestFieldErrors_NotAboutFields(t *testing.T) {
+	assert.Nil(t, FieldErrors(bind(`{"email":`), i18n.English))
+	assert.Nil(t, FieldErrors(errors.New("boom"), i18n.English))
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS webauthn_credentials;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_providers;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,72 @@
+-- Baseline schema, matching what the service created through gorm before
+-- versioned migrations existed. Every statement is idempotent so that the
+-- baseline can also be applied to a database that already has these tables.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            SERIAL PRIMARY KEY,
+    email         TEXT UNIQUE,
+    password_hash TEXT,
+    is_active     BOOLEAN,
+    create_at     TIMESTAMPTZ,
+    roles         JSONB,
+    permissions   JSONB
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS webauthn_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS user_providers (
+    id        SERIAL PRIMARY KEY,
+    user_id   INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    provider  TEXT    NOT NULL,
+    subject   TEXT    NOT NULL,
+    email     TEXT,
+    linked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_user_providers_user_id ON user_providers (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_providers_provider_subject ON user_providers (provider, subject);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  TEXT    NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id                 SERIAL PRIMARY KEY,
+    user_id            INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name               TEXT,
+    credential_id      BYTEA   NOT NULL,
+    public_key         BYTEA   NOT NULL,
+    attestation_format TEXT,
+    transports         TEXT,
+    aaguid             BYTEA,
+    sign_count         BIGINT  NOT NULL DEFAULT 0,
+    clone_warning      BOOLEAN NOT NULL DEFAULT false,
+    user_verified      BOOLEAN NOT NULL DEFAULT false,
+    backup_eligible    BOOLEAN NOT NULL DEFAULT false,
+    backup_state       BOOLEAN NOT NULL DEFAULT false,
+    created_at         TIMESTAMPTZ,
+    last_used_at       TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user_id ON webauthn_credentials (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webauthn_credentials_credential_id ON webauthn_credentials (credential_id);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id            VARCHAR(36) PRIMARY KEY,
+    user_id       INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    device_name   TEXT,
+    user_agent    TEXT,
+    ip_address    TEXT,
+    refresh_token TEXT    NOT NULL,
+    created_at    TIMESTAMPTZ,
+    last_used_at  TIMESTAMPTZ,
+    expires_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
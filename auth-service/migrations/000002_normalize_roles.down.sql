@@ -0,0 +1,37 @@
+-- Restore the users.roles / users.permissions jsonb columns. Inheritance is
+-- flattened: users get the permissions of their roles and of every ancestor,
+-- and the legacy-user-<id> roles are folded back into users.permissions.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS roles JSONB;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS permissions JSONB;
+
+UPDATE users u
+SET roles = COALESCE((
+    SELECT jsonb_agg(ro.name ORDER BY ro.name)
+    FROM user_roles ur
+    JOIN roles ro ON ro.id = ur.role_id
+    WHERE ur.user_id = u.id AND ro.name NOT LIKE 'legacy-user-%'
+), '[]'::jsonb);
+
+WITH RECURSIVE granted (user_id, role_id) AS (
+    SELECT ur.user_id, ur.role_id
+    FROM user_roles ur
+    UNION
+    SELECT g.user_id, ro.parent_id
+    FROM granted g
+    JOIN roles ro ON ro.id = g.role_id
+    WHERE ro.parent_id IS NOT NULL
+)
+UPDATE users u
+SET permissions = COALESCE((
+    SELECT jsonb_agg(DISTINCT pe.name)
+    FROM granted g
+    JOIN role_permissions rp ON rp.role_id = g.role_id
+    JOIN permissions pe ON pe.id = rp.permission_id
+    WHERE g.user_id = u.id
+), '[]'::jsonb);
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,102 @@
+-- Replace the users.roles / users.permissions jsonb columns with a normalized
+-- roles and permissions model. Roles may inherit from a parent role.
+
+CREATE TABLE IF NOT EXISTS roles (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(50)  NOT NULL UNIQUE,
+    description TEXT         NOT NULL DEFAULT '',
+    parent_id   INTEGER      REFERENCES roles (id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_roles_parent_id ON roles (parent_id);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT         NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id       INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    permission_id INTEGER NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+CREATE INDEX IF NOT EXISTS idx_role_permissions_permission_id ON role_permissions (permission_id);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_roles_role_id ON user_roles (role_id);
+
+-- Built-in roles. Admins inherit everything a regular user can do.
+INSERT INTO roles (name, description) VALUES ('user', 'Default role for every account')
+ON CONFLICT (name) DO NOTHING;
+INSERT INTO roles (name, description, parent_id)
+SELECT 'admin', 'Manages users, roles and permissions', id FROM roles WHERE name = 'user'
+ON CONFLICT (name) DO NOTHING;
+
+-- Every role name found in users.roles becomes a catalogue role and is
+-- assigned to the users that had it.
+INSERT INTO roles (name)
+SELECT DISTINCT r.name
+FROM users u
+CROSS JOIN LATERAL jsonb_array_elements_text(
+    CASE WHEN jsonb_typeof(u.roles) = 'array' THEN u.roles ELSE '[]'::jsonb END
+) AS r(name)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT DISTINCT u.id, ro.id
+FROM users u
+CROSS JOIN LATERAL jsonb_array_elements_text(
+    CASE WHEN jsonb_typeof(u.roles) = 'array' THEN u.roles ELSE '[]'::jsonb END
+) AS r(name)
+JOIN roles ro ON ro.name = r.name
+ON CONFLICT DO NOTHING;
+
+-- Permissions used to be granted per user. To keep every user's effective
+-- permissions unchanged, each user with direct permissions gets a private
+-- legacy-user-<id> role holding exactly those permissions.
+INSERT INTO permissions (name)
+SELECT DISTINCT p.name
+FROM users u
+CROSS JOIN LATERAL jsonb_array_elements_text(
+    CASE WHEN jsonb_typeof(u.permissions) = 'array' THEN u.permissions ELSE '[]'::jsonb END
+) AS p(name)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description)
+SELECT 'legacy-user-' || u.id, 'Permissions migrated from the former per-user permissions column'
+FROM users u
+WHERE CASE WHEN jsonb_typeof(u.permissions) = 'array' THEN jsonb_array_length(u.permissions) > 0 ELSE false END
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT DISTINCT ro.id, pe.id
+FROM users u
+CROSS JOIN LATERAL jsonb_array_elements_text(
+    CASE WHEN jsonb_typeof(u.permissions) = 'array' THEN u.permissions ELSE '[]'::jsonb END
+) AS p(name)
+JOIN roles ro ON ro.name = 'legacy-user-' || u.id
+JOIN permissions pe ON pe.name = p.name
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, ro.id
+FROM users u
+JOIN roles ro ON ro.name = 'legacy-user-' || u.id
+ON CONFLICT DO NOTHING;
+
+-- Accounts without any role fall back to the default role.
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, ro.id
+FROM users u
+JOIN roles ro ON ro.name = 'user'
+WHERE NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = u.id)
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users DROP COLUMN IF EXISTS roles;
+ALTER TABLE users DROP COLUMN IF EXISTS permissions;
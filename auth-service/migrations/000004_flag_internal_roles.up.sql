@@ -0,0 +1,7 @@
+-- Internal roles only carry permissions. Their names are left out of the
+-- role claim of tokens, like the per-user legacy-user-<id> roles created by
+-- 000002_normalize_roles.
+
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS internal BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE roles SET internal = true WHERE name LIKE 'legacy-user-%';
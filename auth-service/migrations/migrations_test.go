@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	migrations, err := Load(Files)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "init_schema", migrations[0].Name)
+	for i := 1; i < len(migrations); i++ {
+		assert.Equal(t, migrations[i-1].Version+1, migrations[i].Version)
+	}
+}
+
+func TestLoad_RequiresDownFile(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"000001_a.up.sql":   {Data: []byte("SELECT 1")},
+		"000001_a.down.sql": {Data: []byte("SELECT 1")},
+		"000002_b.up.sql":   {Data: []byte("SELECT 1")},
+	})
+	assert.ErrorContains(t, err, "000002")
+}
+
+func TestCreate_NumbersAfterLast(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000004_a.up.sql"), []byte("SELECT 1"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "000004_a.down.sql"), []byte("SELECT 1"), 0o644))
+
+	up, down, err := Create(dir, "add_index")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "000005_add_index.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "000005_add_index.down.sql"), down)
+
+	_, _, err = Create(dir, "Bad Name")
+	assert.Error(t, err)
+}
+
+func TestUp_AppliesOnlyPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "a", Up: "CREATE TABLE a ()", Down: "DROP TABLE a"},
+		{Version: 2, Name: "b", Up: "CREATE TABLE b ()", Down: "DROP TABLE b"},
+	})
+
+	mock.ExpectExec(`SELECT pg_advisory_lock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE b \(\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(int64(2), "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	done, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.Equal(t, int64(2), done[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDown_RollsBackFailedMigration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	migrator := NewMigrator(db, []Migration{
+		{Version: 1, Name: "a", Up: "CREATE TABLE a ()", Down: "DROP TABLE a"},
+	})
+
+	mock.ExpectExec(`SELECT pg_advisory_lock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DROP TABLE a`).WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).WithArgs(lockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	done, err := migrator.Down(context.Background(), 1)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Empty(t, done)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,12 +2,133 @@ package router
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/handlers"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/middleware"
 )
 
-func LoginRouter(r *gin.Engine, userHandler *handlers.UserHandler) {
+func LoginRouter(r *gin.Engine, userHandler *handlers.UserHandler, limits *middleware.RateLimits) {
 	api := r.Group("/api/v1/auth")
 	{
-		api.POST("/login", userHandler.LoginWithEmail())
+		api.POST("/login", limits.For("login"), userHandler.LoginWithEmail())
+		api.POST("/register", limits.For("register"), userHandler.Register())
+		api.POST("/verify-email", limits.For("verify_email"), userHandler.VerifyEmail())
+		api.POST("/refresh", limits.For("refresh"), userHandler.RefreshToken())
+		api.POST("/password/forgot", limits.For("password_forgot"), userHandler.ForgotPassword())
+		api.POST("/password/reset", limits.For("password_reset"), userHandler.ResetPassword())
+
+		authorized := api.Group("", middleware.Authenticate())
+		{
+			authorized.POST("/logout", limits.For("logout"), userHandler.Logout())
+			authorized.POST("/logout-all", userHandler.LogoutAll())
+		}
+	}
+}
+
+func SessionRouter(r *gin.Engine, sessionHandler *handlers.SessionHandler) {
+	api := r.Group("/api/v1/auth/sessions", middleware.Authenticate())
+	{
+		api.GET("", sessionHandler.List())
+		api.DELETE("/:id", sessionHandler.Revoke())
+	}
+}
+
+func TwoFactorRouter(r *gin.Engine, twoFactorHandler *handlers.TwoFactorHandler, limits *middleware.RateLimits) {
+	api := r.Group("/api/v1/auth/2fa")
+	{
+		api.POST("/verify", limits.For("two_factor_verify"), twoFactorHandler.Verify())
+
+		authorized := api.Group("", middleware.Authenticate())
+		{
+			authorized.POST("/enroll", twoFactorHandler.Enroll())
+			authorized.POST("/confirm", twoFactorHandler.Confirm())
+			authorized.POST("/disable", twoFactorHandler.Disable())
+		}
+	}
+}
+
+func PasskeyRouter(r *gin.Engine, passkeyHandler *handlers.PasskeyHandler, limits *middleware.RateLimits) {
+	api := r.Group("/api/v1/auth")
+	{
+		api.POST("/passkeys/login/begin", limits.For("passkey_login"), passkeyHandler.BeginLogin())
+		api.POST("/passkeys/login/finish", limits.For("passkey_login"), passkeyHandler.FinishLogin())
+		api.POST("/2fa/webauthn/begin", limits.For("two_factor_verify"), passkeyHandler.BeginSecondFactor())
+		api.POST("/2fa/webauthn/finish", limits.For("two_factor_verify"), passkeyHandler.FinishSecondFactor())
+
+		authorized := api.Group("/passkeys", middleware.Authenticate())
+		{
+			authorized.GET("", passkeyHandler.List())
+			authorized.DELETE("/:id", passkeyHandler.Delete())
+			authorized.POST("/register/begin", passkeyHandler.BeginRegistration())
+			authorized.POST("/register/finish", passkeyHandler.FinishRegistration())
+		}
+	}
+}
+
+func OAuthRouter(r *gin.Engine, oauthHandler *handlers.OAuthHandler) {
+	api := r.Group("/api/v1/auth/oauth")
+	{
+		api.GET("/:provider/start", oauthHandler.Start())
+		// Apple posts the callback as a form (response_mode=form_post).
+		api.GET("/:provider/callback", oauthHandler.Callback())
+		api.POST("/:provider/callback", oauthHandler.Callback())
+	}
+}
+
+func HealthRouter(r *gin.Engine, healthHandler *handlers.HealthHandler) {
+	r.GET("/healthz", healthHandler.Liveness())
+	r.GET("/readyz", healthHandler.Readiness())
+}
+
+func MetricsRouter(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+}
+
+func WellKnownRouter(r *gin.Engine, jwksHandler *handlers.JWKSHandler) {
+	r.GET("/.well-known/jwks.json", jwksHandler.JWKS())
+}
+
+func TokenRouter(r *gin.Engine, tokenHandler *handlers.TokenHandler, clients map[string]string) {
+	api := r.Group("/api/v1/auth", middleware.ClientCredentials(clients))
+	{
+		api.POST("/introspect", tokenHandler.Introspect())
+	}
+}
+
+func AdminRouter(r *gin.Engine, userHandler *handlers.UserHandler, adminUserHandler *handlers.AdminUserHandler, roleHandler *handlers.RoleHandler, auditHandler *handlers.AuditHandler) {
+	api := r.Group("/api/v1/admin", middleware.Authenticate(), middleware.RequireRole("admin"))
+	{
+		api.POST("/lockouts/unlock", userHandler.UnlockAccount())
+
+		users := api.Group("/users")
+		{
+			users.GET("", adminUserHandler.ListUsers())
+			users.POST("", adminUserHandler.CreateUser())
+			users.GET("/:id", adminUserHandler.GetUser())
+			users.PATCH("/:id", adminUserHandler.UpdateUser())
+			users.POST("/:id/deactivate", adminUserHandler.DeactivateUser())
+			users.POST("/:id/reactivate", adminUserHandler.ReactivateUser())
+			users.PUT("/:id/roles", adminUserHandler.SetRoles())
+			users.POST("/:id/password-reset", adminUserHandler.ForcePasswordReset())
+		}
+
+		roles := api.Group("/roles")
+		{
+			roles.GET("", roleHandler.ListRoles())
+			roles.POST("", roleHandler.CreateRole())
+			roles.GET("/:id", roleHandler.GetRole())
+			roles.PATCH("/:id", roleHandler.UpdateRole())
+			roles.DELETE("/:id", roleHandler.DeleteRole())
+			roles.PUT("/:id/permissions", roleHandler.SetRolePermissions())
+		}
+
+		permissions := api.Group("/permissions")
+		{
+			permissions.GET("", roleHandler.ListPermissions())
+			permissions.POST("", roleHandler.CreatePermission())
+			permissions.DELETE("/:id", roleHandler.DeletePermission())
+		}
+
+		api.GET("/audit-events", auditHandler.ListEvents())
 	}
-}
\ No newline at end of file
+}
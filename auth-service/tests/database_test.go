@@ -1,10 +1,11 @@
 package tests
 
 import (
+	"context"
 	"log"
 	"time"
 
-	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/migrations"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -28,10 +29,14 @@ func setupTestDB() *gorm.DB {
 	sqlDB.SetMaxOpenConns(10)
 	sqlDB.SetMaxIdleConns(5)
 
-	if err := db.AutoMigrate(&user.Users{}); err != nil {
-		log.Fatalf("AutoMigrate lỗi: %v", err)
+	all, err := migrations.Load(migrations.Files)
+	if err != nil {
+		log.Fatalf("Không đọc được migrations: %v", err)
+	}
+	if _, err := migrations.NewMigrator(sqlDB, all).Up(context.Background()); err != nil {
+		log.Fatalf("Chạy migrations lỗi: %v", err)
 	}
 
-	log.Println("✅ Kết nối DB test thành công và đã migrate bảng users")
+	log.Println("✅ Kết nối DB test thành công và đã chạy migrations")
 	return db
 }
\ No newline at end of file
@@ -5,11 +5,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"gorm.io/datatypes"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/config"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/handlers"
+	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/role"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/models/user"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/repositories"
 	"github.com/tranthanhsang2k3/healthmate-backend/auth-service/internal/services"
@@ -24,20 +25,20 @@ func TestLoginWithEmail_Integration(t *testing.T) {
 		t.Fatal("Failed to connect to test database")
 	}
 
-	// Seed dữ liệu user
+	// Seed dữ liệu role và user
+	adminRole := role.Roles{Name: "admin", Permissions: []role.Permissions{{Name: "read"}}}
+	db.Where(role.Roles{Name: "admin"}).FirstOrCreate(&adminRole)
 	passwordHash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.DefaultCost)
 	db.Create(&user.Users{
-		Email:        "test@example.com",
-		Password:     string(passwordHash),
-		IsActive:     false,
-		Role:         datatypes.JSON([]byte(`["admin"]`)),
-		Permission:   datatypes.JSON([]byte(`["read"]`)),
-		RefreshToken: "",
+		Email:    "test@example.com",
+		Password: string(passwordHash),
+		IsActive: true,
+		Roles:    []role.Roles{adminRole},
 	})
 
 	// Khởi tạo repo, service, handler thật
 	repo := repositories.NewUserRepository(db)
-	service := services.NewUserService(repo, logrus.New())
+	service := services.NewUserService(repo, repositories.NewSessionRepository(db), repositories.NewRoleRepository(db), repositories.NewAuditRepository(db), config.LoginLockout{}, logrus.New())
 	handler := handlers.NewUserHandler(service)
 
 	// Setup router
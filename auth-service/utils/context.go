@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type requestMetaKey struct{}
+
+type claimsKey struct{}
+
+type loggerKey struct{}
+
+// RequestMeta carries details of the incoming HTTP request down to the
+// service layer.
+type RequestMeta struct {
+	IP         string
+	UserAgent  string
+	DeviceName string
+	RequestID  string
+}
+
+func WithRequestMeta(ctx context.Context, meta RequestMeta) context.Context {
+	return context.WithValue(ctx, requestMetaKey{}, meta)
+}
+
+func RequestMetaFromContext(ctx context.Context) RequestMeta {
+	meta, _ := ctx.Value(requestMetaKey{}).(RequestMeta)
+	return meta
+}
+
+// WithClaims stores the claims of the authenticated caller so that services
+// can tell who is acting without being handed the claims explicitly.
+func WithClaims(ctx context.Context, claims *JWTClaim) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func ClaimsFromContext(ctx context.Context) (*JWTClaim, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*JWTClaim)
+	return claims, ok
+}
+
+// WithLogger stores the log entry of the current request, already carrying
+// its request ID, route, client IP and, once known, user ID.
+func WithLogger(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, loggerKey{}, entry)
+}
+
+// LoggerFromContext returns the log entry of the request in ctx, bound to ctx
+// so that trace IDs are added, or an entry of fallback outside a request.
+func LoggerFromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	if entry, ok := ctx.Value(loggerKey{}).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	return fallback.WithContext(ctx)
+}
+
+// WithLogFields adds fields to the request log entry in ctx, if it has one.
+func WithLogFields(ctx context.Context, fields logrus.Fields) context.Context {
+	entry, ok := ctx.Value(loggerKey{}).(*logrus.Entry)
+	if !ok {
+		return ctx
+	}
+	return WithLogger(ctx, entry.WithFields(fields))
+}
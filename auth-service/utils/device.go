@@ -0,0 +1,60 @@
+package utils
+
+import "strings"
+
+const maxDeviceNameLength = 100
+
+// DeviceName returns the name a client gave its device, or a readable name
+// such as "Chrome on Windows" derived from the user agent.
+func DeviceName(name string, userAgent string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = deviceNameFromUserAgent(userAgent)
+	}
+	if len(name) > maxDeviceNameLength {
+		name = strings.ToValidUTF8(name[:maxDeviceNameLength], "")
+	}
+	return name
+}
+
+func deviceNameFromUserAgent(userAgent string) string {
+	browser := matchFirst(userAgent, [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"okhttp/", "Android app"},
+		{"Dart/", "Mobile app"},
+		{"CFNetwork/", "iOS app"},
+	})
+	platform := matchFirst(userAgent, [][2]string{
+		{"Android", "Android"},
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	default:
+		return "Unknown device"
+	}
+}
+
+func matchFirst(userAgent string, patterns [][2]string) string {
+	for _, pattern := range patterns {
+		if strings.Contains(userAgent, pattern[0]) {
+			return pattern[1]
+		}
+	}
+	return ""
+}
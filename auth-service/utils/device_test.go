@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceName(t *testing.T) {
+	cases := map[string]string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36":              "Chrome on Windows",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/604.1": "Safari on iPhone",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0": "Edge on macOS",
+		"okhttp/4.12.0": "Android app",
+		"":              "Unknown device",
+	}
+	for userAgent, want := range cases {
+		assert.Equal(t, want, DeviceName("", userAgent), userAgent)
+	}
+
+	assert.Equal(t, "My phone", DeviceName("  My phone ", "okhttp/4.12.0"))
+	assert.Len(t, DeviceName(strings.Repeat("a", 300), ""), maxDeviceNameLength)
+}
@@ -1,16 +1,36 @@
 package utils
 
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
 
-const(
-	ErrorNotFound = "not_found"
-	ErrorInternal = "internal_error"
-	ErrorBadRequest = "bad_request"
-	ErrorUnauthorized = "unauthorized"
-	ErrorForbidden    = "forbidden"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	ErrorNotFound        = "not_found"
+	ErrorInternal        = "internal_error"
+	ErrorBadRequest      = "bad_request"
+	ErrorUnauthorized    = "unauthorized"
+	ErrorForbidden       = "forbidden"
+	ErrorConflict        = "conflict"
+	ErrorTooManyRequests = "too_many_requests"
+	ErrorUnavailable     = "service_unavailable"
+	ErrorValidation      = "validation_failed"
 )
 
 type ErrorResponse struct {
-	Status  bool   `json:"status"`
+	Status  bool         `json:"status"`
+	Code    string       `json:"code,omitempty"`
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a request field that failed the validation rule Rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
 	Message string `json:"message"`
 }
 
@@ -19,4 +39,107 @@ func ErrorResponseFull(status bool, message string) *ErrorResponse {
 		Status:  status,
 		Message: message,
 	}
-}
\ No newline at end of file
+}
+
+// AppError is an error that can be shown to clients. Code is a stable machine
+// readable identifier, Status the HTTP status it is rendered with and Message
+// a public message that never contains the wrapped cause, which is only
+// logged. Message is English and translated when rendered; Detail, such as
+// the names of unknown roles, is appended to it untranslated.
+type AppError struct {
+	Code    string
+	Status  int
+	Message string
+	Detail  string
+	Err     error
+}
+
+func NewAppError(code string, status int, message string) *AppError {
+	return &AppError{Code: code, Status: status, Message: message}
+}
+
+var (
+	ErrBadRequest      = NewAppError(ErrorBadRequest, http.StatusBadRequest, "Invalid request")
+	ErrUnauthorized    = NewAppError(ErrorUnauthorized, http.StatusUnauthorized, "Unauthorized")
+	ErrForbidden       = NewAppError(ErrorForbidden, http.StatusForbidden, "Forbidden")
+	ErrNotFound        = NewAppError(ErrorNotFound, http.StatusNotFound, "Not found")
+	ErrTooManyRequests = NewAppError(ErrorTooManyRequests, http.StatusTooManyRequests, "Too many requests, please try again later")
+	ErrInternal        = NewAppError(ErrorInternal, http.StatusInternalServerError, "Internal server error")
+)
+
+func (e *AppError) Error() string {
+	message := e.Message
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	if e.Err != nil {
+		return message + ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches app errors by code, so a wrapped or reworded copy of a sentinel
+// still matches the sentinel.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *AppError) Wrap(err error) *AppError {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithMessage returns a copy of e with another public message.
+func (e *AppError) WithMessage(message string) *AppError {
+	reworded := *e
+	reworded.Message = message
+	return &reworded
+}
+
+// WithDetail returns a copy of e with detail appended to its message.
+func (e *AppError) WithDetail(detail string) *AppError {
+	detailed := *e
+	detailed.Detail = detail
+	return &detailed
+}
+
+// Internal wraps err as an internal error unless it already carries an
+// AppError, so that repository and infrastructure failures never reach
+// clients verbatim.
+func Internal(err error) error {
+	var appErr *AppError
+	if err == nil || errors.As(err, &appErr) {
+		return err
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// AsAppError returns the AppError carried by err, or ErrInternal wrapping err
+// when there is none.
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// InvalidRequest reports a request that could not be bound or parsed. When
+// particular fields failed validation the code is ErrorValidation and the
+// error handler lists the fields from the wrapped binding error.
+func InvalidRequest(message string, err error) *AppError {
+	appErr := ErrBadRequest.WithMessage(message).Wrap(err)
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &validationErrs) || errors.As(err, &typeErr) {
+		appErr.Code = ErrorValidation
+	}
+	return appErr
+}
@@ -1,18 +1,43 @@
 package utils
 
 import (
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
 var jwtSecret []byte
 
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Token lifetimes, configurable through InitTokenTTLs.
+var (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 24 * time.Hour
+)
+
+var ErrInvalidTokenType = errors.New("invalid token type")
+
+// Time claims keep milliseconds so that revoking the tokens of a user with
+// config.RevokeUserTokens tells apart tokens issued within the same second.
+func init() {
+	jwt.TimePrecision = time.Millisecond
+}
+
 type JWTClaim struct {
 	Permission []string `json:"permission"`
 	Role       []string `json:"role"`
 	UserID     int      `json:"id"`
+	Type       string   `json:"typ"`
+	SessionID  string   `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -24,8 +49,16 @@ func InitJWTSecret(secret string, log *logrus.Logger) {
 	jwtSecret = []byte(secret)
 }
 
+func InitTokenTTLs(access time.Duration, refresh time.Duration) {
+	AccessTokenTTL = access
+	RefreshTokenTTL = refresh
+}
+
+// GenerateJwtToken issues an access and refresh token pair bound to the
+// session sessionID through the sid claim.
 func GenerateJwtToken(
 	userID int,
+	sessionID string,
 	permissions []string,
 	roles []string,
 )(string, string, error){
@@ -33,13 +66,17 @@ func GenerateJwtToken(
 		Permission: permissions,
 		Role:      roles,
 		UserID:    userID,
+		Type:      TokenTypeAccess,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+			ID:        uuid.NewString(),
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString(jwtSecret)
+	accessToken, err := signToken(accessClaims)
 	if err != nil {
 		return "", "", err
 	}
@@ -48,13 +85,17 @@ func GenerateJwtToken(
 		Permission: permissions,
 		Role:      roles,
 		UserID:    userID,
+		Type:      TokenTypeRefresh,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.NewString(),
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 			IssuedAt: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString(jwtSecret)
+	refreshToken, err := signToken(refreshClaims)
 	if err != nil {
 		return accessToken, "", err
 	}
@@ -62,10 +103,52 @@ func GenerateJwtToken(
 	return accessToken, refreshToken, nil
 }
 
+// ValidateJwtToken validates an access token. Refresh tokens are rejected.
 func ValidateJwtToken(tokenString string) (*JWTClaim, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaim{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
+	return parseJwtToken(tokenString, TokenTypeAccess)
+}
+
+// ValidateRefreshToken validates a refresh token. Access tokens are rejected.
+func ValidateRefreshToken(tokenString string) (*JWTClaim, error) {
+	return parseJwtToken(tokenString, TokenTypeRefresh)
+}
+
+// signToken signs with the active key of the keyset and sets its kid header.
+// Without a keyset it falls back to HS256 with the shared secret.
+func signToken(claims jwt.Claims) (string, error) {
+	set := keySet.Load()
+	if set == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+	}
+
+	token := jwt.NewWithClaims(set.Active.Method, claims)
+	token.Header["kid"] = set.Active.ID
+	return token.SignedString(set.Active.PrivateKey)
+}
+
+// verificationKeyFunc only accepts asymmetric algorithms once a keyset is
+// loaded, so an HS256 token can never be verified with a public key.
+func verificationKeyFunc() (jwt.Keyfunc, []string) {
+	set := keySet.Load()
+	if set == nil {
+		return func(token *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		}, []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	return func(token *jwt.Token) (interface{}, error) {
+		return lookupVerificationKey(token, set)
+	}, []string{
+		jwt.SigningMethodRS256.Alg(),
+		jwt.SigningMethodES256.Alg(),
+		jwt.SigningMethodES384.Alg(),
+		jwt.SigningMethodEdDSA.Alg(),
+	}
+}
+
+func parseJwtToken(tokenString string, tokenType string) (*JWTClaim, error) {
+	keyFunc, methods := verificationKeyFunc()
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaim{}, keyFunc, jwt.WithValidMethods(methods))
 	if err != nil {
 		return nil, err
 	}
@@ -75,5 +158,18 @@ func ValidateJwtToken(tokenString string) (*JWTClaim, error) {
 		return nil, jwt.ErrTokenInvalidClaims
 	}
 
+	if claims.Type != tokenType {
+		return nil, ErrInvalidTokenType
+	}
+
 	return claims, nil
-}
\ No newline at end of file
+}
+
+// ExtractBearerToken returns the token of an "Authorization: Bearer <token>" header.
+func ExtractBearerToken(header string) (string, bool) {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
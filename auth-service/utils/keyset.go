@@ -0,0 +1,389 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// activeKeyFile is the file inside the keys directory that holds the kid of
+// the key used for signing. Every other key in the directory is only used to
+// verify tokens it signed earlier.
+const activeKeyFile = "active"
+
+// retiredKeyDir is the subdirectory of the keys directory where the public
+// part of removed keys is kept, with its expiry in the Expires-At PEM header,
+// so a restarted or other replica still verifies the tokens they signed.
+const retiredKeyDir = "retired"
+
+const retiredKeyExpiresHeader = "Expires-At"
+
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	// ExpiresAt is set on retired keys whose PEM file was removed. They are
+	// kept for verification until every token they signed has expired.
+	ExpiresAt time.Time
+}
+
+type KeySet struct {
+	Active *SigningKey
+	Keys   map[string]*SigningKey
+}
+
+var (
+	keySet     atomic.Pointer[KeySet]
+	keySetDir  string
+	keySetLock sync.Mutex
+)
+
+// LoadKeySet loads the signing keys from the PEM files of dir. Once a keyset
+// is loaded tokens are signed with asymmetric keys only.
+func LoadKeySet(dir string) error {
+	keySetLock.Lock()
+	defer keySetLock.Unlock()
+
+	keySetDir = dir
+	return reloadKeySet()
+}
+
+// ReloadKeySet re-reads the keys directory, so keys can be rotated without a
+// restart: add the new key, reload so verifiers see it in the JWKS, then point
+// the active file to it and reload again.
+func ReloadKeySet() error {
+	keySetLock.Lock()
+	defer keySetLock.Unlock()
+
+	if keySetDir == "" {
+		return errors.New("keyset is not configured")
+	}
+	return reloadKeySet()
+}
+
+func reloadKeySet() error {
+	next, err := readKeySet(keySetDir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if previous := keySet.Load(); previous != nil {
+		for kid, key := range previous.Keys {
+			if _, ok := next.Keys[kid]; ok {
+				continue
+			}
+			retired := *key
+			retired.PrivateKey = nil
+			if retired.ExpiresAt.IsZero() {
+				retired.ExpiresAt = now.Add(RefreshTokenTTL)
+				if err := writeRetiredKey(keySetDir, &retired); err != nil {
+					return fmt.Errorf("persist retired key %s: %w", kid, err)
+				}
+			}
+			if retired.ExpiresAt.After(now) {
+				next.Keys[kid] = &retired
+			}
+		}
+	}
+
+	keySet.Store(next)
+	return nil
+}
+
+func readKeySet(dir string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	set := &KeySet{Keys: make(map[string]*SigningKey, len(paths))}
+	for _, path := range paths {
+		key, err := readSigningKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", path, err)
+		}
+		set.Keys[key.ID] = key
+	}
+
+	activeID := ""
+	if raw, err := os.ReadFile(filepath.Join(dir, activeKeyFile)); err == nil {
+		activeID = strings.TrimSpace(string(raw))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	} else if len(paths) == 1 {
+		activeID = keyIDFromPath(paths[0])
+	}
+
+	active, ok := set.Keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found in %s", activeID, dir)
+	}
+	if active.PrivateKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key", activeID)
+	}
+	set.Active = active
+
+	if err := readRetiredKeys(dir, set); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// readRetiredKeys adds the persisted retired keys that are not in the keys
+// directory anymore and removes the files of those that have expired.
+func readRetiredKeys(dir string, set *KeySet) error {
+	paths, err := filepath.Glob(filepath.Join(dir, retiredKeyDir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	now := time.Now()
+	for _, path := range paths {
+		key, err := readRetiredKey(path)
+		if err != nil {
+			return fmt.Errorf("load retired key %s: %w", path, err)
+		}
+		if !key.ExpiresAt.After(now) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		if _, ok := set.Keys[key.ID]; !ok {
+			set.Keys[key.ID] = key
+		}
+	}
+	return nil
+}
+
+func readRetiredKey(path string) (*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("no PUBLIC KEY block found")
+	}
+	expiresAt, err := time.Parse(time.RFC3339, block.Headers[retiredKeyExpiresHeader])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", retiredKeyExpiresHeader, err)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	method, err := signingMethodFor(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{
+		ID:        keyIDFromPath(path),
+		Method:    method,
+		PublicKey: publicKey,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func writeRetiredKey(dir string, key *SigningKey) error {
+	der, err := x509.MarshalPKIXPublicKey(key.PublicKey)
+	if err != nil {
+		return err
+	}
+	raw := pem.EncodeToMemory(&pem.Block{
+		Type:    "PUBLIC KEY",
+		Headers: map[string]string{retiredKeyExpiresHeader: key.ExpiresAt.UTC().Format(time.RFC3339)},
+		Bytes:   der,
+	})
+
+	retiredDir := filepath.Join(dir, retiredKeyDir)
+	if err := os.MkdirAll(retiredDir, 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(retiredDir, key.ID+".pem"), raw, 0o600)
+}
+
+func keyIDFromPath(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".pem")
+}
+
+// readSigningKey reads a PKCS#8, PKCS#1 or SEC 1 private key, or a PKIX
+// public key for verify-only keys. The kid is the file name without ".pem".
+func readSigningKey(path string) (*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &SigningKey{ID: keyIDFromPath(path)}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		key.PrivateKey = signer
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey = parsed
+	case "EC PRIVATE KEY":
+		parsed, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PrivateKey = parsed
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.PublicKey = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	if key.PrivateKey != nil {
+		key.PublicKey = key.PrivateKey.Public()
+	}
+
+	key.Method, err = signingMethodFor(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func signingMethodFor(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		}
+		return nil, fmt.Errorf("unsupported curve %s", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+}
+
+// lookupVerificationKey returns the public key for the kid in the token
+// header, refusing retired keys that outlived their tokens.
+func lookupVerificationKey(token *jwt.Token, set *KeySet) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := set.Keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if !key.ExpiresAt.IsZero() && time.Now().After(key.ExpiresAt) {
+		return nil, fmt.Errorf("key %q has expired", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s for key %q", token.Method.Alg(), kid)
+	}
+	return key.PublicKey, nil
+}
+
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// PublicJWKS returns the public part of every key that can still verify tokens.
+func PublicJWKS() JSONWebKeySet {
+	jwks := JSONWebKeySet{Keys: []JSONWebKey{}}
+	set := keySet.Load()
+	if set == nil {
+		return jwks
+	}
+
+	ids := make([]string, 0, len(set.Keys))
+	for kid := range set.Keys {
+		ids = append(ids, kid)
+	}
+	sort.Strings(ids)
+
+	now := time.Now()
+	for _, kid := range ids {
+		key := set.Keys[kid]
+		if !key.ExpiresAt.IsZero() && now.After(key.ExpiresAt) {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, toJSONWebKey(key))
+	}
+	return jwks
+}
+
+func toJSONWebKey(key *SigningKey) JSONWebKey {
+	jwk := JSONWebKey{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+	switch k := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	}
+	return jwk
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePrivateKey(t *testing.T, dir string, kid string, key interface{}) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	raw := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, kid+".pem"), raw, 0o600))
+}
+
+func setActiveKey(t *testing.T, dir string, kid string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, activeKeyFile), []byte(kid+"\n"), 0o600))
+}
+
+func resetKeySet(t *testing.T) {
+	t.Cleanup(func() {
+		keySet.Store(nil)
+		keySetDir = ""
+	})
+}
+
+func TestKeySet_SignsWithKidAndAlgorithm(t *testing.T) {
+	resetKeySet(t)
+	dir := t.TempDir()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ec-1", ecKey)
+	require.NoError(t, LoadKeySet(dir))
+
+	accessToken, _, err := GenerateJwtToken(1, "", nil, []string{"user"})
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(accessToken, &JWTClaim{})
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", parsed.Method.Alg())
+	assert.Equal(t, "ec-1", parsed.Header["kid"])
+
+	claims, err := ValidateJwtToken(accessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+}
+
+func TestKeySet_RejectsHS256Tokens(t *testing.T) {
+	resetKeySet(t)
+	hsToken, _, err := GenerateJwtToken(1, "", nil, []string{"user"})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ed-1", edKey)
+	require.NoError(t, LoadKeySet(dir))
+
+	_, err = ValidateJwtToken(hsToken)
+	assert.Error(t, err)
+}
+
+func TestKeySet_RotationKeepsRetiredKeys(t *testing.T) {
+	resetKeySet(t)
+	dir := t.TempDir()
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "rsa-1", oldKey)
+	require.NoError(t, LoadKeySet(dir))
+
+	oldToken, _, err := GenerateJwtToken(1, "", nil, []string{"user"})
+	require.NoError(t, err)
+
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ec-2", newKey)
+	setActiveKey(t, dir, "ec-2")
+	require.NoError(t, ReloadKeySet())
+
+	newToken, _, err := GenerateJwtToken(1, "", nil, []string{"user"})
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &JWTClaim{})
+	require.NoError(t, err)
+	assert.Equal(t, "ec-2", parsed.Header["kid"])
+
+	// The old key file is removed, but the key must still verify its tokens.
+	require.NoError(t, os.Remove(filepath.Join(dir, "rsa-1.pem")))
+	require.NoError(t, ReloadKeySet())
+
+	_, err = ValidateJwtToken(oldToken)
+	assert.NoError(t, err)
+	_, err = ValidateJwtToken(newToken)
+	assert.NoError(t, err)
+
+	jwks := PublicJWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "EC", jwks.Keys[0].Kty)
+	assert.Equal(t, "P-256", jwks.Keys[0].Crv)
+	assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+}
+
+func TestKeySet_RetiredKeysSurviveRestart(t *testing.T) {
+	resetKeySet(t)
+	dir := t.TempDir()
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ec-1", oldKey)
+	require.NoError(t, LoadKeySet(dir))
+
+	oldToken, _, err := GenerateJwtToken(1, "", nil, []string{"user"})
+	require.NoError(t, err)
+
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ed-2", newKey)
+	setActiveKey(t, dir, "ed-2")
+	require.NoError(t, os.Remove(filepath.Join(dir, "ec-1.pem")))
+	require.NoError(t, ReloadKeySet())
+	assert.FileExists(t, filepath.Join(dir, retiredKeyDir, "ec-1.pem"))
+
+	// A fresh process only has the keys directory to go by.
+	keySet.Store(nil)
+	require.NoError(t, LoadKeySet(dir))
+
+	_, err = ValidateJwtToken(oldToken)
+	assert.NoError(t, err)
+	assert.Len(t, PublicJWKS().Keys, 2)
+}
+
+func TestKeySet_DropsExpiredRetiredKeys(t *testing.T) {
+	resetKeySet(t)
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ed-1", edKey)
+
+	oldPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, writeRetiredKey(dir, &SigningKey{ID: "ed-0", PublicKey: oldPub, ExpiresAt: time.Now().Add(-time.Minute)}))
+
+	require.NoError(t, LoadKeySet(dir))
+	assert.Len(t, PublicJWKS().Keys, 1)
+	assert.NoFileExists(t, filepath.Join(dir, retiredKeyDir, "ed-0.pem"))
+}
+
+func TestKeySet_MissingActiveKey(t *testing.T) {
+	resetKeySet(t)
+	dir := t.TempDir()
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writePrivateKey(t, dir, "ed-1", edKey)
+	der, err := x509.MarshalPKIXPublicKey(edPub)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ed-pub.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	// Two keys and no active file: the signing key is ambiguous.
+	assert.Error(t, LoadKeySet(dir))
+
+	setActiveKey(t, dir, "ed-pub")
+	assert.Error(t, LoadKeySet(dir), "a public key cannot be the active key")
+}
@@ -1,15 +1,21 @@
 package utils
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"math/big"
 )
 
 const digits = "0123456789"
 
 func RandomOTP() string {
 	var otp string
+	max := big.NewInt(int64(len(digits)))
 	for i := 0; i < 6; i++ {
-		otp += string(digits[rand.Intn(len(digits))])
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		otp += string(digits[n.Int64()])
 	}
 	return otp
-}
\ No newline at end of file
+}
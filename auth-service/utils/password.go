@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrPasswordMismatch is returned by VerifyPassword when the password
+	// does not match the hash, whichever algorithm produced it.
+	ErrPasswordMismatch = errors.New("password does not match")
+	// ErrUnknownPasswordHash is returned for a hash no hasher recognizes.
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+// PasswordHasher hashes passwords with one algorithm.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify returns ErrPasswordMismatch when password does not match hash.
+	Verify(hash string, password string) error
+	// Recognizes reports whether hash was produced by this algorithm.
+	Recognizes(hash string) bool
+	// NeedsRehash reports whether a hash this hasher recognizes was made
+	// with other parameters than the hasher's own.
+	NeedsRehash(hash string) bool
+}
+
+// Argon2idParams are the cost parameters of Argon2id. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the RFC 9106 recommendation for memory
+// constrained environments: 64 MiB and three passes.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+const argon2idPrefix = "$argon2id$"
+
+// Minimum salt and key lengths accepted in a stored hash. A shorter key would
+// make almost any password match.
+const (
+	minArgon2idSaltLength = 8
+	minArgon2idKeyLength  = 16
+)
+
+// Argon2idHasher stores hashes in the PHC string format,
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>,
+// with salt and key base64 encoded without padding.
+type Argon2idHasher struct {
+	Params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{Params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.Params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Params.Iterations, h.Params.Memory, h.Params.Parallelism, h.Params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version,
+		h.Params.Memory, h.Params.Iterations, h.Params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(hash string, password string) error {
+	params, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := parseArgon2id(hash)
+	return err != nil || params != h.Params
+}
+
+func parseArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters %q: %w", parts[3], err)
+	}
+	// argon2.IDKey panics when parallelism is zero.
+	if params.Iterations < 1 || params.Parallelism < 1 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	if len(salt) < minArgon2idSaltLength || len(key) < minArgon2idKeyLength {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// BcryptHasher verifies the bcrypt hashes stored before Argon2id became the
+// default, and can still be chosen to hash new passwords.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h BcryptHasher) Verify(hash string, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// passwordHasher hashes new passwords, configurable through
+// InitPasswordHasher. Hashes made by any of the known hashers still verify.
+var passwordHasher PasswordHasher = NewArgon2idHasher(DefaultArgon2idParams)
+
+func InitPasswordHasher(hasher PasswordHasher) {
+	passwordHasher = hasher
+}
+
+func knownHashers() []PasswordHasher {
+	return []PasswordHasher{
+		passwordHasher,
+		NewArgon2idHasher(DefaultArgon2idParams),
+		BcryptHasher{Cost: bcrypt.DefaultCost},
+	}
+}
+
+// HashPassword hashes password with the configured hasher.
+func HashPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
+// VerifyPassword checks password against hash, made by any known hasher. On
+// a match, rehash reports whether the hash should be replaced by one from
+// HashPassword because it uses another algorithm or other parameters. An
+// empty hash, as stored for accounts created through social login, never
+// matches.
+func VerifyPassword(hash string, password string) (rehash bool, err error) {
+	if hash == "" {
+		return false, ErrPasswordMismatch
+	}
+	for _, hasher := range knownHashers() {
+		if !hasher.Recognizes(hash) {
+			continue
+		}
+		if err := hasher.Verify(hash, password); err != nil {
+			return false, err
+		}
+		return !passwordHasher.Recognizes(hash) || passwordHasher.NeedsRehash(hash), nil
+	}
+	return false, ErrUnknownPasswordHash
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func useHasher(t *testing.T, hasher PasswordHasher) {
+	previous := passwordHasher
+	InitPasswordHasher(hasher)
+	t.Cleanup(func() { InitPasswordHasher(previous) })
+}
+
+func TestArgon2idHasher_PHCFormat(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	hash, err := hasher.Hash("Passw0rd")
+	require.NoError(t, err)
+
+	parts := strings.Split(hash, "$")
+	require.Len(t, parts, 6)
+	assert.Equal(t, []string{"", "argon2id", "v=19", "m=64,t=1,p=1"}, parts[:4])
+	assert.Len(t, parts[4], 22)
+	assert.Len(t, parts[5], 43)
+
+	assert.NoError(t, hasher.Verify(hash, "Passw0rd"))
+	assert.ErrorIs(t, hasher.Verify(hash, "passw0rd"), ErrPasswordMismatch)
+
+	other, err := hasher.Hash("Passw0rd")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+}
+
+func TestArgon2idHasher_NeedsRehash(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+	hash, err := hasher.Hash("Passw0rd")
+	require.NoError(t, err)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	assert.True(t, NewArgon2idHasher(stronger).NeedsRehash(hash))
+	// A hash made with other parameters still verifies.
+	assert.NoError(t, NewArgon2idHasher(stronger).Verify(hash, "Passw0rd"))
+}
+
+func TestArgon2idHasher_RejectsMalformedHash(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+
+	for _, hash := range []string{
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5",
+	} {
+		err := hasher.Verify(hash, "Passw0rd")
+		assert.Error(t, err, hash)
+		assert.NotErrorIs(t, err, ErrPasswordMismatch, hash)
+	}
+}
+
+func TestArgon2idHasher_RejectsUnsafeParameters(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+
+	for _, hash := range []string{
+		"$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$" + key,
+	} {
+		assert.ErrorIs(t, hasher.Verify(hash, "Passw0rd"), ErrUnknownPasswordHash, hash)
+	}
+}
+
+func TestVerifyPassword_LegacyBcrypt(t *testing.T) {
+	useHasher(t, NewArgon2idHasher(testArgon2idParams))
+	hash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	rehash, err := VerifyPassword(string(hash), "123456")
+	assert.NoError(t, err)
+	assert.True(t, rehash)
+
+	_, err = VerifyPassword(string(hash), "654321")
+	assert.ErrorIs(t, err, ErrPasswordMismatch)
+}
+
+func TestVerifyPassword_CurrentHash(t *testing.T) {
+	useHasher(t, NewArgon2idHasher(testArgon2idParams))
+	hash, err := HashPassword("Passw0rd")
+	require.NoError(t, err)
+
+	rehash, err := VerifyPassword(hash, "Passw0rd")
+	assert.NoError(t, err)
+	assert.False(t, rehash)
+
+	stronger := testArgon2idParams
+	stronger.Memory = 128
+	useHasher(t, NewArgon2idHasher(stronger))
+	rehash, err = VerifyPassword(hash, "Passw0rd")
+	assert.NoError(t, err)
+	assert.True(t, rehash)
+}
+
+func TestVerifyPassword_BcryptConfigured(t *testing.T) {
+	useHasher(t, BcryptHasher{Cost: bcrypt.MinCost})
+	hash, err := HashPassword("Passw0rd")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2a$"))
+
+	rehash, err := VerifyPassword(hash, "Passw0rd")
+	assert.NoError(t, err)
+	assert.False(t, rehash)
+
+	argon2idHash, err := NewArgon2idHasher(testArgon2idParams).Hash("Passw0rd")
+	require.NoError(t, err)
+	rehash, err = VerifyPassword(argon2idHash, "Passw0rd")
+	assert.NoError(t, err)
+	assert.True(t, rehash)
+}
+
+func TestVerifyPassword_NoOrUnknownHash(t *testing.T) {
+	_, err := VerifyPassword("", "")
+	assert.ErrorIs(t, err, ErrPasswordMismatch)
+
+	_, err = VerifyPassword("$scrypt$ln=15,r=8,p=1$c2FsdA$a2V5", "Passw0rd")
+	assert.ErrorIs(t, err, ErrUnknownPasswordHash)
+}
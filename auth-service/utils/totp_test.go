@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238 appendix B, base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		code, err := TOTPCode(rfc6238Secret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, want, code, "time %d", unix)
+	}
+}
+
+func TestValidateTOTP_AllowsOneStepOfSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, err := TOTPCode(rfc6238Secret, now.Add(-TOTPPeriod))
+	require.NoError(t, err)
+
+	step, ok := ValidateTOTP(rfc6238Secret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	old, err := TOTPCode(rfc6238Secret, now.Add(-2*TOTPPeriod))
+	require.NoError(t, err)
+	_, ok = ValidateTOTP(rfc6238Secret, old, now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("HealthMate", "a@example.com", "ABC")
+	assert.Equal(t, "otpauth://totp/HealthMate:a@example.com?algorithm=SHA1&digits=6&issuer=HealthMate&period=30&secret=ABC", uri)
+}
+
+func TestEncryptSecret_RoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	sealed, err := EncryptSecret(key, "secret")
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "secret")
+
+	plaintext, err := DecryptSecret(key, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", plaintext)
+
+	key[0] = 1
+	_, err = DecryptSecret(key, sealed)
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+}